@@ -0,0 +1,246 @@
+package srclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AuthProvider authenticates the requests sent to Schema Registry,
+// usually by setting their Authorization header. It is called for
+// every request, so providers that fetch tokens should cache them.
+type AuthProvider interface {
+	Authenticate(req *http.Request) error
+}
+
+// AuthRefresher is implemented by the AuthProviders whose credentials
+// can change over time. When Schema Registry rejects a request as
+// unauthorized, the client calls Refresh and sends the request again,
+// once, so that rotated credentials are picked up right away.
+type AuthRefresher interface {
+	Refresh() error
+}
+
+// WithAuthProvider makes the client authenticate its requests with
+// the given provider, instead of the credentials of WithCredentials.
+func WithAuthProvider(provider AuthProvider) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		client.authProvider = provider
+	})
+}
+
+// WithBearerToken makes the client authenticate its requests
+// with the given bearer token, which never changes.
+func WithBearerToken(token string) Option {
+	return WithAuthProvider(BearerToken(token))
+}
+
+// BearerToken is an AuthProvider that sets a static bearer token.
+type BearerToken string
+
+// Authenticate sets the token in the Authorization header.
+func (token BearerToken) Authenticate(req *http.Request) error {
+	req.Header.Set("Authorization", "Bearer "+string(token))
+	return nil
+}
+
+// defaultRefreshMargin is how long before they expire tokens are
+// refreshed, so that requests are never sent with expired tokens.
+const defaultRefreshMargin = 30 * time.Second
+
+// OAuthProvider is an AuthProvider that gets bearer tokens from an
+// OAuth2 token endpoint with the client credentials grant. Tokens are
+// fetched on the first request, cached, and fetched again shortly
+// before they expire. A single token is fetched at a time, and while
+// it is, requests go on with the current token until it expires.
+type OAuthProvider struct {
+	tokenURL      string
+	clientID      string
+	clientSecret  string
+	scopes        []string
+	httpClient    *http.Client
+	refreshMargin time.Duration
+	now           func() time.Time
+
+	token     string
+	refreshAt time.Time
+	expiresAt time.Time
+	fetch     *tokenFetch
+	tokenLock sync.Mutex
+}
+
+// tokenFetch is a request for a token, which the requests
+// that have no valid token to go on with wait for.
+type tokenFetch struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+// OAuthOption configures an OAuthProvider.
+type OAuthOption func(*OAuthProvider)
+
+// WithOAuthScopes sets the scopes requested along with tokens.
+func WithOAuthScopes(scopes ...string) OAuthOption {
+	return OAuthOption(func(provider *OAuthProvider) {
+		provider.scopes = scopes
+	})
+}
+
+// WithOAuthHTTPClient sets the client used to call the token endpoint.
+func WithOAuthHTTPClient(httpClient *http.Client) OAuthOption {
+	return OAuthOption(func(provider *OAuthProvider) {
+		provider.httpClient = httpClient
+	})
+}
+
+// WithOAuthRefreshMargin sets how long before they expire tokens are
+// refreshed, which is 30 seconds by default. Tokens that live less
+// than twice the margin are refreshed halfway through their life.
+func WithOAuthRefreshMargin(margin time.Duration) OAuthOption {
+	return OAuthOption(func(provider *OAuthProvider) {
+		provider.refreshMargin = margin
+	})
+}
+
+// NewOAuthProvider creates an OAuthProvider that gets tokens from the
+// given token endpoint, authenticating with the client ID and secret.
+func NewOAuthProvider(tokenURL, clientID, clientSecret string, opts ...OAuthOption) *OAuthProvider {
+	provider := &OAuthProvider{
+		tokenURL:      tokenURL,
+		clientID:      clientID,
+		clientSecret:  clientSecret,
+		httpClient:    &http.Client{Timeout: 5 * time.Second},
+		refreshMargin: defaultRefreshMargin,
+		now:           time.Now,
+	}
+	for _, opt := range opts {
+		opt(provider)
+	}
+	return provider
+}
+
+// Authenticate sets the current token in the Authorization header,
+// fetching a new one when there is none or it is about to expire.
+func (provider *OAuthProvider) Authenticate(req *http.Request) error {
+	token, err := provider.Token()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// Token returns the current token, fetching a new one
+// when there is none or it is about to expire.
+func (provider *OAuthProvider) Token() (string, error) {
+	provider.tokenLock.Lock()
+	now := provider.now()
+	token := provider.token
+	valid := len(token) > 0 && (provider.expiresAt.IsZero() || now.Before(provider.expiresAt))
+	if valid && (provider.refreshAt.IsZero() || now.Before(provider.refreshAt)) {
+		provider.tokenLock.Unlock()
+		return token, nil
+	}
+	fetch := provider.fetch
+	if fetch == nil {
+		fetch = &tokenFetch{done: make(chan struct{})}
+		provider.fetch = fetch
+		provider.tokenLock.Unlock()
+		provider.fetchToken(fetch)
+		return fetch.token, fetch.err
+	}
+	provider.tokenLock.Unlock()
+
+	// Another request is fetching a token already.
+	if valid {
+		return token, nil
+	}
+	<-fetch.done
+	return fetch.token, fetch.err
+}
+
+// fetchToken requests a token, without holding the lock,
+// and hands it to the requests waiting for it.
+func (provider *OAuthProvider) fetchToken(fetch *tokenFetch) {
+	token, expiresIn, err := provider.requestToken()
+
+	provider.tokenLock.Lock()
+	if err == nil {
+		now := provider.now()
+		provider.token = token
+		provider.refreshAt, provider.expiresAt = time.Time{}, time.Time{}
+		if expiresIn > 0 {
+			margin := provider.refreshMargin
+			if margin > expiresIn/2 {
+				margin = expiresIn / 2
+			}
+			provider.refreshAt = now.Add(expiresIn - margin)
+			provider.expiresAt = now.Add(expiresIn)
+		}
+	}
+	provider.fetch = nil
+	provider.tokenLock.Unlock()
+
+	fetch.token, fetch.err = token, err
+	close(fetch.done)
+}
+
+// Refresh drops the current token, so that a new one
+// is fetched for the next request.
+func (provider *OAuthProvider) Refresh() error {
+	provider.tokenLock.Lock()
+	defer provider.tokenLock.Unlock()
+	provider.token = ""
+	return nil
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+func (provider *OAuthProvider) requestToken() (string, time.Duration, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(provider.scopes) > 0 {
+		form.Set("scope", strings.Join(provider.scopes, " "))
+	}
+	req, err := http.NewRequest("POST", provider.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", 0, err
+	}
+	req.SetBasicAuth(url.QueryEscape(provider.clientID), url.QueryEscape(provider.clientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := provider.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("unable to get a token: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", 0, fmt.Errorf("unable to get a token: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", 0, fmt.Errorf("unable to get a token: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	tokenResp := new(tokenResponse)
+	if err := json.Unmarshal(body, tokenResp); err != nil {
+		return "", 0, fmt.Errorf("invalid token response: %w", err)
+	}
+	if len(tokenResp.AccessToken) == 0 {
+		return "", 0, fmt.Errorf("invalid token response: no access token")
+	}
+	if len(tokenResp.TokenType) > 0 && !strings.EqualFold(tokenResp.TokenType, "bearer") {
+		return "", 0, fmt.Errorf("invalid token response: unsupported token type %s", tokenResp.TokenType)
+	}
+	return tokenResp.AccessToken, time.Duration(tokenResp.ExpiresIn) * time.Second, nil
+}
@@ -0,0 +1,178 @@
+package srclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// tokenServer is a stand-in OAuth2 token endpoint that hands
+// out numbered tokens living for the given number of seconds.
+func tokenServer(t *testing.T, expiresIn int) (*httptest.Server, *int) {
+	issued := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.NoError(t, req.ParseForm())
+		assert.Equal(t, "client_credentials", req.PostForm.Get("grant_type"))
+		clientID, clientSecret, ok := req.BasicAuth()
+		if !ok || clientID != "client" || clientSecret != "secret" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			rw.Write([]byte(`{"error": "invalid_client"}`))
+			return
+		}
+		issued++
+		fmt.Fprintf(rw, `{"access_token": "token-%d", "token_type": "Bearer", "expires_in": %d}`, issued, expiresIn)
+	}))
+	return server, &issued
+}
+
+func TestAuth_BearerToken(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "Bearer static", req.Header.Get("Authorization"))
+		rw.Write([]byte(`["test1"]`))
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL, WithCredentials("user", "password"), WithBearerToken("static"))
+	subjects, err := srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test1"}, subjects)
+}
+
+func TestAuth_OAuthProvider(t *testing.T) {
+	tokens, issued := tokenServer(t, 300)
+	defer tokens.Close()
+
+	var authorizations []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		authorizations = append(authorizations, req.Header.Get("Authorization"))
+		rw.Write([]byte(`["test1"]`))
+	}))
+	defer server.Close()
+
+	now := time.Now()
+	provider := NewOAuthProvider(tokens.URL, "client", "secret", WithOAuthScopes("registry:read", "registry:write"))
+	provider.now = func() time.Time { return now }
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL, WithAuthProvider(provider))
+
+	for i := 0; i < 3; i++ {
+		_, err := srClient.GetSubjects()
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, 1, *issued)
+
+	// Tokens are refreshed 30 seconds before they expire.
+	now = now.Add(269 * time.Second)
+	_, err := srClient.GetSubjects()
+	assert.NoError(t, err)
+	now = now.Add(time.Second)
+	_, err = srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, *issued)
+	assert.Equal(t, []string{"Bearer token-1", "Bearer token-1", "Bearer token-1", "Bearer token-1", "Bearer token-2"}, authorizations)
+}
+
+func TestAuth_OAuthProviderShortLivedTokens(t *testing.T) {
+	tokens, issued := tokenServer(t, 20)
+	defer tokens.Close()
+
+	now := time.Now()
+	provider := NewOAuthProvider(tokens.URL, "client", "secret")
+	provider.now = func() time.Time { return now }
+	token, err := provider.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	// Tokens living less than twice the margin are refreshed halfway.
+	now = now.Add(9 * time.Second)
+	token, _ = provider.Token()
+	assert.Equal(t, "token-1", token)
+	now = now.Add(time.Second)
+	token, _ = provider.Token()
+	assert.Equal(t, "token-2", token)
+	assert.Equal(t, 2, *issued)
+}
+
+func TestAuth_OAuthProviderSlowRefresh(t *testing.T) {
+	var issued int32
+	requested := make(chan struct{}, 10)
+	release := make(chan struct{})
+	tokens := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if n := atomic.AddInt32(&issued, 1); n > 1 {
+			requested <- struct{}{}
+			<-release
+		}
+		fmt.Fprintf(rw, `{"access_token": "token-%d", "expires_in": 300}`, atomic.LoadInt32(&issued))
+	}))
+	defer tokens.Close()
+
+	start := time.Now()
+	var now int64
+	provider := NewOAuthProvider(tokens.URL, "client", "secret")
+	provider.now = func() time.Time { return start.Add(time.Duration(atomic.LoadInt64(&now))) }
+	token, err := provider.Token()
+	assert.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	// While a token is fetched, requests go on with the current one.
+	atomic.StoreInt64(&now, int64(280*time.Second))
+	refreshed := make(chan string)
+	go func() {
+		token, _ := provider.Token()
+		refreshed <- token
+	}()
+	<-requested
+	for i := 0; i < 3; i++ {
+		token, err = provider.Token()
+		assert.NoError(t, err)
+		assert.Equal(t, "token-1", token)
+	}
+	release <- struct{}{}
+	assert.Equal(t, "token-2", <-refreshed)
+
+	// Once the token expired, requests wait for a single new one.
+	atomic.StoreInt64(&now, int64(600*time.Second))
+	var wg sync.WaitGroup
+	results := make([]string, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = provider.Token()
+		}(i)
+	}
+	<-requested
+	close(release)
+	wg.Wait()
+	assert.Equal(t, []string{"token-3", "token-3", "token-3", "token-3", "token-3"}, results)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&issued))
+}
+
+func TestAuth_OAuthProviderErrors(t *testing.T) {
+	tokens, _ := tokenServer(t, 300)
+	defer tokens.Close()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL, WithAuthProvider(NewOAuthProvider(tokens.URL, "client", "wrong")))
+	_, err := srClient.GetSubjects()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.Equal(t, 0, requests)
+
+	invalid := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte(`{"access_token": "abc", "token_type": "mac"}`))
+	}))
+	defer invalid.Close()
+	_, err = NewOAuthProvider(invalid.URL, "client", "secret").Token()
+	assert.Error(t, err)
+}
@@ -0,0 +1,205 @@
+package srclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// avroChecker applies the schema resolution rules of the Avro
+// specification to decide if data written with one schema can
+// be read with another, like Schema Registry does for Avro.
+type avroChecker struct{}
+
+func (avroChecker) parse(schema string) (interface{}, error) {
+	return parseAvroSchema(schema)
+}
+
+func (avroChecker) check(reader, writer interface{}, newIsReader bool) []Incompatibility {
+	state := &avroCompatibility{
+		newIsReader: newIsReader,
+		visiting:    map[[2]*avroType]bool{},
+	}
+	state.check(reader.(*avroType), writer.(*avroType), schemaPath{})
+	return state.found
+}
+
+type avroCompatibility struct {
+	newIsReader bool
+	// visiting guards against infinite recursion on recursive
+	// schemas: a pair already being checked is assumed to be
+	// compatible, the actual answer is given up the stack.
+	visiting map[[2]*avroType]bool
+	found    []Incompatibility
+}
+
+func (state *avroCompatibility) report(incompatibilityType IncompatibilityType, path schemaPath, additionalInfo, description string) {
+	state.found = append(state.found, Incompatibility{
+		Type:           incompatibilityType,
+		Path:           path.String(),
+		Description:    description,
+		AdditionalInfo: additionalInfo,
+	})
+}
+
+// compatible tells if reader can read writer without recording
+// any incompatibility, which is needed to pick union branches.
+func (state *avroCompatibility) compatible(reader, writer *avroType) bool {
+	probe := &avroCompatibility{newIsReader: state.newIsReader, visiting: state.visiting}
+	probe.check(reader, writer, schemaPath{})
+	return len(probe.found) == 0
+}
+
+func (state *avroCompatibility) check(reader, writer *avroType, path schemaPath) {
+	pair := [2]*avroType{reader, writer}
+	if state.visiting[pair] {
+		return
+	}
+	state.visiting[pair] = true
+	defer delete(state.visiting, pair)
+
+	readerAge, writerAge := ages(state.newIsReader)
+
+	if reader.kind() == writer.kind() {
+		switch reader.kind() {
+		case "array":
+			state.check(reader.items, writer.items, path.child("items"))
+		case "map":
+			state.check(reader.values, writer.values, path.child("values"))
+		case "fixed":
+			if !state.checkName(reader, writer, path) {
+				return
+			}
+			if reader.size != writer.size {
+				state.report(FixedSizeMismatch, path.child("size"), fmt.Sprintf("expected: %d, found: %d", writer.size, reader.size),
+					fmt.Sprintf("The size of FIXED type field at path '%s' in the %s schema does not match with the %s schema", path.child("size"), readerAge, writerAge))
+			}
+		case "enum":
+			if !state.checkName(reader, writer, path) {
+				return
+			}
+			state.checkSymbols(reader, writer, path)
+		case "record":
+			if !state.checkName(reader, writer, path) {
+				return
+			}
+			state.checkFields(reader, writer, path)
+		case "union":
+			for i, branch := range writer.branches {
+				state.check(reader, branch, path.child(strconv.Itoa(i)))
+			}
+		}
+		return
+	}
+
+	if writer.kind() == "union" {
+		for i, branch := range writer.branches {
+			state.check(reader, branch, path.child(strconv.Itoa(i)))
+		}
+		return
+	}
+
+	switch reader.kind() {
+	case "long":
+		if writer.kind() == "int" {
+			return
+		}
+	case "float":
+		if writer.kind() == "int" || writer.kind() == "long" {
+			return
+		}
+	case "double":
+		if writer.kind() == "int" || writer.kind() == "long" || writer.kind() == "float" {
+			return
+		}
+	case "bytes":
+		if writer.kind() == "string" {
+			return
+		}
+	case "string":
+		if writer.kind() == "bytes" {
+			return
+		}
+	case "union":
+		for _, branch := range reader.branches {
+			if state.compatible(branch, writer) {
+				return
+			}
+		}
+		state.report(MissingUnionBranch, path, fmt.Sprintf("reader union lacking writer type: %s", strings.ToUpper(writer.kind())),
+			fmt.Sprintf("The %s schema is missing a type inside a union field at path '%s' in the %s schema", readerAge, path, writerAge))
+		return
+	}
+
+	state.report(TypeMismatch, path, fmt.Sprintf("reader type: %s not compatible with writer type: %s", strings.ToUpper(reader.kind()), strings.ToUpper(writer.kind())),
+		fmt.Sprintf("The type (path '%s') of a field in the %s schema does not match with the %s schema", path, readerAge, writerAge))
+}
+
+// checkName verifies that named types match, either by their full
+// name or because the reader declares the writer name as an alias.
+func (state *avroCompatibility) checkName(reader, writer *avroType, path schemaPath) bool {
+	if reader.fullName() == writer.fullName() {
+		return true
+	}
+	for _, alias := range reader.aliases {
+		if alias == writer.fullName() {
+			return true
+		}
+	}
+	readerAge, writerAge := ages(state.newIsReader)
+	state.report(NameMismatch, path.child("name"), fmt.Sprintf("expected: %s", writer.fullName()),
+		fmt.Sprintf("The name of the schema has changed (path '%s') in the %s schema when compared to the %s schema", path.child("name"), readerAge, writerAge))
+	return false
+}
+
+func (state *avroCompatibility) checkSymbols(reader, writer *avroType, path schemaPath) {
+	if reader.enumDefault != nil {
+		// Unknown symbols are read as the default.
+		return
+	}
+	known := map[string]bool{}
+	for _, symbol := range reader.symbols {
+		known[symbol] = true
+	}
+	var missing []string
+	for _, symbol := range writer.symbols {
+		if !known[symbol] {
+			missing = append(missing, symbol)
+		}
+	}
+	if len(missing) > 0 {
+		readerAge, writerAge := ages(state.newIsReader)
+		state.report(MissingEnumSymbols, path.child("symbols"), fmt.Sprintf("[%s]", strings.Join(missing, ", ")),
+			fmt.Sprintf("The %s schema is missing enum symbols '[%s]' at path '%s' in the %s schema", readerAge, strings.Join(missing, ", "), path.child("symbols"), writerAge))
+	}
+}
+
+func (state *avroCompatibility) checkFields(reader, writer *avroType, path schemaPath) {
+	readerAge, writerAge := ages(state.newIsReader)
+	for i, readerField := range reader.fields {
+		fieldPath := path.child("fields", strconv.Itoa(i))
+		writerField := lookupWriterField(writer, readerField)
+		if writerField == nil {
+			if !readerField.hasDefault {
+				state.report(ReaderFieldMissingDefaultValue, fieldPath, readerField.name,
+					fmt.Sprintf("The field '%s' at path '%s' in the %s schema has no default value and is missing in the %s schema", readerField.name, fieldPath, readerAge, writerAge))
+			}
+			continue
+		}
+		state.check(readerField.typ, writerField.typ, fieldPath.child("type"))
+	}
+}
+
+// lookupWriterField finds the writer field that feeds the given
+// reader field, either by name or by one of the reader aliases.
+func lookupWriterField(writer *avroType, readerField *avroField) *avroField {
+	if field := writer.field(readerField.name); field != nil {
+		return field
+	}
+	for _, alias := range readerField.aliases {
+		if field := writer.field(alias); field != nil {
+			return field
+		}
+	}
+	return nil
+}
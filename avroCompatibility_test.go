@@ -0,0 +1,139 @@
+package srclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const avroUserV1 = `{
+	"type": "record", "name": "User", "namespace": "com.example",
+	"fields": [
+		{"name": "id", "type": "int"},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "INACTIVE"]}}
+	]
+}`
+
+func TestCheckCompatibility_Avro(t *testing.T) {
+	cases := []struct {
+		name     string
+		level    CompatibilityLevel
+		schema   string
+		expected []IncompatibilityType
+		paths    []string
+	}{
+		{
+			name:  "field added with default and type promoted",
+			level: Full,
+			schema: `{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+				{"name": "id", "type": "long"},
+				{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "INACTIVE"]}},
+				{"name": "email", "type": ["null", "string"], "default": null}
+			]}`,
+			expected: []IncompatibilityType{TypeMismatch},
+			paths:    []string{"/fields/0/type"},
+		},
+		{
+			name:  "field added without default",
+			level: Backward,
+			schema: `{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+				{"name": "id", "type": "long"},
+				{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "INACTIVE"]}},
+				{"name": "email", "type": "string"}
+			]}`,
+			expected: []IncompatibilityType{ReaderFieldMissingDefaultValue},
+			paths:    []string{"/fields/2"},
+		},
+		{
+			name:  "enum symbol removed",
+			level: Backward,
+			schema: `{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+				{"name": "id", "type": "int"},
+				{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE"]}}
+			]}`,
+			expected: []IncompatibilityType{MissingEnumSymbols},
+			paths:    []string{"/fields/1/type/symbols"},
+		},
+		{
+			name:  "enum symbol removed with enum default",
+			level: Backward,
+			schema: `{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+				{"name": "id", "type": "int"},
+				{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE"], "default": "ACTIVE"}}
+			]}`,
+		},
+		{
+			name:  "field narrowed to a union lacking the old type",
+			level: Backward,
+			schema: `{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+				{"name": "id", "type": ["null", "string"]},
+				{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "INACTIVE"]}}
+			]}`,
+			expected: []IncompatibilityType{MissingUnionBranch},
+			paths:    []string{"/fields/0/type"},
+		},
+		{
+			name:     "record renamed",
+			level:    Forward,
+			schema:   `{"type": "record", "name": "Person", "namespace": "com.example", "fields": [{"name": "id", "type": "int"}]}`,
+			expected: []IncompatibilityType{NameMismatch},
+			paths:    []string{"/name"},
+		},
+		{
+			name:   "anything goes without compatibility",
+			level:  None,
+			schema: `"string"`,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := CheckCompatibility(Avro, c.level, c.schema, avroUserV1)
+			assert.NoError(t, err)
+			assert.Equal(t, len(c.expected) == 0, result.IsCompatible)
+			var types []IncompatibilityType
+			var paths []string
+			for _, incompatibility := range result.Incompatibilities {
+				types = append(types, incompatibility.Type)
+				paths = append(paths, incompatibility.Path)
+			}
+			assert.Equal(t, c.expected, types)
+			assert.Equal(t, c.paths, paths)
+		})
+	}
+}
+
+func TestCheckCompatibility_AvroTransitive(t *testing.T) {
+	v2 := `{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+		{"name": "id", "type": "int"},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "INACTIVE"]}},
+		{"name": "email", "type": "string", "default": ""}
+	]}`
+	// Dropping the default of email is fine against v2, which has the
+	// field, but not against v1, which doesn't.
+	v3 := `{"type": "record", "name": "User", "namespace": "com.example", "fields": [
+		{"name": "id", "type": "int"},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["ACTIVE", "INACTIVE"]}},
+		{"name": "email", "type": "string"}
+	]}`
+
+	result, err := CheckCompatibility(Avro, Backward, v3, avroUserV1, v2)
+	assert.NoError(t, err)
+	assert.True(t, result.IsCompatible)
+
+	result, err = CheckCompatibility(Avro, BackwardTransitive, v3, avroUserV1, v2)
+	assert.NoError(t, err)
+	assert.False(t, result.IsCompatible)
+	assert.Equal(t, "{errorType:'READER_FIELD_MISSING_DEFAULT_VALUE', description:'The field 'email' at path '/fields/2' in the new schema has no default value and is missing in the old schema', additionalInfo:'email'}", result.Messages[0])
+	assert.Equal(t, "{compatibility: 'BACKWARD_TRANSITIVE'}", result.Messages[len(result.Messages)-1])
+}
+
+func TestCheckCompatibility_AvroRecursive(t *testing.T) {
+	list := `{"type": "record", "name": "Node", "fields": [
+		{"name": "value", "type": "int"},
+		{"name": "next", "type": ["null", "Node"], "default": null}
+	]}`
+	result, err := CheckCompatibility(Avro, FullTransitive, list, list)
+	assert.NoError(t, err)
+	assert.True(t, result.IsCompatible)
+}
@@ -0,0 +1,229 @@
+package srclient
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+)
+
+// appendAvro appends the binary encoding of a native value of the given
+// type to buf. It accepts the values goavro accepts, such as unions
+// written as nil or as a map from the name of the branch to its value,
+// along with the Go values of the logical types that are mapped and
+// the values of their underlying types.
+func appendAvro(buf []byte, t *avroType, logicalTypes logicalTypeMapping, value interface{}) ([]byte, error) {
+	switch t.kind() {
+	case "union":
+		return appendAvroUnion(buf, t, logicalTypes, value)
+	case "record":
+		record, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected a value of the record %s, found %T", t, value)
+		}
+		for _, field := range t.fields {
+			fieldValue, ok := record[field.name]
+			if !ok {
+				if !field.hasDefault {
+					return nil, fmt.Errorf("missing field %s of %s, which has no default", field.name, t)
+				}
+				var err error
+				if fieldValue, err = avroDefaultNative(field.typ, field.defaultValue, logicalTypes); err != nil {
+					return nil, fmt.Errorf("invalid default of the field %s of %s: %w", field.name, t, err)
+				}
+			}
+			var err error
+			if buf, err = appendAvro(buf, field.typ, logicalTypes, fieldValue); err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.name, err)
+			}
+		}
+		return buf, nil
+	case "enum":
+		symbol, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a symbol of the enum %s, found %T", t, value)
+		}
+		for i, known := range t.symbols {
+			if known == symbol {
+				return appendAvroLong(buf, int64(i)), nil
+			}
+		}
+		return nil, fmt.Errorf("%s isn't a symbol of the enum %s", symbol, t)
+	case "array":
+		items := reflect.ValueOf(value)
+		if items.Kind() != reflect.Slice && items.Kind() != reflect.Array {
+			return nil, fmt.Errorf("expected an array, found %T", value)
+		}
+		if items.Len() > 0 {
+			buf = appendAvroLong(buf, int64(items.Len()))
+			for i := 0; i < items.Len(); i++ {
+				var err error
+				if buf, err = appendAvro(buf, t.items, logicalTypes, items.Index(i).Interface()); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return appendAvroLong(buf, 0), nil
+	case "map":
+		values := reflect.ValueOf(value)
+		if values.Kind() != reflect.Map || values.Type().Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("expected a map with string keys, found %T", value)
+		}
+		if values.Len() > 0 {
+			buf = appendAvroLong(buf, int64(values.Len()))
+			iter := values.MapRange()
+			for iter.Next() {
+				var err error
+				buf = appendAvroBytes(buf, []byte(iter.Key().String()))
+				if buf, err = appendAvro(buf, t.values, logicalTypes, iter.Value().Interface()); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return appendAvroLong(buf, 0), nil
+	}
+
+	raw, err := logicalTypes.raw(t, value)
+	if err != nil {
+		return nil, err
+	}
+	return appendAvroPrimitive(buf, t, raw)
+}
+
+// appendAvroUnion writes nil with the null branch, and maps holding one
+// value with the branch they name. Other values are written with the
+// first branch that accepts them.
+func appendAvroUnion(buf []byte, t *avroType, logicalTypes logicalTypeMapping, value interface{}) ([]byte, error) {
+	if value == nil {
+		for i, branch := range t.branches {
+			if branch.typ == "null" {
+				return appendAvroLong(buf, int64(i)), nil
+			}
+		}
+		return nil, fmt.Errorf("the union %s isn't nullable", t)
+	}
+	if named, ok := value.(map[string]interface{}); ok && len(named) == 1 {
+		for name, branchValue := range named {
+			for i, branch := range t.branches {
+				if name == goavroTypeName(branch) || name == branch.typ || (branch.isNamed() && name == branch.name) {
+					return appendAvro(appendAvroLong(buf, int64(i)), branch, logicalTypes, branchValue)
+				}
+			}
+		}
+	}
+	for i, branch := range t.branches {
+		if branch.typ == "null" {
+			continue
+		}
+		if encoded, err := appendAvro(appendAvroLong(buf, int64(i)), branch, logicalTypes, value); err == nil {
+			return encoded, nil
+		}
+	}
+	return nil, fmt.Errorf("no branch of the union %s accepts %T", t, value)
+}
+
+func appendAvroPrimitive(buf []byte, t *avroType, value interface{}) ([]byte, error) {
+	switch t.kind() {
+	case "null":
+		if value != nil {
+			return nil, fmt.Errorf("expected null, found %T", value)
+		}
+		return buf, nil
+	case "boolean":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a boolean, found %T", value)
+		}
+		if b {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case "int", "long":
+		i, err := avroInteger(value)
+		if err != nil {
+			return nil, err
+		}
+		if t.kind() == "int" && (i < math.MinInt32 || i > math.MaxInt32) {
+			return nil, fmt.Errorf("%d overflows the int type", i)
+		}
+		return appendAvroLong(buf, i), nil
+	case "float", "double":
+		f, err := avroFloat(value)
+		if err != nil {
+			return nil, err
+		}
+		if t.kind() == "float" {
+			var b [4]byte
+			binary.LittleEndian.PutUint32(b[:], math.Float32bits(float32(f)))
+			return append(buf, b[:]...), nil
+		}
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(f))
+		return append(buf, b[:]...), nil
+	case "bytes", "string":
+		switch v := value.(type) {
+		case []byte:
+			return appendAvroBytes(buf, v), nil
+		case string:
+			return appendAvroBytes(buf, []byte(v)), nil
+		}
+		return nil, fmt.Errorf("expected a %s, found %T", t.kind(), value)
+	case "fixed":
+		b, ok := value.([]byte)
+		if !ok || len(b) != t.size {
+			return nil, fmt.Errorf("expected %d bytes for the fixed %s, found %T", t.size, t, value)
+		}
+		return append(buf, b...), nil
+	}
+	return nil, fmt.Errorf("unknown avro type %s", t.kind())
+}
+
+// avroInteger accepts any Go number that holds an integer, as goavro does.
+func avroInteger(value interface{}) (int64, error) {
+	v := reflect.ValueOf(value)
+	switch {
+	case !v.IsValid() || v.Type() == durationType:
+	case isInteger(v.Kind()) && !isUnsigned(v.Kind()):
+		return v.Int(), nil
+	case isUnsigned(v.Kind()):
+		if v.Uint() > math.MaxInt64 {
+			return 0, fmt.Errorf("%d overflows the long type", v.Uint())
+		}
+		return int64(v.Uint()), nil
+	case isFloat(v.Kind()):
+		f := v.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return 0, fmt.Errorf("%v isn't an integer", f)
+		}
+		return int64(f), nil
+	}
+	return 0, fmt.Errorf("expected an integer, found %T", value)
+}
+
+func avroFloat(value interface{}) (float64, error) {
+	v := reflect.ValueOf(value)
+	switch {
+	case !v.IsValid():
+	case isFloat(v.Kind()):
+		return v.Float(), nil
+	case isUnsigned(v.Kind()):
+		return float64(v.Uint()), nil
+	case isInteger(v.Kind()):
+		return float64(v.Int()), nil
+	}
+	return 0, fmt.Errorf("expected a number, found %T", value)
+}
+
+// appendAvroLong writes a long as a zigzag encoded varint.
+func appendAvroLong(buf []byte, value int64) []byte {
+	n := uint64(value<<1) ^ uint64(value>>63)
+	for n >= 0x80 {
+		buf = append(buf, byte(n)|0x80)
+		n >>= 7
+	}
+	return append(buf, byte(n))
+}
+
+func appendAvroBytes(buf []byte, value []byte) []byte {
+	return append(appendAvroLong(buf, int64(len(value))), value...)
+}
@@ -0,0 +1,268 @@
+package srclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// GenerateAvroSchema returns an Avro schema for the Go type of the
+// given value, which must be a struct or a pointer to one, in a form
+// ready to be registered with CreateSchema. Data of the type can then
+// be written with Serializer and read with Deserializer.DeserializeInto.
+//
+// Structs become records named after their Go type, and the fields of
+// the records are named by the avro tag, or else by the Go field name.
+// Other tags describe fields further: doc sets their documentation,
+// default their default value, written in JSON, symbols turns string
+// fields into enums (as in `symbols:"NEW,PAID"`), and precision and
+// scale turn *big.Rat fields into decimals. A blank field, as in
+//
+//	_ struct{} `name:"Order" namespace:"com.example" doc:"An order"`
+//
+// sets the name, namespace and documentation of the record itself.
+// Pointers become nullable unions, time.Time a timestamp-millis and
+// time.Duration a time-micros.
+func GenerateAvroSchema(value interface{}) (string, error) {
+	typ := reflect.TypeOf(value)
+	for typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	if typ == nil || typ.Kind() != reflect.Struct {
+		return "", fmt.Errorf("avro schemas can only be generated for structs, not %T", value)
+	}
+	generator := &avroGenerator{defined: map[reflect.Type]string{}, names: map[string]bool{}}
+	schema, err := generator.record(typ, "")
+	if err != nil {
+		return "", err
+	}
+	return canonicalJSON(schema), nil
+}
+
+type avroGenerator struct {
+	// defined holds the full name of the records already written,
+	// which are referenced by name afterwards, and names the full
+	// names used so far, to tell apart Go types with the same name.
+	defined map[reflect.Type]string
+	names   map[string]bool
+}
+
+func (generator *avroGenerator) record(typ reflect.Type, namespace string) (interface{}, error) {
+	if fullName, ok := generator.defined[typ]; ok {
+		return fullName, nil
+	}
+
+	schema := map[string]interface{}{"type": "record", "name": typ.Name()}
+	if marker, ok := typ.FieldByName("_"); ok {
+		if name, ok := marker.Tag.Lookup("name"); ok {
+			schema["name"] = name
+		}
+		if ns, ok := marker.Tag.Lookup("namespace"); ok {
+			namespace = ns
+		}
+		if doc, ok := marker.Tag.Lookup("doc"); ok {
+			schema["doc"] = doc
+		}
+	}
+	if len(schema["name"].(string)) == 0 {
+		return nil, fmt.Errorf("anonymous structs need a name tag to be written as avro records")
+	}
+	if len(namespace) > 0 {
+		schema["namespace"] = namespace
+	}
+	fullName := qualifiedName(schema["name"].(string), namespace)
+	if generator.names[fullName] {
+		return nil, fmt.Errorf("more than one Go type is written as the avro record %s", fullName)
+	}
+	generator.names[fullName] = true
+	generator.defined[typ] = fullName
+
+	fields := []interface{}{}
+	for i := 0; i < typ.NumField(); i++ {
+		structField := typ.Field(i)
+		name := strings.Split(structField.Tag.Get("avro"), ",")[0]
+		if len(structField.PkgPath) > 0 || structField.Name == "_" || name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			name = structField.Name
+		}
+		field, err := generator.field(name, structField, namespace)
+		if err != nil {
+			return nil, fmt.Errorf("field %s of %s: %w", structField.Name, typ, err)
+		}
+		fields = append(fields, field)
+	}
+	schema["fields"] = fields
+	return schema, nil
+}
+
+func (generator *avroGenerator) field(name string, structField reflect.StructField, namespace string) (interface{}, error) {
+	field := map[string]interface{}{"name": name}
+	if doc, ok := structField.Tag.Lookup("doc"); ok {
+		field["doc"] = doc
+	}
+
+	typ := structField.Type
+	nullable := false
+	if typ.Kind() == reflect.Ptr && typ != ratType {
+		typ, nullable = typ.Elem(), true
+	}
+	t, err := generator.typeFor(typ, name, structField.Tag, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	rawDefault, hasDefault := structField.Tag.Lookup("default")
+	var defaultValue interface{}
+	if hasDefault {
+		if defaultValue, err = parseAvroDefault(rawDefault, typ); err != nil {
+			return nil, err
+		}
+	}
+
+	// The default of a union is a value of its first branch.
+	switch {
+	case nullable && (!hasDefault || defaultValue == nil):
+		field["type"] = []interface{}{"null", t}
+		field["default"] = nil
+	case nullable:
+		field["type"] = []interface{}{t, "null"}
+		field["default"] = defaultValue
+	default:
+		field["type"] = t
+		if hasDefault {
+			field["default"] = defaultValue
+		}
+	}
+	return field, nil
+}
+
+// parseAvroDefault reads the default tag, which is written in JSON,
+// except for strings that can be written without quotes.
+func parseAvroDefault(raw string, typ reflect.Type) (interface{}, error) {
+	var value interface{}
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		if typ.Kind() == reflect.String {
+			return raw, nil
+		}
+		return nil, fmt.Errorf("invalid default %q: %w", raw, err)
+	}
+	return value, nil
+}
+
+func (generator *avroGenerator) typeFor(typ reflect.Type, name string, tag reflect.StructTag, namespace string) (interface{}, error) {
+	switch typ {
+	case timeType:
+		return map[string]interface{}{"type": "long", "logicalType": "timestamp-millis"}, nil
+	case durationType:
+		return map[string]interface{}{"type": "long", "logicalType": "time-micros"}, nil
+	case ratType:
+		precision, err := strconv.Atoi(tag.Get("precision"))
+		if err != nil {
+			return nil, fmt.Errorf("decimals need a precision tag")
+		}
+		scale, _ := strconv.Atoi(tag.Get("scale"))
+		return map[string]interface{}{"type": "bytes", "logicalType": "decimal", "precision": precision, "scale": scale}, nil
+	}
+
+	switch typ.Kind() {
+	case reflect.Bool:
+		return "boolean", nil
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return "int", nil
+	case reflect.Int, reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return "long", nil
+	case reflect.Float32:
+		return "float", nil
+	case reflect.Float64:
+		return "double", nil
+	case reflect.String:
+		symbols, ok := tag.Lookup("symbols")
+		if !ok {
+			return "string", nil
+		}
+		enumName := typ.Name()
+		if typ.PkgPath() == "" {
+			enumName = name
+		}
+		return generator.named(typ, map[string]interface{}{
+			"type":    "enum",
+			"name":    enumName,
+			"symbols": strings.Split(symbols, ","),
+		}, namespace)
+	case reflect.Slice:
+		if typ.Elem().Kind() == reflect.Uint8 {
+			return "bytes", nil
+		}
+		items, err := generator.elementType(typ.Elem(), name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "array", "items": items}, nil
+	case reflect.Array:
+		if typ.Elem().Kind() != reflect.Uint8 {
+			return nil, fmt.Errorf("arrays other than byte arrays aren't supported, use a slice")
+		}
+		fixedName := typ.Name()
+		if len(fixedName) == 0 {
+			fixedName = name
+		}
+		return generator.named(typ, map[string]interface{}{"type": "fixed", "name": fixedName, "size": typ.Len()}, namespace)
+	case reflect.Map:
+		if typ.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("maps need string keys")
+		}
+		values, err := generator.elementType(typ.Elem(), name, namespace)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{"type": "map", "values": values}, nil
+	case reflect.Struct:
+		return generator.record(typ, namespace)
+	}
+	return nil, fmt.Errorf("%s can't be written with avro", typ)
+}
+
+// elementType returns the type of the items of arrays and maps,
+// which are nullable when they are pointers, like fields are.
+func (generator *avroGenerator) elementType(typ reflect.Type, name, namespace string) (interface{}, error) {
+	if typ.Kind() == reflect.Ptr && typ != ratType {
+		t, err := generator.typeFor(typ.Elem(), name, "", namespace)
+		if err != nil {
+			return nil, err
+		}
+		return []interface{}{"null", t}, nil
+	}
+	return generator.typeFor(typ, name, "", namespace)
+}
+
+// named defines an enum or a fixed once, and references it afterwards.
+// Types that aren't named in Go are defined each time they are found.
+func (generator *avroGenerator) named(typ reflect.Type, schema map[string]interface{}, namespace string) (interface{}, error) {
+	if len(typ.Name()) > 0 {
+		if fullName, ok := generator.defined[typ]; ok {
+			return fullName, nil
+		}
+	}
+	fullName := qualifiedName(schema["name"].(string), namespace)
+	if generator.names[fullName] {
+		return nil, fmt.Errorf("more than one type is written as the avro type %s", fullName)
+	}
+	generator.names[fullName] = true
+	if len(typ.Name()) > 0 {
+		generator.defined[typ] = fullName
+	}
+	return schema, nil
+}
+
+func qualifiedName(name, namespace string) string {
+	if len(namespace) == 0 || strings.Contains(name, ".") {
+		return name
+	}
+	return namespace + "." + name
+}
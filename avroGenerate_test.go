@@ -0,0 +1,110 @@
+package srclient
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+type generatedStatus string
+
+type generatedAddress struct {
+	City    string `avro:"city"`
+	Country string `avro:"country" default:"PT"`
+}
+
+type generatedEvent struct {
+	_         struct{}          `name:"Event" namespace:"com.example" doc:"Something that happened"`
+	ID        int64             `avro:"id" doc:"The identifier"`
+	Kind      generatedStatus   `avro:"kind" symbols:"CREATED,DELETED" default:"CREATED"`
+	Count     int32             `avro:"count" default:"1"`
+	Ratio     float64           `avro:"ratio"`
+	Payload   []byte            `avro:"payload"`
+	Hash      [4]byte           `avro:"hash"`
+	Tags      []string          `avro:"tags"`
+	Labels    map[string]int    `avro:"labels"`
+	Home      generatedAddress  `avro:"home"`
+	Work      *generatedAddress `avro:"work"`
+	Nickname  *string           `avro:"nickname" default:"\"none\""`
+	At        time.Time         `avro:"at"`
+	Took      time.Duration     `avro:"took"`
+	Amount    *big.Rat          `avro:"amount" precision:"9" scale:"2"`
+	Parent    *generatedEvent   `avro:"parent"`
+	Untracked string            `avro:"-"`
+	internal  string
+}
+
+func TestGenerateAvroSchema(t *testing.T) {
+	schema, err := GenerateAvroSchema(&generatedEvent{})
+	assert.NoError(t, err)
+
+	canonical, err := AvroCanonicalForm(schema)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"com.example.Event","type":"record","fields":[`+
+		`{"name":"id","type":"long"},`+
+		`{"name":"kind","type":{"name":"com.example.generatedStatus","type":"enum","symbols":["CREATED","DELETED"]}},`+
+		`{"name":"count","type":"int"},`+
+		`{"name":"ratio","type":"double"},`+
+		`{"name":"payload","type":"bytes"},`+
+		`{"name":"hash","type":{"name":"com.example.hash","type":"fixed","size":4}},`+
+		`{"name":"tags","type":{"type":"array","items":"string"}},`+
+		`{"name":"labels","type":{"type":"map","values":"long"}},`+
+		`{"name":"home","type":{"name":"com.example.generatedAddress","type":"record","fields":[`+
+		`{"name":"city","type":"string"},{"name":"country","type":"string"}]}},`+
+		`{"name":"work","type":["null","com.example.generatedAddress"]},`+
+		`{"name":"nickname","type":["string","null"]},`+
+		`{"name":"at","type":"long"},`+
+		`{"name":"took","type":"long"},`+
+		`{"name":"amount","type":"bytes"},`+
+		`{"name":"parent","type":["null","com.example.Event"]}]}`, canonical)
+
+	parsed, err := parseAvroSchema(schema)
+	assert.NoError(t, err)
+	assert.Equal(t, "Something that happened", parsed.doc)
+	assert.Equal(t, "The identifier", parsed.fields[0].doc)
+	assert.Equal(t, "timestamp-millis", parsed.field("at").typ.logicalType)
+	assert.Equal(t, 9, parsed.field("amount").typ.precision)
+
+	_, err = goavro.NewCodec(schema)
+	assert.NoError(t, err)
+}
+
+func TestGenerateAvroSchema_RoundTrip(t *testing.T) {
+	schema, err := GenerateAvroSchema(generatedAddress{})
+	assert.NoError(t, err)
+
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("addresses", schema, Avro, false)
+	assert.NoError(t, err)
+	record, err := NewSerializer(mockClient).Serialize(registered, generatedAddress{City: "Porto", Country: "PT"})
+	assert.NoError(t, err)
+
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+	var decoded generatedAddress
+	assert.NoError(t, deserializer.DeserializeInto(record, &decoded))
+	assert.Equal(t, generatedAddress{City: "Porto", Country: "PT"}, decoded)
+}
+
+func TestGenerateAvroSchema_Errors(t *testing.T) {
+	_, err := GenerateAvroSchema(42)
+	assert.Error(t, err)
+
+	_, err = GenerateAvroSchema(struct{ A int }{})
+	assert.Error(t, err)
+
+	type unsupported struct {
+		Channel chan int
+	}
+	_, err = GenerateAvroSchema(unsupported{})
+	assert.Error(t, err)
+
+	type badDefault struct {
+		Count int `default:"many"`
+	}
+	_, err = GenerateAvroSchema(badDefault{})
+	assert.Error(t, err)
+}
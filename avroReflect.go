@@ -0,0 +1,615 @@
+package srclient
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// avroValuePlan converts between the native values of goavro for
+// an Avro type and a Go type, so records can be read into structs
+// and written from them. Fields are matched with the avro tag, as
+// in `avro:"name"`, or else by name, ignoring case; a tag of "-"
+// leaves the field out. Nullable unions map to pointers, enums to
+// strings, or integers holding the index of the symbol, and logical
+// types to time.Time, time.Duration, *big.Rat and strings for UUIDs.
+type avroValuePlan struct {
+	decode func(native interface{}, target reflect.Value) error
+	encode func(value reflect.Value) (interface{}, error)
+}
+
+var (
+	timeType         = reflect.TypeOf(time.Time{})
+	durationType     = reflect.TypeOf(time.Duration(0))
+	ratType          = reflect.TypeOf(&big.Rat{})
+	bytesType        = reflect.TypeOf([]byte{})
+	nativeRecordType = reflect.TypeOf(map[string]interface{}{})
+)
+
+// typePlanKey identifies a plan: the Go type, and the schemas
+// whose native values it converts, like resolverKey does.
+type typePlanKey struct {
+	resolverKey
+	typ reflect.Type
+}
+
+// avroTypePlans caches the plans built for schemas and Go types,
+// which depend on the logical types that are mapped. The generation
+// counts the changes of logical types, so that plans built for the
+// previous ones while they changed aren't cached.
+type avroTypePlans struct {
+	plans        map[typePlanKey]*avroValuePlan
+	logicalTypes logicalTypeMapping
+	generation   uint64
+	plansLock    sync.RWMutex
+}
+
+func newAvroTypePlans() *avroTypePlans {
+	return &avroTypePlans{plans: make(map[typePlanKey]*avroValuePlan)}
+}
+
+// setLogicalTypes changes the logical types that are mapped,
+// dropping the plans built for the previous ones.
+func (cache *avroTypePlans) setLogicalTypes(logicalTypes logicalTypeMapping) {
+	cache.plansLock.Lock()
+	defer cache.plansLock.Unlock()
+	cache.logicalTypes = logicalTypes
+	cache.generation++
+	cache.plans = make(map[typePlanKey]*avroValuePlan)
+}
+
+func (cache *avroTypePlans) planFor(key resolverKey, t *avroType, typ reflect.Type) (*avroValuePlan, error) {
+	planKey := typePlanKey{resolverKey: key, typ: typ}
+	cache.plansLock.RLock()
+	plan, ok := cache.plans[planKey]
+	logicalTypes, generation := cache.logicalTypes, cache.generation
+	cache.plansLock.RUnlock()
+	if ok {
+		return plan, nil
+	}
+
+	builder := &avroValuePlanBuilder{plans: map[avroValuePlanKey]*avroValuePlan{}, logicalTypes: logicalTypes}
+	plan, err := builder.build(t, typ)
+	if err != nil {
+		return nil, err
+	}
+	cache.plansLock.Lock()
+	if cache.generation == generation {
+		cache.plans[planKey] = plan
+	}
+	cache.plansLock.Unlock()
+	return plan, nil
+}
+
+// isStructValue tells whether values of the type have to be
+// converted with a plan, instead of being goavro native values.
+func isStructValue(typ reflect.Type) bool {
+	for typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ.Kind() == reflect.Struct && typ != timeType && typ != reflect.TypeOf(big.Rat{})
+}
+
+type avroValuePlanKey struct {
+	t   *avroType
+	typ reflect.Type
+}
+
+type avroValuePlanBuilder struct {
+	plans        map[avroValuePlanKey]*avroValuePlan
+	logicalTypes logicalTypeMapping
+}
+
+func (builder *avroValuePlanBuilder) build(t *avroType, typ reflect.Type) (*avroValuePlan, error) {
+	key := avroValuePlanKey{t, typ}
+	if plan, ok := builder.plans[key]; ok {
+		return plan, nil
+	}
+	plan := &avroValuePlan{}
+	builder.plans[key] = plan
+
+	var err error
+	switch {
+	case typ.Kind() == reflect.Interface && typ.NumMethod() == 0:
+		err = builder.buildInterface(plan)
+	case t.kind() == "union":
+		err = builder.buildUnion(plan, t, typ)
+	case typ.Kind() == reflect.Ptr && typ != ratType:
+		err = builder.buildPointer(plan, t, typ)
+	case t.kind() == "record":
+		err = builder.buildRecord(plan, t, typ)
+	case t.kind() == "array":
+		err = builder.buildArray(plan, t, typ)
+	case t.kind() == "map":
+		err = builder.buildMap(plan, t, typ)
+	case t.kind() == "enum":
+		err = builder.buildEnum(plan, t, typ)
+	default:
+		err = builder.buildPrimitive(plan, t, typ)
+	}
+	if err != nil {
+		delete(builder.plans, key)
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (builder *avroValuePlanBuilder) buildInterface(plan *avroValuePlan) error {
+	plan.decode = func(native interface{}, target reflect.Value) error {
+		if native == nil {
+			target.Set(reflect.Zero(target.Type()))
+			return nil
+		}
+		target.Set(reflect.ValueOf(native))
+		return nil
+	}
+	plan.encode = func(value reflect.Value) (interface{}, error) {
+		if value.IsNil() {
+			return nil, nil
+		}
+		return value.Elem().Interface(), nil
+	}
+	return nil
+}
+
+func (builder *avroValuePlanBuilder) buildUnion(plan *avroValuePlan, t *avroType, typ reflect.Type) error {
+	var nullable bool
+	var branches []*avroType
+	for _, branch := range t.branches {
+		if branch.typ == "null" {
+			nullable = true
+		} else {
+			branches = append(branches, branch)
+		}
+	}
+
+	// Pointers, slices and maps are nil for the null branch, so the
+	// type they hold, or themselves for slices and maps, is mapped to
+	// the other branches. Other Go types use their zero value for null.
+	valueType := typ
+	if typ.Kind() == reflect.Ptr && typ != ratType {
+		valueType = typ.Elem()
+	}
+	plans := make([]*avroValuePlan, len(branches))
+	found := false
+	for i, branch := range branches {
+		if branchPlan, err := builder.build(branch, valueType); err == nil {
+			plans[i], found = branchPlan, true
+		}
+	}
+	if !found && len(branches) > 0 {
+		return fmt.Errorf("%s can't hold any branch of the union %s", typ, t)
+	}
+
+	plan.decode = func(native interface{}, target reflect.Value) error {
+		if native == nil {
+			target.Set(reflect.Zero(typ))
+			return nil
+		}
+		wrapped, ok := native.(map[string]interface{})
+		if !ok || len(wrapped) != 1 {
+			return fmt.Errorf("expected a value of the union %s, found %T", t, native)
+		}
+		for name, value := range wrapped {
+			for i, branch := range branches {
+				if goavroTypeName(branch) != name || plans[i] == nil {
+					continue
+				}
+				if valueType == typ {
+					return plans[i].decode(value, target)
+				}
+				pointer := reflect.New(valueType)
+				if err := plans[i].decode(value, pointer.Elem()); err != nil {
+					return err
+				}
+				target.Set(pointer)
+				return nil
+			}
+			return fmt.Errorf("%s can't hold the %s branch of the union %s", typ, name, t)
+		}
+		return nil
+	}
+
+	plan.encode = func(value reflect.Value) (interface{}, error) {
+		switch typ.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map:
+			if value.IsNil() && nullable {
+				return nil, nil
+			}
+		}
+		if valueType != typ {
+			if value.IsNil() {
+				return nil, fmt.Errorf("nil value for the union %s, which isn't nullable", t)
+			}
+			value = value.Elem()
+		}
+		var lastErr error
+		for i, branch := range branches {
+			if plans[i] == nil {
+				continue
+			}
+			native, err := plans[i].encode(value)
+			if err != nil {
+				lastErr = err
+				continue
+			}
+			return map[string]interface{}{goavroTypeName(branch): native}, nil
+		}
+		if lastErr == nil {
+			lastErr = fmt.Errorf("no branch of the union %s can hold %s", t, typ)
+		}
+		return nil, lastErr
+	}
+	return nil
+}
+
+func (builder *avroValuePlanBuilder) buildPointer(plan *avroValuePlan, t *avroType, typ reflect.Type) error {
+	elemPlan, err := builder.build(t, typ.Elem())
+	if err != nil {
+		return err
+	}
+	plan.decode = func(native interface{}, target reflect.Value) error {
+		pointer := reflect.New(typ.Elem())
+		if err := elemPlan.decode(native, pointer.Elem()); err != nil {
+			return err
+		}
+		target.Set(pointer)
+		return nil
+	}
+	plan.encode = func(value reflect.Value) (interface{}, error) {
+		if value.IsNil() {
+			return nil, fmt.Errorf("nil value for %s, which isn't nullable", t)
+		}
+		return elemPlan.encode(value.Elem())
+	}
+	return nil
+}
+
+// structField maps a field of an Avro record to a field of a struct.
+type structField struct {
+	name         string
+	index        []int
+	plan         *avroValuePlan
+	defaultValue interface{}
+}
+
+func (builder *avroValuePlanBuilder) buildRecord(plan *avroValuePlan, t *avroType, typ reflect.Type) error {
+	if typ == nativeRecordType {
+		return builder.buildInterface(plan)
+	}
+	if typ.Kind() != reflect.Struct {
+		return fmt.Errorf("the record %s can't be mapped to %s", t, typ)
+	}
+
+	goFields := map[string][]int{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("avro"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			name = strings.ToLower(field.Name)
+			if _, tagged := goFields[name]; tagged {
+				continue
+			}
+		}
+		goFields[name] = field.Index
+	}
+
+	fields := make([]structField, 0, len(t.fields))
+	for _, avroField := range t.fields {
+		field := structField{name: avroField.name}
+		index, ok := goFields[avroField.name]
+		if !ok {
+			index, ok = goFields[strings.ToLower(avroField.name)]
+		}
+		if ok {
+			fieldPlan, err := builder.build(avroField.typ, typ.FieldByIndex(index).Type)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", avroField.name, err)
+			}
+			field.index, field.plan = index, fieldPlan
+		} else if avroField.hasDefault {
+			value, err := avroDefaultNative(avroField.typ, avroField.defaultValue, builder.logicalTypes)
+			if err != nil {
+				return fmt.Errorf("invalid default of the field %s of %s: %w", avroField.name, t, err)
+			}
+			field.defaultValue = value
+		} else {
+			return fmt.Errorf("%s has no field for %s.%s, which has no default", typ, t, avroField.name)
+		}
+		fields = append(fields, field)
+	}
+
+	plan.decode = func(native interface{}, target reflect.Value) error {
+		record, ok := native.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected a value of the record %s, found %T", t, native)
+		}
+		for _, field := range fields {
+			if field.plan == nil {
+				continue
+			}
+			if err := field.plan.decode(record[field.name], target.FieldByIndex(field.index)); err != nil {
+				return fmt.Errorf("field %s: %w", field.name, err)
+			}
+		}
+		return nil
+	}
+	plan.encode = func(value reflect.Value) (interface{}, error) {
+		record := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			if field.plan == nil {
+				record[field.name] = field.defaultValue
+				continue
+			}
+			native, err := field.plan.encode(value.FieldByIndex(field.index))
+			if err != nil {
+				return nil, fmt.Errorf("field %s: %w", field.name, err)
+			}
+			record[field.name] = native
+		}
+		return record, nil
+	}
+	return nil
+}
+
+func (builder *avroValuePlanBuilder) buildArray(plan *avroValuePlan, t *avroType, typ reflect.Type) error {
+	if typ.Kind() != reflect.Slice {
+		return fmt.Errorf("the array %s can't be mapped to %s", t, typ)
+	}
+	itemPlan, err := builder.build(t.items, typ.Elem())
+	if err != nil {
+		return err
+	}
+	plan.decode = func(native interface{}, target reflect.Value) error {
+		items, ok := native.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected an array, found %T", native)
+		}
+		slice := reflect.MakeSlice(typ, len(items), len(items))
+		for i, item := range items {
+			if err := itemPlan.decode(item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+		return nil
+	}
+	plan.encode = func(value reflect.Value) (interface{}, error) {
+		items := make([]interface{}, value.Len())
+		for i := range items {
+			native, err := itemPlan.encode(value.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			items[i] = native
+		}
+		return items, nil
+	}
+	return nil
+}
+
+func (builder *avroValuePlanBuilder) buildMap(plan *avroValuePlan, t *avroType, typ reflect.Type) error {
+	if typ.Kind() != reflect.Map || typ.Key().Kind() != reflect.String {
+		return fmt.Errorf("the map %s can't be mapped to %s", t, typ)
+	}
+	valuePlan, err := builder.build(t.values, typ.Elem())
+	if err != nil {
+		return err
+	}
+	plan.decode = func(native interface{}, target reflect.Value) error {
+		values, ok := native.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("expected a map, found %T", native)
+		}
+		result := reflect.MakeMapWithSize(typ, len(values))
+		for key, item := range values {
+			value := reflect.New(typ.Elem()).Elem()
+			if err := valuePlan.decode(item, value); err != nil {
+				return err
+			}
+			result.SetMapIndex(reflect.ValueOf(key).Convert(typ.Key()), value)
+		}
+		target.Set(result)
+		return nil
+	}
+	plan.encode = func(value reflect.Value) (interface{}, error) {
+		values := make(map[string]interface{}, value.Len())
+		iterator := value.MapRange()
+		for iterator.Next() {
+			native, err := valuePlan.encode(iterator.Value())
+			if err != nil {
+				return nil, err
+			}
+			values[iterator.Key().String()] = native
+		}
+		return values, nil
+	}
+	return nil
+}
+
+func (builder *avroValuePlanBuilder) buildEnum(plan *avroValuePlan, t *avroType, typ reflect.Type) error {
+	switch typ.Kind() {
+	case reflect.String:
+		plan.decode = func(native interface{}, target reflect.Value) error {
+			symbol, ok := native.(string)
+			if !ok {
+				return fmt.Errorf("expected a symbol of the enum %s, found %T", t, native)
+			}
+			target.SetString(symbol)
+			return nil
+		}
+		plan.encode = func(value reflect.Value) (interface{}, error) {
+			return value.String(), nil
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		indexes := make(map[string]int64, len(t.symbols))
+		for i, symbol := range t.symbols {
+			indexes[symbol] = int64(i)
+		}
+		plan.decode = func(native interface{}, target reflect.Value) error {
+			symbol, _ := native.(string)
+			index, ok := indexes[symbol]
+			if !ok {
+				return fmt.Errorf("unknown symbol %v of the enum %s", native, t)
+			}
+			target.SetInt(index)
+			return nil
+		}
+		plan.encode = func(value reflect.Value) (interface{}, error) {
+			index := value.Int()
+			if index < 0 || index >= int64(len(t.symbols)) {
+				return nil, fmt.Errorf("no symbol of the enum %s has the index %d", t, index)
+			}
+			return t.symbols[index], nil
+		}
+	default:
+		return fmt.Errorf("the enum %s can't be mapped to %s", t, typ)
+	}
+	return nil
+}
+
+func (builder *avroValuePlanBuilder) buildPrimitive(plan *avroValuePlan, t *avroType, typ reflect.Type) error {
+	var native reflect.Type
+	switch builder.logicalTypes.of(t) {
+	case Date, TimestampMillis, TimestampMicros, LocalTimestampMillis, LocalTimestampMicros:
+		native = timeType
+	case TimeMillis, TimeMicros:
+		native = durationType
+	case Decimal:
+		native = ratType
+	case UUID:
+		native = reflect.TypeOf("")
+	}
+	switch {
+	case native != nil:
+	case t.kind() == "null":
+		plan.decode = func(interface{}, reflect.Value) error { return nil }
+		plan.encode = func(reflect.Value) (interface{}, error) { return nil, nil }
+		return nil
+	case t.kind() == "fixed":
+		if typ.Kind() == reflect.Array && typ.Elem().Kind() == reflect.Uint8 && typ.Len() == t.size {
+			return buildFixedArray(plan, typ)
+		}
+		native = bytesType
+	default:
+		native = map[string]reflect.Type{
+			"boolean": reflect.TypeOf(false),
+			"int":     reflect.TypeOf(int32(0)),
+			"long":    reflect.TypeOf(int64(0)),
+			"float":   reflect.TypeOf(float32(0)),
+			"double":  reflect.TypeOf(float64(0)),
+			"bytes":   bytesType,
+			"string":  reflect.TypeOf(""),
+		}[t.kind()]
+	}
+	if native == nil || !convertibleNative(native, typ) {
+		return fmt.Errorf("%s can't be mapped to %s", t, typ)
+	}
+
+	plan.decode = func(value interface{}, target reflect.Value) error {
+		v := reflect.ValueOf(value)
+		if !v.IsValid() || !convertibleNative(v.Type(), typ) {
+			return fmt.Errorf("%T can't be stored in %s", value, typ)
+		}
+		converted := v.Convert(typ)
+		if overflows(v, typ) {
+			return fmt.Errorf("%v overflows %s", value, typ)
+		}
+		target.Set(converted)
+		return nil
+	}
+	plan.encode = func(value reflect.Value) (interface{}, error) {
+		if value.Kind() == reflect.Ptr && value.IsNil() {
+			return nil, fmt.Errorf("nil value for %s, which isn't nullable", t)
+		}
+		if overflows(value, native) {
+			return nil, fmt.Errorf("%v overflows the %s type", value.Interface(), t.kind())
+		}
+		return value.Convert(native).Interface(), nil
+	}
+	return nil
+}
+
+func buildFixedArray(plan *avroValuePlan, typ reflect.Type) error {
+	plan.decode = func(native interface{}, target reflect.Value) error {
+		b, ok := native.([]byte)
+		if !ok || len(b) != typ.Len() {
+			return fmt.Errorf("%T can't be stored in %s", native, typ)
+		}
+		reflect.Copy(target, reflect.ValueOf(b))
+		return nil
+	}
+	plan.encode = func(value reflect.Value) (interface{}, error) {
+		b := make([]byte, typ.Len())
+		reflect.Copy(reflect.ValueOf(b), value)
+		return b, nil
+	}
+	return nil
+}
+
+// convertibleNative tells if values of one type can be converted to
+// the other without changing their meaning, unlike what reflect allows
+// (such as converting numbers to strings).
+func convertibleNative(from, to reflect.Type) bool {
+	if from == to {
+		return true
+	}
+	switch {
+	case isInteger(from.Kind()) && isInteger(to.Kind()):
+		return from != durationType && to != durationType || from.Kind() == to.Kind()
+	case isFloat(from.Kind()) && isFloat(to.Kind()):
+		return true
+	case from.Kind() == reflect.String && to.Kind() == reflect.String,
+		from.Kind() == reflect.Bool && to.Kind() == reflect.Bool:
+		return true
+	case from.Kind() == reflect.Slice && to.Kind() == reflect.Slice:
+		return from.Elem().Kind() == reflect.Uint8 && to.Elem().Kind() == reflect.Uint8
+	}
+	return false
+}
+
+// overflows tells if the integer or float value can't be represented by
+// the given type. Converting a float64 to float32 only loses precision.
+func overflows(value reflect.Value, typ reflect.Type) bool {
+	switch {
+	case isInteger(value.Kind()) && isInteger(typ.Kind()):
+		if isUnsigned(value.Kind()) {
+			u := value.Uint()
+			if isUnsigned(typ.Kind()) {
+				return reflect.Zero(typ).OverflowUint(u)
+			}
+			return u > math.MaxInt64 || reflect.Zero(typ).OverflowInt(int64(u))
+		}
+		i := value.Int()
+		if isUnsigned(typ.Kind()) {
+			return i < 0 || reflect.Zero(typ).OverflowUint(uint64(i))
+		}
+		return reflect.Zero(typ).OverflowInt(i)
+	case isFloat(value.Kind()) && isFloat(typ.Kind()):
+		f := value.Float()
+		return !math.IsInf(f, 0) && reflect.Zero(typ).OverflowFloat(f)
+	}
+	return false
+}
+
+func isInteger(kind reflect.Kind) bool {
+	return kind >= reflect.Int && kind <= reflect.Uint64
+}
+
+func isUnsigned(kind reflect.Kind) bool {
+	return kind >= reflect.Uint && kind <= reflect.Uint64
+}
+
+func isFloat(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
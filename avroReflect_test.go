@@ -0,0 +1,139 @@
+package srclient
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const orderSchema = `{
+	"type": "record", "name": "Order", "namespace": "com.example",
+	"fields": [
+		{"name": "id", "type": "long"},
+		{"name": "customer", "type": {"type": "record", "name": "Customer", "fields": [
+			{"name": "name", "type": "string"},
+			{"name": "email", "type": ["null", "string"], "default": null}
+		]}},
+		{"name": "lines", "type": {"type": "array", "items": {"type": "record", "name": "Line", "fields": [
+			{"name": "sku", "type": "string"},
+			{"name": "quantity", "type": "int"}
+		]}}},
+		{"name": "attributes", "type": {"type": "map", "values": "string"}},
+		{"name": "status", "type": {"type": "enum", "name": "Status", "symbols": ["NEW", "PAID", "SHIPPED"]}},
+		{"name": "priority", "type": {"type": "enum", "name": "Priority", "symbols": ["LOW", "HIGH"]}},
+		{"name": "placedAt", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "window", "type": {"type": "int", "logicalType": "time-millis"}},
+		{"name": "total", "type": {"type": "bytes", "logicalType": "decimal", "precision": 9, "scale": 2}},
+		{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 4}},
+		{"name": "parent", "type": ["null", "Order"], "default": null},
+		{"name": "channel", "type": "string", "default": "web"}
+	]
+}`
+
+type customer struct {
+	Name  string  `avro:"name"`
+	Email *string `avro:"email"`
+}
+
+type line struct {
+	Sku      string
+	Quantity uint16
+}
+
+type order struct {
+	ID         int64             `avro:"id"`
+	Customer   customer          `avro:"customer"`
+	Lines      []line            `avro:"lines"`
+	Attributes map[string]string `avro:"attributes"`
+	Status     string            `avro:"status"`
+	Priority   int               `avro:"priority"`
+	PlacedAt   time.Time         `avro:"placedAt"`
+	Window     time.Duration     `avro:"window"`
+	Total      *big.Rat          `avro:"total"`
+	Hash       [4]byte           `avro:"hash"`
+	Parent     *order            `avro:"parent"`
+	Internal   string            `avro:"-"`
+}
+
+func TestAvroValuePlan_RoundTrip(t *testing.T) {
+	parsed, err := parseAvroSchema(orderSchema)
+	assert.NoError(t, err)
+	codec, err := goavro.NewCodec(orderSchema)
+	assert.NoError(t, err)
+	plans := newAvroTypePlans()
+	plan, err := plans.planFor(resolverKey{writerID: 1}, parsed, reflect.TypeOf(order{}))
+	assert.NoError(t, err)
+
+	email := "gopher@example.com"
+	value := order{
+		ID:         42,
+		Customer:   customer{Name: "Gopher", Email: &email},
+		Lines:      []line{{Sku: "a", Quantity: 2}, {Sku: "b", Quantity: 1}},
+		Attributes: map[string]string{"gift": "yes"},
+		Status:     "PAID",
+		Priority:   1,
+		PlacedAt:   time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC),
+		Window:     90 * time.Second,
+		Total:      big.NewRat(1999, 100),
+		Hash:       [4]byte{1, 2, 3, 4},
+		Parent: &order{ID: 41, Lines: []line{}, Attributes: map[string]string{}, Status: "NEW",
+			PlacedAt: time.Unix(0, 0).UTC(), Total: big.NewRat(0, 1)},
+		Internal: "ignored",
+	}
+
+	native, err := plan.encode(reflect.ValueOf(value))
+	assert.NoError(t, err)
+	assert.Equal(t, "web", native.(map[string]interface{})["channel"])
+	data, err := codec.BinaryFromNative(nil, native)
+	assert.NoError(t, err)
+
+	decodedNative, _, err := codec.NativeFromBinary(data)
+	assert.NoError(t, err)
+	var decoded order
+	assert.NoError(t, plan.decode(decodedNative, reflect.ValueOf(&decoded).Elem()))
+
+	value.Internal = ""
+	assert.Equal(t, 0, value.Total.Cmp(decoded.Total))
+	assert.Equal(t, 0, value.Parent.Total.Cmp(decoded.Parent.Total))
+	value.Total, decoded.Total = nil, nil
+	value.Parent.Total, decoded.Parent.Total = nil, nil
+	assert.Equal(t, value, decoded)
+
+	cached, err := plans.planFor(resolverKey{writerID: 1}, parsed, reflect.TypeOf(order{}))
+	assert.NoError(t, err)
+	assert.True(t, plan == cached)
+}
+
+func TestAvroValuePlan_Errors(t *testing.T) {
+	parsed, err := parseAvroSchema(`{"type": "record", "name": "R", "fields": [
+		{"name": "small", "type": "long"},
+		{"name": "required", "type": "string"}
+	]}`)
+	assert.NoError(t, err)
+	plans := newAvroTypePlans()
+
+	// A struct without a field for a field that has no default.
+	_, err = plans.planFor(resolverKey{}, parsed, reflect.TypeOf(struct{ Small int8 }{}))
+	assert.Error(t, err)
+
+	// A field whose type doesn't match.
+	_, err = plans.planFor(resolverKey{}, parsed, reflect.TypeOf(struct {
+		Small    string
+		Required string
+	}{}))
+	assert.Error(t, err)
+
+	type target struct {
+		Small    int8
+		Required string
+	}
+	plan, err := plans.planFor(resolverKey{}, parsed, reflect.TypeOf(target{}))
+	assert.NoError(t, err)
+	var decoded target
+	err = plan.decode(map[string]interface{}{"small": int64(300), "required": "x"}, reflect.ValueOf(&decoded).Elem())
+	assert.Error(t, err)
+}
@@ -0,0 +1,702 @@
+package srclient
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// avroResolver decodes binary data written with a writer schema into
+// the native values that goavro uses for a reader schema, applying the
+// schema resolution rules of the Avro specification: fields only known
+// by the writer are skipped, fields only known by the reader take their
+// default, numbers are promoted, unknown enum symbols are read as the
+// enum default and union branches are matched between both schemas.
+type avroResolver struct {
+	plan *avroReadPlan
+}
+
+// newAvroResolver prepares the resolution of writer data into the
+// reader schema, failing if the two schemas can't be resolved. The
+// values of the logical types that are mapped are turned into Go values.
+func newAvroResolver(reader, writer *avroType, logicalTypes logicalTypeMapping) (*avroResolver, error) {
+	builder := &avroPlanBuilder{plans: map[[2]*avroType]*avroReadPlan{}, logicalTypes: logicalTypes}
+	plan, err := builder.build(reader, writer)
+	if err != nil {
+		return nil, err
+	}
+	return &avroResolver{plan: plan}, nil
+}
+
+// NativeFromBinary decodes one datum, returning it along with
+// the bytes that follow it, like goavro.Codec does.
+func (resolver *avroResolver) NativeFromBinary(buf []byte) (interface{}, []byte, error) {
+	return resolver.plan.decode(buf)
+}
+
+// avroReadPlan describes how to read a datum of the writer schema
+// and turn it into a datum of the reader schema. Plans are built
+// once per pair of types, so recursive schemas produce cycles.
+type avroReadPlan struct {
+	reader, writer *avroType
+	logicalTypes   logicalTypeMapping
+
+	// unresolved is set for writer union branches that can't be
+	// read, which is only an error when such a branch is found.
+	unresolved error
+
+	// writerBranches are the plans for each branch of a writer union.
+	writerBranches []*avroReadPlan
+
+	// readerBranch is the plan for the branch of a reader union
+	// chosen to read a writer type that isn't a union, and
+	// branchName is the key goavro uses for that branch.
+	readerBranch *avroReadPlan
+	branchName   string
+
+	fields   []avroFieldPlan
+	defaults map[string]interface{}
+	symbols  []*string
+	items    *avroReadPlan
+	itemSize int
+}
+
+// avroFieldPlan reads one field of the writer record. Fields
+// unknown to the reader are skipped, and have no name.
+type avroFieldPlan struct {
+	name string
+	plan *avroReadPlan
+}
+
+type avroPlanBuilder struct {
+	plans        map[[2]*avroType]*avroReadPlan
+	logicalTypes logicalTypeMapping
+}
+
+func (builder *avroPlanBuilder) build(reader, writer *avroType) (*avroReadPlan, error) {
+	pair := [2]*avroType{reader, writer}
+	if plan, ok := builder.plans[pair]; ok {
+		return plan, nil
+	}
+	// The plan is cached before it is resolved, so that recursive
+	// types find it, and dropped if it can't be resolved.
+	plan := &avroReadPlan{reader: reader, writer: writer, logicalTypes: builder.logicalTypes}
+	builder.plans[pair] = plan
+	if err := builder.resolve(plan); err != nil {
+		delete(builder.plans, pair)
+		return nil, err
+	}
+	return plan, nil
+}
+
+func (builder *avroPlanBuilder) resolve(plan *avroReadPlan) error {
+	reader, writer := plan.reader, plan.writer
+	if writer.kind() == "union" {
+		for _, branch := range writer.branches {
+			branchPlan, err := builder.build(reader, branch)
+			if err != nil {
+				branchPlan = &avroReadPlan{reader: reader, writer: branch, unresolved: err}
+			}
+			plan.writerBranches = append(plan.writerBranches, branchPlan)
+		}
+		return nil
+	}
+
+	if reader.kind() == "union" {
+		branch := selectReaderBranch(reader, writer)
+		if branch == nil {
+			return fmt.Errorf("no branch of the reader union %s can read the writer type %s", reader, writer)
+		}
+		branchPlan, err := builder.build(branch, writer)
+		if err != nil {
+			return err
+		}
+		plan.readerBranch = branchPlan
+		if branch.typ != "null" {
+			plan.branchName = goavroTypeName(branch)
+		}
+		return nil
+	}
+
+	if !avroPromotable(reader, writer) {
+		return fmt.Errorf("the reader type %s can't read the writer type %s", reader, writer)
+	}
+
+	switch writer.kind() {
+	case "record":
+		return builder.buildRecord(plan)
+	case "enum":
+		known := map[string]bool{}
+		for _, symbol := range reader.symbols {
+			known[symbol] = true
+		}
+		for _, symbol := range writer.symbols {
+			symbol := symbol
+			switch {
+			case known[symbol]:
+				plan.symbols = append(plan.symbols, &symbol)
+			case reader.enumDefault != nil:
+				plan.symbols = append(plan.symbols, reader.enumDefault)
+			default:
+				plan.symbols = append(plan.symbols, nil)
+			}
+		}
+	case "array":
+		items, err := builder.build(reader.items, writer.items)
+		if err != nil {
+			return err
+		}
+		plan.items = items
+		plan.itemSize = avroMinSize(writer.items, nil)
+	case "map":
+		values, err := builder.build(reader.values, writer.values)
+		if err != nil {
+			return err
+		}
+		plan.items = values
+		plan.itemSize = 1 + avroMinSize(writer.values, nil)
+	case "fixed":
+		if reader.size != writer.size {
+			return fmt.Errorf("the size of the fixed %s changed from %d to %d", reader, writer.size, reader.size)
+		}
+	}
+	return nil
+}
+
+func (builder *avroPlanBuilder) buildRecord(plan *avroReadPlan) error {
+	reader, writer := plan.reader, plan.writer
+	feeds := map[*avroField]*avroField{}
+	plan.defaults = map[string]interface{}{}
+	for _, readerField := range reader.fields {
+		writerField := lookupWriterField(writer, readerField)
+		if writerField != nil {
+			feeds[writerField] = readerField
+			continue
+		}
+		if !readerField.hasDefault {
+			return fmt.Errorf("the field %s of %s is missing in the writer schema and has no default", readerField.name, reader)
+		}
+		value, err := avroDefaultNative(readerField.typ, readerField.defaultValue, builder.logicalTypes)
+		if err != nil {
+			return fmt.Errorf("invalid default of the field %s of %s: %w", readerField.name, reader, err)
+		}
+		plan.defaults[readerField.name] = value
+	}
+	for _, writerField := range writer.fields {
+		readerField, ok := feeds[writerField]
+		if !ok {
+			plan.fields = append(plan.fields, avroFieldPlan{plan: &avroReadPlan{writer: writerField.typ}})
+			continue
+		}
+		fieldPlan, err := builder.build(readerField.typ, writerField.typ)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", readerField.name, err)
+		}
+		plan.fields = append(plan.fields, avroFieldPlan{name: readerField.name, plan: fieldPlan})
+	}
+	return nil
+}
+
+// selectReaderBranch picks the branch of the reader union used to
+// read a writer type: the first one of the same type, or else the
+// first one that the writer type can be resolved into.
+func selectReaderBranch(reader, writer *avroType) *avroType {
+	for _, branch := range reader.branches {
+		if branch.kind() != writer.kind() {
+			continue
+		}
+		if !branch.isNamed() || avroNamesMatch(branch, writer) {
+			return branch
+		}
+	}
+	state := &avroCompatibility{visiting: map[[2]*avroType]bool{}}
+	for _, branch := range reader.branches {
+		if state.compatible(branch, writer) {
+			return branch
+		}
+	}
+	return nil
+}
+
+func avroNamesMatch(reader, writer *avroType) bool {
+	if reader.fullName() == writer.fullName() {
+		return true
+	}
+	for _, alias := range reader.aliases {
+		if alias == writer.fullName() {
+			return true
+		}
+	}
+	return false
+}
+
+// avroPromotable tells if the reader type, which isn't a union,
+// can read the writer type, which isn't a union either.
+func avroPromotable(reader, writer *avroType) bool {
+	if reader.kind() == writer.kind() {
+		return !reader.isNamed() || avroNamesMatch(reader, writer)
+	}
+	switch reader.kind() {
+	case "long":
+		return writer.kind() == "int"
+	case "float":
+		return writer.kind() == "int" || writer.kind() == "long"
+	case "double":
+		return writer.kind() == "int" || writer.kind() == "long" || writer.kind() == "float"
+	case "bytes":
+		return writer.kind() == "string"
+	case "string":
+		return writer.kind() == "bytes"
+	}
+	return false
+}
+
+// goavroTypeName returns the name goavro gives to a type, which
+// is the key of the maps it uses for the values of unions.
+func goavroTypeName(t *avroType) string {
+	if t.isNamed() {
+		return t.fullName()
+	}
+	switch t.typ + "." + t.logicalType {
+	case "long.timestamp-millis", "long.timestamp-micros", "int.time-millis", "long.time-micros", "int.date", "bytes.decimal":
+		return t.typ + "." + t.logicalType
+	}
+	return t.typ
+}
+
+var errAvroShortBuffer = errors.New("short buffer")
+
+func (plan *avroReadPlan) decode(buf []byte) (interface{}, []byte, error) {
+	if plan.unresolved != nil {
+		return nil, buf, plan.unresolved
+	}
+	if plan.reader == nil {
+		rest, err := skipAvroDatum(plan.writer, buf)
+		return nil, rest, err
+	}
+
+	if plan.writerBranches != nil {
+		index, rest, err := readAvroLong(buf)
+		if err != nil {
+			return nil, buf, err
+		}
+		if index < 0 || index >= int64(len(plan.writerBranches)) {
+			return nil, buf, fmt.Errorf("union index %d out of range for %s", index, plan.writer)
+		}
+		return plan.writerBranches[index].decode(rest)
+	}
+
+	if plan.readerBranch != nil {
+		value, rest, err := plan.readerBranch.decode(buf)
+		if err != nil || len(plan.branchName) == 0 {
+			return nil, rest, err
+		}
+		return map[string]interface{}{plan.branchName: value}, rest, nil
+	}
+
+	switch plan.writer.kind() {
+	case "record":
+		record := make(map[string]interface{}, len(plan.reader.fields))
+		for name, value := range plan.defaults {
+			record[name] = value
+		}
+		for _, field := range plan.fields {
+			value, rest, err := field.plan.decode(buf)
+			if err != nil {
+				return nil, buf, err
+			}
+			buf = rest
+			if len(field.name) > 0 {
+				record[field.name] = value
+			}
+		}
+		return record, buf, nil
+	case "enum":
+		index, rest, err := readAvroLong(buf)
+		if err != nil {
+			return nil, buf, err
+		}
+		if index < 0 || index >= int64(len(plan.symbols)) {
+			return nil, buf, fmt.Errorf("enum index %d out of range for %s", index, plan.writer)
+		}
+		if plan.symbols[index] == nil {
+			return nil, buf, fmt.Errorf("the symbol %s of %s is unknown to the reader schema, which has no default", plan.writer.symbols[index], plan.writer)
+		}
+		return *plan.symbols[index], rest, nil
+	case "array":
+		items := []interface{}{}
+		rest, err := readAvroBlocks(buf, plan.itemSize, func(buf []byte) ([]byte, error) {
+			item, rest, err := plan.items.decode(buf)
+			items = append(items, item)
+			return rest, err
+		})
+		return items, rest, err
+	case "map":
+		values := map[string]interface{}{}
+		rest, err := readAvroBlocks(buf, plan.itemSize, func(buf []byte) ([]byte, error) {
+			key, rest, err := readAvroBytes(buf)
+			if err != nil {
+				return buf, err
+			}
+			value, rest, err := plan.items.decode(rest)
+			values[string(key)] = value
+			return rest, err
+		})
+		return values, rest, err
+	case "fixed":
+		if len(buf) < plan.writer.size {
+			return nil, buf, errAvroShortBuffer
+		}
+		value := append([]byte{}, buf[:plan.writer.size]...)
+		return plan.logicalTypes.native(plan.reader, value), buf[plan.writer.size:], nil
+	}
+
+	value, rest, err := readAvroPrimitive(plan.writer.kind(), buf)
+	if err != nil {
+		return nil, buf, err
+	}
+	return plan.logicalTypes.native(plan.reader, promoteAvro(plan.reader.kind(), value)), rest, nil
+}
+
+func readAvroPrimitive(kind string, buf []byte) (interface{}, []byte, error) {
+	switch kind {
+	case "null":
+		return nil, buf, nil
+	case "boolean":
+		if len(buf) < 1 {
+			return nil, buf, errAvroShortBuffer
+		}
+		return buf[0] != 0, buf[1:], nil
+	case "int":
+		value, rest, err := readAvroLong(buf)
+		if err != nil {
+			return nil, buf, err
+		}
+		if value < math.MinInt32 || value > math.MaxInt32 {
+			return nil, buf, fmt.Errorf("int value out of range: %d", value)
+		}
+		return int32(value), rest, nil
+	case "long":
+		return readAvroLong(buf)
+	case "float":
+		if len(buf) < 4 {
+			return nil, buf, errAvroShortBuffer
+		}
+		return math.Float32frombits(binary.LittleEndian.Uint32(buf)), buf[4:], nil
+	case "double":
+		if len(buf) < 8 {
+			return nil, buf, errAvroShortBuffer
+		}
+		return math.Float64frombits(binary.LittleEndian.Uint64(buf)), buf[8:], nil
+	case "bytes":
+		value, rest, err := readAvroBytes(buf)
+		if err != nil {
+			return nil, buf, err
+		}
+		return append([]byte{}, value...), rest, nil
+	case "string":
+		value, rest, err := readAvroBytes(buf)
+		if err != nil {
+			return nil, buf, err
+		}
+		return string(value), rest, nil
+	}
+	return nil, buf, fmt.Errorf("unknown avro type %s", kind)
+}
+
+func promoteAvro(kind string, value interface{}) interface{} {
+	switch kind {
+	case "long":
+		if v, ok := value.(int32); ok {
+			return int64(v)
+		}
+	case "float":
+		switch v := value.(type) {
+		case int32:
+			return float32(v)
+		case int64:
+			return float32(v)
+		}
+	case "double":
+		switch v := value.(type) {
+		case int32:
+			return float64(v)
+		case int64:
+			return float64(v)
+		case float32:
+			return float64(v)
+		}
+	case "bytes":
+		if v, ok := value.(string); ok {
+			return []byte(v)
+		}
+	case "string":
+		if v, ok := value.([]byte); ok {
+			return string(v)
+		}
+	}
+	return value
+}
+
+// avroDefaultNative turns the JSON default value of a field into its
+// native value, mapping logical types as the given mapping says.
+func avroDefaultNative(t *avroType, value interface{}, logicalTypes logicalTypeMapping) (interface{}, error) {
+	var native interface{}
+	switch t.kind() {
+	case "null":
+		if value != nil {
+			return nil, fmt.Errorf("expected null, found %v", value)
+		}
+		return nil, nil
+	case "boolean":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expected a boolean, found %v", value)
+		}
+		return b, nil
+	case "int", "long", "float", "double":
+		number, ok := value.(json.Number)
+		if !ok {
+			return nil, fmt.Errorf("expected a number, found %v", value)
+		}
+		f, err := number.Float64()
+		if err != nil {
+			return nil, err
+		}
+		switch t.kind() {
+		case "int":
+			native = int32(f)
+		case "long":
+			i, err := number.Int64()
+			if err != nil {
+				i = int64(f)
+			}
+			native = i
+		case "float":
+			native = float32(f)
+		default:
+			native = f
+		}
+	case "string", "enum":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, found %v", value)
+		}
+		return s, nil
+	case "bytes", "fixed":
+		s, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected a string, found %v", value)
+		}
+		// Bytes are written as strings whose code points are the bytes.
+		b := make([]byte, 0, len(s))
+		for _, r := range s {
+			b = append(b, byte(r))
+		}
+		native = b
+	case "array":
+		values, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an array, found %v", value)
+		}
+		items := make([]interface{}, len(values))
+		for i, item := range values {
+			converted, err := avroDefaultNative(t.items, item, logicalTypes)
+			if err != nil {
+				return nil, err
+			}
+			items[i] = converted
+		}
+		return items, nil
+	case "map":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object, found %v", value)
+		}
+		values := make(map[string]interface{}, len(object))
+		for key, item := range object {
+			converted, err := avroDefaultNative(t.values, item, logicalTypes)
+			if err != nil {
+				return nil, err
+			}
+			values[key] = converted
+		}
+		return values, nil
+	case "record":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expected an object, found %v", value)
+		}
+		record := make(map[string]interface{}, len(t.fields))
+		for _, field := range t.fields {
+			fieldValue, ok := object[field.name]
+			if !ok {
+				if !field.hasDefault {
+					return nil, fmt.Errorf("missing field %s", field.name)
+				}
+				fieldValue = field.defaultValue
+			}
+			converted, err := avroDefaultNative(field.typ, fieldValue, logicalTypes)
+			if err != nil {
+				return nil, err
+			}
+			record[field.name] = converted
+		}
+		return record, nil
+	case "union":
+		// The default of a union is a value of its first branch.
+		if len(t.branches) == 0 {
+			return nil, fmt.Errorf("empty union")
+		}
+		branch := t.branches[0]
+		converted, err := avroDefaultNative(branch, value, logicalTypes)
+		if err != nil || branch.typ == "null" {
+			return nil, err
+		}
+		return map[string]interface{}{goavroTypeName(branch): converted}, nil
+	}
+	return logicalTypes.native(t, native), nil
+}
+
+// skipAvroDatum moves past a datum of the given type.
+func skipAvroDatum(t *avroType, buf []byte) ([]byte, error) {
+	switch t.kind() {
+	case "union":
+		index, rest, err := readAvroLong(buf)
+		if err != nil {
+			return buf, err
+		}
+		if index < 0 || index >= int64(len(t.branches)) {
+			return buf, fmt.Errorf("union index %d out of range for %s", index, t)
+		}
+		return skipAvroDatum(t.branches[index], rest)
+	case "record":
+		for _, field := range t.fields {
+			rest, err := skipAvroDatum(field.typ, buf)
+			if err != nil {
+				return buf, err
+			}
+			buf = rest
+		}
+		return buf, nil
+	case "enum":
+		_, rest, err := readAvroLong(buf)
+		return rest, err
+	case "fixed":
+		if len(buf) < t.size {
+			return buf, errAvroShortBuffer
+		}
+		return buf[t.size:], nil
+	case "array":
+		return readAvroBlocks(buf, avroMinSize(t.items, nil), func(buf []byte) ([]byte, error) {
+			return skipAvroDatum(t.items, buf)
+		})
+	case "map":
+		return readAvroBlocks(buf, 1+avroMinSize(t.values, nil), func(buf []byte) ([]byte, error) {
+			_, rest, err := readAvroBytes(buf)
+			if err != nil {
+				return buf, err
+			}
+			return skipAvroDatum(t.values, rest)
+		})
+	}
+	_, rest, err := readAvroPrimitive(t.kind(), buf)
+	return rest, err
+}
+
+// maxAvroBlockCount caps the number of items of the arrays and maps
+// whose items can take no byte at all, such as nulls, so that hostile
+// data can't make the decoder loop and allocate without limit. Other
+// items are bounded by the size of the data.
+const maxAvroBlockCount = 1 << 20
+
+// readAvroBlocks reads the blocks of arrays and maps, calling
+// item for each of their items, which take at least itemSize
+// bytes. The byte size that precedes blocks with a negative
+// count isn't needed, so it is ignored.
+func readAvroBlocks(buf []byte, itemSize int, item func([]byte) ([]byte, error)) ([]byte, error) {
+	var total int64
+	for {
+		count, rest, err := readAvroLong(buf)
+		if err != nil {
+			return buf, err
+		}
+		buf = rest
+		if count == 0 {
+			return buf, nil
+		}
+		if count < 0 {
+			count = -count
+			if _, buf, err = readAvroLong(buf); err != nil {
+				return buf, err
+			}
+		}
+		total += count
+		switch {
+		case count < 0 || total > maxAvroBlockCount && itemSize == 0:
+			return buf, fmt.Errorf("block count %d exceeds the maximum of %d items", count, maxAvroBlockCount)
+		case itemSize > 0 && count > int64(len(buf)/itemSize):
+			return buf, errAvroShortBuffer
+		}
+		for ; count > 0; count-- {
+			if buf, err = item(buf); err != nil {
+				return buf, err
+			}
+		}
+	}
+}
+
+// avroMinSize returns the minimum number of bytes a datum of the
+// given type is encoded with. Records being measured are skipped
+// when found again, which only happens with invalid schemas.
+func avroMinSize(t *avroType, measuring map[*avroType]bool) int {
+	switch t.kind() {
+	case "null":
+		return 0
+	case "float":
+		return 4
+	case "double":
+		return 8
+	case "fixed":
+		return t.size
+	case "record":
+		if measuring[t] {
+			return 0
+		}
+		if measuring == nil {
+			measuring = map[*avroType]bool{}
+		}
+		measuring[t] = true
+		size := 0
+		for _, field := range t.fields {
+			size += avroMinSize(field.typ, measuring)
+		}
+		delete(measuring, t)
+		return size
+	}
+	return 1
+}
+
+func readAvroLong(buf []byte) (int64, []byte, error) {
+	var value uint64
+	for i := 0; i < len(buf) && i < binary.MaxVarintLen64; i++ {
+		value |= uint64(buf[i]&0x7f) << (7 * uint(i))
+		if buf[i]&0x80 == 0 {
+			return int64(value>>1) ^ -int64(value&1), buf[i+1:], nil
+		}
+	}
+	return 0, buf, errAvroShortBuffer
+}
+
+func readAvroBytes(buf []byte) ([]byte, []byte, error) {
+	size, rest, err := readAvroLong(buf)
+	if err != nil {
+		return nil, buf, err
+	}
+	if size < 0 || size > int64(len(rest)) {
+		return nil, buf, errAvroShortBuffer
+	}
+	return rest[:size], rest[size:], nil
+}
@@ -0,0 +1,203 @@
+package srclient
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func resolveAvro(t *testing.T, readerSchema, writerSchema string, native interface{}) (interface{}, error) {
+	writerCodec, err := goavro.NewCodec(writerSchema)
+	assert.NoError(t, err)
+	data, err := writerCodec.BinaryFromNative(nil, native)
+	assert.NoError(t, err)
+
+	reader, err := parseAvroSchema(readerSchema)
+	assert.NoError(t, err)
+	writer, err := parseAvroSchema(writerSchema)
+	assert.NoError(t, err)
+	resolver, err := newAvroResolver(reader, writer, nil)
+	if err != nil {
+		return nil, err
+	}
+	resolved, rest, err := resolver.NativeFromBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	assert.Empty(t, rest)
+
+	// What the resolver returns must be usable with the reader codec.
+	readerCodec, err := goavro.NewCodec(readerSchema)
+	assert.NoError(t, err)
+	_, err = readerCodec.BinaryFromNative(nil, resolved)
+	assert.NoError(t, err)
+	return resolved, nil
+}
+
+func TestAvroResolver_Records(t *testing.T) {
+	writer := `{"type": "record", "name": "User", "fields": [
+		{"name": "id", "type": "int"},
+		{"name": "name", "type": "string"},
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "nickname", "type": ["null", "string"]}
+	]}`
+	reader := `{"type": "record", "name": "Person", "aliases": ["User"], "fields": [
+		{"name": "id", "type": "long"},
+		{"name": "fullName", "aliases": ["name"], "type": "bytes"},
+		{"name": "nickname", "type": ["null", "string"], "default": null},
+		{"name": "score", "type": "double", "default": 1.5},
+		{"name": "address", "type": {"type": "record", "name": "Address", "fields": [
+			{"name": "city", "type": "string"}]}, "default": {"city": "Lisbon"}},
+		{"name": "country", "type": ["string", "null"], "default": "PT"}
+	]}`
+
+	resolved, err := resolveAvro(t, reader, writer, map[string]interface{}{
+		"id":       int32(7),
+		"name":     "Gopher",
+		"tags":     []interface{}{"a", "b"},
+		"nickname": goavro.Union("string", "go"),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"id":       int64(7),
+		"fullName": []byte("Gopher"),
+		"nickname": map[string]interface{}{"string": "go"},
+		"score":    1.5,
+		"address":  map[string]interface{}{"city": "Lisbon"},
+		"country":  map[string]interface{}{"string": "PT"},
+	}, resolved)
+}
+
+func TestAvroResolver_Promotions(t *testing.T) {
+	cases := []struct {
+		reader, writer   string
+		native, expected interface{}
+	}{
+		{`"long"`, `"int"`, int32(3), int64(3)},
+		{`"float"`, `"long"`, int64(3), float32(3)},
+		{`"double"`, `"float"`, float32(1.5), float64(1.5)},
+		{`"string"`, `"bytes"`, []byte("abc"), "abc"},
+		{`["null", "double"]`, `"int"`, int32(2), map[string]interface{}{"double": float64(2)}},
+		{`["null", "long"]`, `["null", "int"]`, nil, nil},
+		{`"long"`, `["int", "long"]`, goavro.Union("int", int32(4)), int64(4)},
+		{`{"type": "long", "logicalType": "timestamp-millis"}`, `"long"`, int64(1500),
+			time.Unix(1, 500*int64(time.Millisecond)).UTC()},
+		{`{"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}`, `"bytes"`, []byte{0xff, 0x38},
+			big.NewRat(-200, 100)},
+	}
+	for _, c := range cases {
+		resolved, err := resolveAvro(t, c.reader, c.writer, c.native)
+		assert.NoError(t, err, c.reader)
+		assert.Equal(t, c.expected, resolved, c.reader)
+	}
+}
+
+func TestAvroResolver_Enums(t *testing.T) {
+	writer := `{"type": "enum", "name": "Color", "symbols": ["RED", "GREEN", "BLUE"]}`
+
+	resolved, err := resolveAvro(t, `{"type": "enum", "name": "Color", "symbols": ["RED", "GREEN", "OTHER"], "default": "OTHER"}`, writer, "BLUE")
+	assert.NoError(t, err)
+	assert.Equal(t, "OTHER", resolved)
+
+	resolved, err = resolveAvro(t, `{"type": "enum", "name": "Color", "symbols": ["GREEN", "RED"]}`, writer, "RED")
+	assert.NoError(t, err)
+	assert.Equal(t, "RED", resolved)
+
+	// Symbols unknown to a reader without default only fail when found.
+	_, err = resolveAvro(t, `{"type": "enum", "name": "Color", "symbols": ["GREEN", "RED"]}`, writer, "BLUE")
+	assert.Error(t, err)
+}
+
+func TestAvroResolver_SkipsWriterFields(t *testing.T) {
+	writer := `{"type": "record", "name": "R", "fields": [
+		{"name": "a", "type": {"type": "map", "values": {"type": "array", "items": "long"}}},
+		{"name": "b", "type": ["null", {"type": "fixed", "name": "F", "size": 3}]},
+		{"name": "c", "type": "string"},
+		{"name": "d", "type": {"type": "enum", "name": "E", "symbols": ["X", "Y"]}}
+	]}`
+	reader := `{"type": "record", "name": "R", "fields": [{"name": "c", "type": "string"}]}`
+
+	resolved, err := resolveAvro(t, reader, writer, map[string]interface{}{
+		"a": map[string]interface{}{"k": []interface{}{int64(1), int64(2)}},
+		"b": goavro.Union("F", []byte("xyz")),
+		"c": "kept",
+		"d": "Y",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"c": "kept"}, resolved)
+}
+
+func TestAvroResolver_Recursive(t *testing.T) {
+	writer := `{"type": "record", "name": "Node", "fields": [
+		{"name": "value", "type": "int"},
+		{"name": "next", "type": ["null", "Node"]}
+	]}`
+	reader := `{"type": "record", "name": "Node", "fields": [
+		{"name": "value", "type": "long"},
+		{"name": "next", "type": ["null", "Node"]}
+	]}`
+
+	resolved, err := resolveAvro(t, reader, writer, map[string]interface{}{
+		"value": int32(1),
+		"next":  goavro.Union("Node", map[string]interface{}{"value": int32(2), "next": nil}),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"value": int64(1),
+		"next":  map[string]interface{}{"Node": map[string]interface{}{"value": int64(2), "next": nil}},
+	}, resolved)
+}
+
+func TestAvroResolver_Unresolvable(t *testing.T) {
+	cases := map[string]string{
+		`"int"`: `"string"`,
+		`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`: `{"type": "record", "name": "R", "fields": []}`,
+		`{"type": "record", "name": "R", "fields": []}`:                             `{"type": "record", "name": "S", "fields": []}`,
+		`{"type": "fixed", "name": "F", "size": 4}`:                                 `{"type": "fixed", "name": "F", "size": 3}`,
+		`["null", "string"]`: `"long"`,
+	}
+	for reader, writer := range cases {
+		r, err := parseAvroSchema(reader)
+		assert.NoError(t, err)
+		w, err := parseAvroSchema(writer)
+		assert.NoError(t, err)
+		_, err = newAvroResolver(r, w, nil)
+		assert.Error(t, err, reader)
+	}
+}
+
+func TestAvroResolver_HostileBlockCounts(t *testing.T) {
+	nulls, err := parseAvroSchema(`{"type": "array", "items": "null"}`)
+	assert.NoError(t, err)
+	resolver, err := newAvroResolver(nulls, nulls, nil)
+	assert.NoError(t, err)
+	// A single block of 2^40 nulls, which take no byte.
+	_, _, err = resolver.NativeFromBinary([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x40, 0x00})
+	assert.Error(t, err)
+	// Two blocks of nulls that are fine apart, but not together.
+	_, _, err = resolver.NativeFromBinary([]byte{0x80, 0x80, 0x80, 0x01, 0x80, 0x80, 0x80, 0x01, 0x00})
+	assert.Error(t, err)
+
+	longs, err := parseAvroSchema(`{"type": "map", "values": "long"}`)
+	assert.NoError(t, err)
+	resolver, err = newAvroResolver(longs, longs, nil)
+	assert.NoError(t, err)
+	// A block of a million entries in a few bytes.
+	_, _, err = resolver.NativeFromBinary([]byte{0x80, 0x89, 0x7a, 0x00, 0x00})
+	assert.Error(t, err)
+}
+
+func TestAvroResolver_DropsFailedPlans(t *testing.T) {
+	reader, err := parseAvroSchema(`{"type": "record", "name": "A", "fields": [{"name": "a", "type": "int"}]}`)
+	assert.NoError(t, err)
+	writer, err := parseAvroSchema(`{"type": "record", "name": "A", "fields": [{"name": "a", "type": "string"}]}`)
+	assert.NoError(t, err)
+
+	builder := &avroPlanBuilder{plans: map[[2]*avroType]*avroReadPlan{}}
+	_, err = builder.build(reader, writer)
+	assert.Error(t, err)
+	assert.Empty(t, builder.plans)
+}
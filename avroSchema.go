@@ -0,0 +1,300 @@
+package srclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// avroType is a parsed Avro schema. The library relies on goavro to
+// encode and decode data, but goavro doesn't expose the structure of
+// the schemas it compiles, which is needed to reason about them (for
+// instance to check compatibility between two of them). Named types
+// are parsed once, so references to them point to the same avroType,
+// which means that recursive schemas produce cyclic structures.
+type avroType struct {
+	typ         string
+	name        string
+	namespace   string
+	aliases     []string
+	doc         string
+	fields      []*avroField
+	symbols     []string
+	enumDefault *string
+	items       *avroType
+	values      *avroType
+	branches    []*avroType
+	size        int
+	logicalType string
+	precision   int
+	scale       int
+}
+
+type avroField struct {
+	name         string
+	aliases      []string
+	doc          string
+	typ          *avroType
+	hasDefault   bool
+	defaultValue interface{}
+	order        string
+}
+
+var avroPrimitives = map[string]bool{
+	"null": true, "boolean": true, "int": true, "long": true,
+	"float": true, "double": true, "bytes": true, "string": true,
+}
+
+// fullName returns the name of named types
+// qualified by their namespace, if any.
+func (t *avroType) fullName() string {
+	if len(t.namespace) == 0 {
+		return t.name
+	}
+	return t.namespace + "." + t.name
+}
+
+// isNamed tells whether the type is a record, an enum or
+// a fixed, whose identity is given by their full name.
+func (t *avroType) isNamed() bool {
+	return t.typ == "record" || t.typ == "error" || t.typ == "enum" || t.typ == "fixed"
+}
+
+// kind returns the type of the schema, folding the
+// "error" type that protocols use into "record".
+func (t *avroType) kind() string {
+	if t.typ == "error" {
+		return "record"
+	}
+	return t.typ
+}
+
+// String returns a short description of the type,
+// used to report problems in a readable manner.
+func (t *avroType) String() string {
+	if t.isNamed() {
+		return t.fullName()
+	}
+	if t.typ == "union" {
+		names := make([]string, len(t.branches))
+		for i, branch := range t.branches {
+			names[i] = branch.String()
+		}
+		return "[" + strings.Join(names, ",") + "]"
+	}
+	return t.typ
+}
+
+// field returns the field with the given name,
+// or nil if the record has no such field.
+func (t *avroType) field(name string) *avroField {
+	for _, field := range t.fields {
+		if field.name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+// avroNames holds the named types known while parsing a schema.
+type avroNames map[string]*avroType
+
+// parseAvroSchema parses the given Avro schema. Named types defined
+// elsewhere, for instance in schemas that are referenced, can be made
+// available to the parser by passing them as names.
+func parseAvroSchema(schema string, names ...avroNames) (*avroType, error) {
+	decoder := json.NewDecoder(bytes.NewBufferString(schema))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, fmt.Errorf("invalid avro schema: %w", err)
+	}
+	known := avroNames{}
+	for _, n := range names {
+		for name, t := range n {
+			known[name] = t
+		}
+	}
+	return known.parse(raw, "")
+}
+
+func (names avroNames) parse(raw interface{}, namespace string) (*avroType, error) {
+	switch value := raw.(type) {
+	case string:
+		return names.resolve(value, namespace)
+	case []interface{}:
+		union := &avroType{typ: "union"}
+		for _, branch := range value {
+			t, err := names.parse(branch, namespace)
+			if err != nil {
+				return nil, err
+			}
+			if t.typ == "union" {
+				return nil, fmt.Errorf("invalid avro schema: unions may not immediately contain other unions")
+			}
+			union.branches = append(union.branches, t)
+		}
+		return union, nil
+	case map[string]interface{}:
+		return names.parseObject(value, namespace)
+	default:
+		return nil, fmt.Errorf("invalid avro schema: unexpected %v", raw)
+	}
+}
+
+func (names avroNames) resolve(name, namespace string) (*avroType, error) {
+	if avroPrimitives[name] {
+		return &avroType{typ: name}, nil
+	}
+	if !strings.Contains(name, ".") && len(namespace) > 0 {
+		if t, ok := names[namespace+"."+name]; ok {
+			return t, nil
+		}
+	}
+	if t, ok := names[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("invalid avro schema: unknown type %q", name)
+}
+
+func (names avroNames) parseObject(object map[string]interface{}, namespace string) (*avroType, error) {
+	typ, ok := object["type"].(string)
+	if !ok {
+		// Some tools nest a full schema under "type"
+		// instead of using it directly, so be lenient.
+		if nested, exists := object["type"]; exists {
+			return names.parse(nested, namespace)
+		}
+		return nil, fmt.Errorf("invalid avro schema: missing type in %v", object)
+	}
+
+	t := &avroType{typ: typ}
+	t.logicalType, _ = object["logicalType"].(string)
+	t.precision = jsonInt(object["precision"])
+	t.scale = jsonInt(object["scale"])
+	t.doc, _ = object["doc"].(string)
+
+	switch typ {
+	case "record", "error", "enum", "fixed":
+		if err := names.define(t, object, namespace); err != nil {
+			return nil, err
+		}
+	case "array":
+		items, err := names.parse(object["items"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		t.items = items
+		return t, nil
+	case "map":
+		values, err := names.parse(object["values"], namespace)
+		if err != nil {
+			return nil, err
+		}
+		t.values = values
+		return t, nil
+	default:
+		if !avroPrimitives[typ] {
+			// A reference to a named type, written as an object.
+			return names.resolve(typ, namespace)
+		}
+		return t, nil
+	}
+
+	switch typ {
+	case "fixed":
+		t.size = jsonInt(object["size"])
+	case "enum":
+		symbols, _ := object["symbols"].([]interface{})
+		for _, symbol := range symbols {
+			s, _ := symbol.(string)
+			t.symbols = append(t.symbols, s)
+		}
+		if def, ok := object["default"].(string); ok {
+			t.enumDefault = &def
+		}
+	default:
+		fields, ok := object["fields"].([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid avro schema: record %s has no fields", t.fullName())
+		}
+		for _, rawField := range fields {
+			field, err := names.parseField(rawField, t.namespace)
+			if err != nil {
+				return nil, err
+			}
+			t.fields = append(t.fields, field)
+		}
+	}
+	return t, nil
+}
+
+// define registers a named type before its contents are
+// parsed, which allows records to reference themselves.
+func (names avroNames) define(t *avroType, object map[string]interface{}, namespace string) error {
+	name, _ := object["name"].(string)
+	if len(name) == 0 {
+		return fmt.Errorf("invalid avro schema: %s without a name", t.typ)
+	}
+	if ns, ok := object["namespace"].(string); ok {
+		namespace = ns
+	}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		namespace, name = name[:i], name[i+1:]
+	}
+	t.name, t.namespace = name, namespace
+
+	aliases, _ := object["aliases"].([]interface{})
+	for _, alias := range aliases {
+		a, _ := alias.(string)
+		if !strings.Contains(a, ".") && len(namespace) > 0 {
+			a = namespace + "." + a
+		}
+		t.aliases = append(t.aliases, a)
+	}
+
+	if _, exists := names[t.fullName()]; exists {
+		return fmt.Errorf("invalid avro schema: %s is defined more than once", t.fullName())
+	}
+	names[t.fullName()] = t
+	return nil
+}
+
+func (names avroNames) parseField(raw interface{}, namespace string) (*avroField, error) {
+	object, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid avro schema: unexpected field %v", raw)
+	}
+	field := &avroField{}
+	field.name, _ = object["name"].(string)
+	if len(field.name) == 0 {
+		return nil, fmt.Errorf("invalid avro schema: field without a name")
+	}
+	field.doc, _ = object["doc"].(string)
+	field.order, _ = object["order"].(string)
+	aliases, _ := object["aliases"].([]interface{})
+	for _, alias := range aliases {
+		a, _ := alias.(string)
+		field.aliases = append(field.aliases, a)
+	}
+	field.defaultValue, field.hasDefault = object["default"]
+
+	typ, err := names.parse(object["type"], namespace)
+	if err != nil {
+		return nil, fmt.Errorf("field %s: %w", field.name, err)
+	}
+	field.typ = typ
+	return field, nil
+}
+
+func jsonInt(value interface{}) int {
+	switch v := value.(type) {
+	case json.Number:
+		i, _ := v.Int64()
+		return int(i)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
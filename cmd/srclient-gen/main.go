@@ -0,0 +1,89 @@
+// Command srclient-gen generates Go types, and the functions to serialize
+// and deserialize them with srclient, from Avro, JSON and Protobuf
+// schemas. Schemas are either fetched from Schema Registry, by subject
+// and version:
+//
+//	srclient-gen -url http://localhost:8081 -subject orders -version latest -package events
+//
+// or read from local .avsc, .json and .proto files:
+//
+//	srclient-gen -file order.avsc -package events -out order.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/riferrei/srclient"
+)
+
+func main() {
+	url := flag.String("url", "http://localhost:8081", "URL of Schema Registry")
+	username := flag.String("username", "", "username to authenticate with Schema Registry")
+	password := flag.String("password", "", "password to authenticate with Schema Registry")
+	subject := flag.String("subject", "", "subject of the schema, without the -key or -value suffix")
+	version := flag.String("version", "latest", "version of the schema")
+	isKey := flag.Bool("key", false, "whether the schema is the one of record keys")
+	file := flag.String("file", "", "local .avsc, .json or .proto file to read the schema from")
+	pkg := flag.String("package", "", "name of the package of the generated code")
+	typeName := flag.String("type", "", "name of the root type of JSON schemas without a title")
+	out := flag.String("out", "", "file to write the generated code to, instead of the standard output")
+	flag.Parse()
+
+	if err := run(*url, *username, *password, *subject, *version, *isKey, *file, *pkg, *typeName, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "srclient-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(url, username, password, subject, version string, isKey bool, file, pkg, typeName, out string) error {
+	var schema string
+	var schemaType srclient.SchemaType
+	switch {
+	case len(file) > 0:
+		switch strings.ToLower(filepath.Ext(file)) {
+		case ".avsc":
+			schemaType = srclient.Avro
+		case ".json":
+			schemaType = srclient.Json
+		case ".proto":
+			schemaType = srclient.Protobuf
+		default:
+			return fmt.Errorf("unknown kind of schema file %s", file)
+		}
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		schema = string(content)
+	case len(subject) > 0:
+		client := srclient.CreateSchemaRegistryClientWithOptions(url, srclient.WithCredentials(username, password))
+		var registered *srclient.Schema
+		var err error
+		if version == "latest" {
+			registered, err = client.GetLatestSchema(subject, isKey)
+		} else {
+			registered, err = client.GetSchemaByVersion(subject, version, isKey)
+		}
+		if err != nil {
+			return err
+		}
+		schema, schemaType = registered.Schema(), registered.SchemaType()
+	default:
+		return fmt.Errorf("either -file or -subject is required")
+	}
+
+	code, err := srclient.GenerateGoCode(schema, schemaType, srclient.GoCodeOptions{Package: pkg, TypeName: typeName})
+	if err != nil {
+		return err
+	}
+	if len(out) == 0 {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	return ioutil.WriteFile(out, code, 0644)
+}
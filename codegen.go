@@ -0,0 +1,586 @@
+package srclient
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GoCodeOptions configures GenerateGoCode.
+type GoCodeOptions struct {
+	// Package is the name of the package of the generated code.
+	Package string
+	// TypeName names the type generated for the root of JSON
+	// schemas, when they have no title. Avro records and Protobuf
+	// messages are always named after their own name.
+	TypeName string
+}
+
+// GenerateGoCode returns Go code for the types described by an Avro,
+// JSON or Protobuf schema, such as one fetched with GetSchemaByVersion,
+// along with functions to serialize and deserialize the root type with
+// the Serializer and Deserializer of this package. The schema itself is
+// kept in a constant, which can be used as a reader schema.
+//
+// Avro records become structs with avro tags, enums string types with
+// a constant per symbol, fixed types byte arrays, nullable unions
+// pointers, and other unions interface{}. JSON objects become structs
+// with json tags, where optional properties are pointers or omitted
+// when empty. Protobuf messages, nested ones included, become structs
+// with protobuf tags, whose blank field names their message, and enums
+// int32 types with a constant per value. Optional fields and the fields
+// of oneofs are pointers, and messages of other schemas bytes. Every
+// message can be serialized, the first one being the root type.
+func GenerateGoCode(schema string, schemaType SchemaType, options GoCodeOptions) ([]byte, error) {
+	if len(options.Package) == 0 {
+		return nil, fmt.Errorf("a package name is required to generate code")
+	}
+	generator := &goGenerator{imports: map[string]bool{}, declared: map[string]bool{}}
+
+	var rootType string
+	var err error
+	switch schemaType {
+	case Avro:
+		rootType, err = generator.avroRoot(schema)
+	case Json:
+		rootType, err = generator.jsonRoot(schema, options.TypeName)
+	case Protobuf:
+		rootType, err = generator.protobufRoot(schema)
+	default:
+		return nil, fmt.Errorf("invalid schema type. valid values are Avro, Json, or Protobuf")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var code bytes.Buffer
+	code.WriteString("// Code generated by srclient-gen. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&code, "package %s\n\n", options.Package)
+	code.WriteString("import (\n")
+	for _, imported := range sortedKeys(generator.imports) {
+		fmt.Fprintf(&code, "\t%q\n", imported)
+	}
+	if len(generator.imports) > 0 {
+		code.WriteString("\n")
+	}
+	code.WriteString("\t\"github.com/riferrei/srclient\"\n)\n\n")
+
+	language := map[SchemaType]string{Avro: "Avro", Json: "JSON", Protobuf: "Protobuf"}[schemaType]
+	fmt.Fprintf(&code, "// %sSchema is the %s schema %s was generated from.\n", rootType, language, rootType)
+	fmt.Fprintf(&code, "const %sSchema = %s\n\n", rootType, goStringLiteral(schema))
+	code.Write(generator.declarations.Bytes())
+	serialized := generator.messageTypes
+	if len(serialized) == 0 {
+		serialized = []string{rootType}
+	}
+	for _, typeName := range serialized {
+		fmt.Fprintf(&code, `
+// Serialize encodes the %[1]s with the schema, in the wire format of Schema Registry.
+func (value *%[1]s) Serialize(serializer *srclient.Serializer, schema *srclient.Schema) ([]byte, error) {
+	return serializer.Serialize(schema, value)
+}
+
+// Deserialize%[1]s decodes a record in the wire format of Schema Registry.
+func Deserialize%[1]s(deserializer *srclient.Deserializer, data []byte) (*%[1]s, error) {
+	value := &%[1]s{}
+	if err := deserializer.DeserializeInto(data, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+`, typeName)
+	}
+
+	return format.Source(code.Bytes())
+}
+
+type goGenerator struct {
+	imports      map[string]bool
+	declared     map[string]bool
+	declarations bytes.Buffer
+	// messageTypes are the types of Protobuf messages,
+	// which can all be serialized.
+	messageTypes []string
+}
+
+// declare reserves the name of a type, failing if it is taken.
+func (generator *goGenerator) declare(name, origin string) error {
+	if generator.declared[name] {
+		return fmt.Errorf("more than one type would be named %s, the last one being %s", name, origin)
+	}
+	generator.declared[name] = true
+	return nil
+}
+
+func (generator *goGenerator) avroRoot(schema string) (string, error) {
+	root, err := parseAvroSchema(schema)
+	if err != nil {
+		return "", err
+	}
+	if root.kind() != "record" {
+		return "", fmt.Errorf("go code can only be generated for avro records, not %s", root)
+	}
+	defined := map[*avroType]string{}
+	if _, err := generator.avroType(root, defined); err != nil {
+		return "", err
+	}
+	return defined[root], nil
+}
+
+// avroType returns the Go type for an Avro type, declaring the
+// types of records, enums and fixed types the first time they
+// are found, which also handles recursive records.
+func (generator *goGenerator) avroType(t *avroType, defined map[*avroType]string) (string, error) {
+	if name, ok := defined[t]; ok {
+		return name, nil
+	}
+	switch logicalTypeMapping(nil).of(t) {
+	case Date, TimestampMillis, TimestampMicros, LocalTimestampMillis, LocalTimestampMicros:
+		generator.imports["time"] = true
+		return "time.Time", nil
+	case TimeMillis, TimeMicros:
+		generator.imports["time"] = true
+		return "time.Duration", nil
+	case Decimal:
+		generator.imports["math/big"] = true
+		return "*big.Rat", nil
+	case UUID:
+		return "string", nil
+	}
+
+	switch t.kind() {
+	case "null":
+		return "interface{}", nil
+	case "boolean":
+		return "bool", nil
+	case "int":
+		return "int32", nil
+	case "long":
+		return "int64", nil
+	case "float":
+		return "float32", nil
+	case "double":
+		return "float64", nil
+	case "bytes":
+		return "[]byte", nil
+	case "string":
+		return "string", nil
+	case "array":
+		items, err := generator.avroType(t.items, defined)
+		return "[]" + items, err
+	case "map":
+		values, err := generator.avroType(t.values, defined)
+		return "map[string]" + values, err
+	case "union":
+		var branches []*avroType
+		for _, branch := range t.branches {
+			if branch.typ != "null" {
+				branches = append(branches, branch)
+			}
+		}
+		if len(branches) != 1 {
+			return "interface{}", nil
+		}
+		branch, err := generator.avroType(branches[0], defined)
+		if err != nil || len(branches) == len(t.branches) || isNilableGoType(branch) {
+			return branch, err
+		}
+		return "*" + branch, nil
+	}
+
+	name := goIdentifier(t.name)
+	if err := generator.declare(name, t.fullName()); err != nil {
+		return "", err
+	}
+	defined[t] = name
+	var declaration bytes.Buffer
+	writeGoDoc(&declaration, name, t.doc, fmt.Sprintf("is the Avro %s %s.", t.kind(), t.fullName()))
+	switch t.kind() {
+	case "fixed":
+		fmt.Fprintf(&declaration, "type %s [%d]byte\n\n", name, t.size)
+	case "enum":
+		fmt.Fprintf(&declaration, "type %s string\n\n", name)
+		fmt.Fprintf(&declaration, "// Symbols of %s.\nconst (\n", name)
+		for _, symbol := range t.symbols {
+			fmt.Fprintf(&declaration, "\t%s%s %s = %q\n", name, goIdentifier(strings.ToLower(symbol)), name, symbol)
+		}
+		declaration.WriteString(")\n\n")
+	case "record":
+		var fields bytes.Buffer
+		for _, field := range t.fields {
+			typ, err := generator.avroType(field.typ, defined)
+			if err != nil {
+				return "", fmt.Errorf("field %s of %s: %w", field.name, t.fullName(), err)
+			}
+			if len(field.doc) > 0 {
+				fmt.Fprintf(&fields, "\t// %s\n", strings.ReplaceAll(field.doc, "\n", "\n\t// "))
+			}
+			fmt.Fprintf(&fields, "\t%s %s `avro:%q json:%q`\n", goIdentifier(field.name), typ, field.name, field.name)
+		}
+		fmt.Fprintf(&declaration, "type %s struct {\n%s}\n\n", name, fields.String())
+	}
+	generator.declarations.Write(declaration.Bytes())
+	return name, nil
+}
+
+func (generator *goGenerator) jsonRoot(schema, typeName string) (string, error) {
+	raw, err := decodeSchemaJSON(schema)
+	if err != nil {
+		return "", fmt.Errorf("invalid json schema: %w", err)
+	}
+	root, ok := raw.(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("go code can only be generated for json schemas of objects")
+	}
+	if title, ok := root["title"].(string); ok && len(typeName) == 0 {
+		typeName = title
+	}
+	if len(typeName) == 0 {
+		return "", fmt.Errorf("json schemas without a title need a type name")
+	}
+	if jsonTypeOf(root) != "object" {
+		return "", fmt.Errorf("go code can only be generated for json schemas of objects")
+	}
+
+	state := &jsonGenerator{goGenerator: generator, root: root, defined: map[string]string{}}
+	name := goIdentifier(typeName)
+	if err := state.object(name, root); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+type jsonGenerator struct {
+	*goGenerator
+	root    map[string]interface{}
+	defined map[string]string
+}
+
+// jsonTypeOf returns the type of a JSON schema, ignoring "null"
+// in lists of types, or "object" when it has properties.
+func jsonTypeOf(schema map[string]interface{}) string {
+	switch typ := schema["type"].(type) {
+	case string:
+		return typ
+	case []interface{}:
+		var types []string
+		for _, t := range typ {
+			if s, ok := t.(string); ok && s != "null" {
+				types = append(types, s)
+			}
+		}
+		if len(types) == 1 {
+			return types[0]
+		}
+		return ""
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	return ""
+}
+
+// jsonNullable tells whether the schema allows null values.
+func jsonNullable(schema map[string]interface{}) bool {
+	if types, ok := schema["type"].([]interface{}); ok {
+		for _, t := range types {
+			if t == "null" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (state *jsonGenerator) object(name string, schema map[string]interface{}) error {
+	if err := state.declare(name, name); err != nil {
+		return err
+	}
+	required := map[string]bool{}
+	if list, ok := schema["required"].([]interface{}); ok {
+		for _, property := range list {
+			if s, ok := property.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+	properties, _ := schema["properties"].(map[string]interface{})
+
+	var fields bytes.Buffer
+	for _, property := range sortedKeys(properties) {
+		propertySchema, _ := properties[property].(map[string]interface{})
+		fieldName := goIdentifier(property)
+		typ, err := state.typeFor(name+fieldName, propertySchema)
+		if err != nil {
+			return fmt.Errorf("property %s of %s: %w", property, name, err)
+		}
+		tag := property
+		if !required[property] || jsonNullable(propertySchema) {
+			tag += ",omitempty"
+			if !isNilableGoType(typ) {
+				typ = "*" + typ
+			}
+		}
+		if description, ok := propertySchema["description"].(string); ok {
+			fmt.Fprintf(&fields, "\t// %s\n", strings.ReplaceAll(description, "\n", "\n\t// "))
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:%q`\n", fieldName, typ, tag)
+	}
+
+	description, _ := schema["description"].(string)
+	writeGoDoc(&state.declarations, name, description, "is generated from a JSON schema.")
+	fmt.Fprintf(&state.declarations, "type %s struct {\n%s}\n\n", name, fields.String())
+	return nil
+}
+
+func (state *jsonGenerator) typeFor(name string, schema map[string]interface{}) (string, error) {
+	if schema == nil {
+		return "interface{}", nil
+	}
+	if ref, ok := schema["$ref"].(string); ok {
+		return state.reference(ref)
+	}
+	switch jsonTypeOf(schema) {
+	case "string":
+		if schema["format"] == "date-time" {
+			state.imports["time"] = true
+			return "time.Time", nil
+		}
+		return "string", nil
+	case "integer":
+		return "int64", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		items, _ := schema["items"].(map[string]interface{})
+		typ, err := state.typeFor(name+"Item", items)
+		return "[]" + typ, err
+	case "object":
+		if properties, ok := schema["properties"].(map[string]interface{}); ok && len(properties) > 0 {
+			return name, state.object(name, schema)
+		}
+		values, _ := schema["additionalProperties"].(map[string]interface{})
+		typ, err := state.typeFor(name+"Value", values)
+		return "map[string]" + typ, err
+	}
+	return "interface{}", nil
+}
+
+// reference returns the type of local definitions, which are
+// generated once, named after the definition.
+func (state *jsonGenerator) reference(ref string) (string, error) {
+	if name, ok := state.defined[ref]; ok {
+		return name, nil
+	}
+	parts := strings.Split(strings.TrimPrefix(ref, "#/"), "/")
+	if !strings.HasPrefix(ref, "#/") || len(parts) != 2 {
+		return "", fmt.Errorf("only local references to definitions are supported, not %s", ref)
+	}
+	definitions, _ := state.root[parts[0]].(map[string]interface{})
+	definition, ok := definitions[parts[1]].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("unknown reference %s", ref)
+	}
+	name := goIdentifier(parts[1])
+	if jsonTypeOf(definition) == "object" {
+		state.defined[ref] = name
+		return name, state.object(name, definition)
+	}
+	typ, err := state.typeFor(name, definition)
+	state.defined[ref] = typ
+	return typ, err
+}
+
+func (generator *goGenerator) protobufRoot(schema string) (string, error) {
+	file, err := parseProtobufSchema(schema)
+	if err != nil {
+		return "", err
+	}
+	if len(file.messages) == 0 {
+		return "", fmt.Errorf("go code can only be generated for protobuf schemas with messages")
+	}
+
+	// Types are named first, as fields can refer to types defined
+	// after them. Nested types are prefixed with their parents.
+	names := map[string]string{}
+	var messages []*protoMessage
+	var enums []*protoEnum
+	var name func(scope string, nestedMessages []*protoMessage, nestedEnums []*protoEnum) error
+	name = func(scope string, nestedMessages []*protoMessage, nestedEnums []*protoEnum) error {
+		for _, message := range nestedMessages {
+			names[message.fullName] = scope + goIdentifier(message.name)
+			if err := generator.declare(names[message.fullName], message.fullName); err != nil {
+				return err
+			}
+			messages = append(messages, message)
+			if err := name(names[message.fullName], message.messages, message.enums); err != nil {
+				return err
+			}
+		}
+		for _, enum := range nestedEnums {
+			names[enum.fullName] = scope + goIdentifier(enum.name)
+			if err := generator.declare(names[enum.fullName], enum.fullName); err != nil {
+				return err
+			}
+			enums = append(enums, enum)
+		}
+		return nil
+	}
+	if err := name("", file.messages, file.enums); err != nil {
+		return "", err
+	}
+
+	for _, message := range messages {
+		typeName := names[message.fullName]
+		var fields bytes.Buffer
+		fmt.Fprintf(&fields, "\t_ struct{} `protobuf:%q`\n", message.fullName)
+		for _, field := range message.fields {
+			kind := field.kind
+			if kind == "map" {
+				kind = "message"
+				if protoScalars[field.fullType] {
+					kind = "scalar"
+				}
+			}
+			typ, imported := protoGoType(kind, field.fullType, names)
+			switch {
+			case field.kind == "map":
+				key, _ := protoGoType("scalar", field.mapKey, names)
+				typ = "map[" + key + "]" + typ
+			case field.label == "repeated":
+				typ = "[]" + typ
+			case field.kind == "message" && !imported:
+				typ = "*" + typ
+			case field.label == "optional" || len(field.oneof) > 0:
+				if !isNilableGoType(typ) {
+					typ = "*" + typ
+				}
+			}
+			if imported {
+				fmt.Fprintf(&fields, "\t// Encoded %s messages.\n", field.fullType)
+			}
+			fmt.Fprintf(&fields, "\t%s %s `protobuf:%q json:%q`\n", goIdentifier(field.name), typ, field.name, field.name)
+		}
+		writeGoDoc(&generator.declarations, typeName, "", fmt.Sprintf("is the Protobuf message %s.", message.fullName))
+		fmt.Fprintf(&generator.declarations, "type %s struct {\n%s}\n\n", typeName, fields.String())
+		generator.messageTypes = append(generator.messageTypes, typeName)
+	}
+
+	for _, enum := range enums {
+		typeName := names[enum.fullName]
+		writeGoDoc(&generator.declarations, typeName, "", fmt.Sprintf("is the Protobuf enum %s.", enum.fullName))
+		fmt.Fprintf(&generator.declarations, "type %s int32\n\n", typeName)
+		fmt.Fprintf(&generator.declarations, "// Values of %s.\nconst (\n", typeName)
+		for _, value := range enum.values {
+			// Values are often prefixed with the name of their enum.
+			valueName := goIdentifier(strings.ToLower(value.name))
+			if trimmed := strings.TrimPrefix(valueName, goIdentifier(enum.name)); len(trimmed) > 0 && !unicode.IsDigit([]rune(trimmed)[0]) {
+				valueName = trimmed
+			}
+			fmt.Fprintf(&generator.declarations, "\t%s%s %s = %d\n", typeName, valueName, typeName, value.number)
+		}
+		generator.declarations.WriteString(")\n\n")
+	}
+	return names[file.messages[0].fullName], nil
+}
+
+// protoGoType returns the Go type of the values of a Protobuf type,
+// and whether it is a message of another schema, read as bytes.
+func protoGoType(kind, typ string, names map[string]string) (string, bool) {
+	switch kind {
+	case "message", "enum":
+		if name, ok := names[typ]; ok {
+			return name, false
+		}
+		return "[]byte", true
+	}
+	switch typ {
+	case "double":
+		return "float64", false
+	case "float":
+		return "float32", false
+	case "bytes":
+		return "[]byte", false
+	case "string", "bool":
+		return typ, false
+	}
+	return protoGoTypes[typ].String(), false
+}
+
+func isNilableGoType(typ string) bool {
+	return strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[") ||
+		strings.HasPrefix(typ, "*") || typ == "interface{}"
+}
+
+func writeGoDoc(buffer *bytes.Buffer, name, doc, fallback string) {
+	if len(doc) == 0 {
+		doc = fallback
+	} else if !strings.HasPrefix(doc, name+" ") {
+		doc = strings.ToLower(doc[:1]) + doc[1:]
+		doc = "is " + strings.TrimSuffix(doc, ".") + "."
+	}
+	if !strings.HasPrefix(doc, name+" ") {
+		doc = name + " " + doc
+	}
+	fmt.Fprintf(buffer, "// %s\n", strings.ReplaceAll(doc, "\n", "\n// "))
+}
+
+// goInitialisms are written in upper case, as Go code does.
+var goInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// goIdentifier turns a name such as "user_id" into an exported Go
+// identifier such as "UserID".
+func goIdentifier(name string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && i > 0 && unicode.IsLower(runes[i-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	var identifier strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); goInitialisms[upper] {
+			identifier.WriteString(upper)
+			continue
+		}
+		runes := []rune(w)
+		identifier.WriteString(string(unicode.ToUpper(runes[0])) + string(runes[1:]))
+	}
+	result := identifier.String()
+	if len(result) == 0 || unicode.IsDigit([]rune(result)[0]) {
+		result = "X" + result
+	}
+	return result
+}
+
+// goStringLiteral quotes a string, preferring raw strings.
+func goStringLiteral(s string) string {
+	if strings.Contains(s, "`") || strings.Contains(s, "\r") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
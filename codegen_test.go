@@ -0,0 +1,152 @@
+package srclient
+
+import (
+	"go/parser"
+	"go/token"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateGoCode_Avro(t *testing.T) {
+	code, err := GenerateGoCode(orderSchema, Avro, GoCodeOptions{Package: "events"})
+	assert.NoError(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "order.go", code, parser.AllErrors)
+	assert.NoError(t, err)
+
+	source := collapseSpaces(string(code))
+	assert.Contains(t, source, "// Code generated by srclient-gen. DO NOT EDIT.")
+	assert.Contains(t, source, "package events")
+	assert.Contains(t, source, "const OrderSchema = `")
+	assert.Contains(t, source, "type Order struct {")
+	assert.Contains(t, source, "ID int64 `avro:\"id\" json:\"id\"`")
+	assert.Contains(t, source, "Email *string `avro:\"email\" json:\"email\"`")
+	assert.Contains(t, source, "Lines []Line `avro:\"lines\" json:\"lines\"`")
+	assert.Contains(t, source, "PlacedAt time.Time `avro:\"placedAt\" json:\"placedAt\"`")
+	assert.Contains(t, source, "Total *big.Rat `avro:\"total\" json:\"total\"`")
+	assert.Contains(t, source, "Parent *Order `avro:\"parent\" json:\"parent\"`")
+	assert.Contains(t, source, "type Status string")
+	assert.Contains(t, source, "StatusShipped Status = \"SHIPPED\"")
+	assert.Contains(t, source, "type Hash [4]byte")
+	assert.Contains(t, source, "func (value *Order) Serialize(serializer *srclient.Serializer, schema *srclient.Schema) ([]byte, error) {")
+	assert.Contains(t, source, "func DeserializeOrder(deserializer *srclient.Deserializer, data []byte) (*Order, error) {")
+
+	_, err = GenerateGoCode(`"string"`, Avro, GoCodeOptions{Package: "events"})
+	assert.Error(t, err)
+	_, err = GenerateGoCode(orderSchema, Avro, GoCodeOptions{})
+	assert.Error(t, err)
+}
+
+func TestGenerateGoCode_Protobuf(t *testing.T) {
+	code, err := GenerateGoCode(orderProto+`
+message Shipment {
+  optional string carrier = 1;
+  oneof destination {
+    string address = 2;
+    Order.Line pickup = 3;
+  }
+  google.protobuf.Timestamp sent_at = 4;
+  map<int64, Order.Status> statuses = 5;
+  Order order = 6;
+}
+`, Protobuf, GoCodeOptions{Package: "events"})
+	assert.NoError(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "order.go", code, parser.AllErrors)
+	assert.NoError(t, err)
+
+	source := collapseSpaces(string(code))
+	assert.Contains(t, source, "// OtherSchema is the Protobuf schema Other was generated from.")
+	assert.Contains(t, source, "// Order is the Protobuf message example.Order.")
+	assert.Contains(t, source, "type Order struct {\n _ struct{} `protobuf:\"example.Order\"`")
+	assert.Contains(t, source, "ID int64 `protobuf:\"id\" json:\"id\"`")
+	assert.Contains(t, source, "Status OrderStatus `protobuf:\"status\" json:\"status\"`")
+	assert.Contains(t, source, "Lines []OrderLine `protobuf:\"lines\" json:\"lines\"`")
+	assert.Contains(t, source, "Counts map[string]int32 `protobuf:\"counts\" json:\"counts\"`")
+	assert.Contains(t, source, "type OrderLine struct {")
+	assert.Contains(t, source, "type OrderStatus int32")
+	assert.Contains(t, source, "OrderStatusNew OrderStatus = 0")
+	assert.Contains(t, source, "Carrier *string `protobuf:\"carrier\" json:\"carrier\"`")
+	assert.Contains(t, source, "Address *string `protobuf:\"address\" json:\"address\"`")
+	assert.Contains(t, source, "Pickup *OrderLine `protobuf:\"pickup\" json:\"pickup\"`")
+	assert.Contains(t, source, "// Encoded google.protobuf.Timestamp messages.\n SentAt []byte")
+	assert.Contains(t, source, "Statuses map[int64]OrderStatus `protobuf:\"statuses\" json:\"statuses\"`")
+	assert.Contains(t, source, "Order *Order `protobuf:\"order\" json:\"order\"`")
+	for _, name := range []string{"Other", "Order", "OrderLine", "Shipment"} {
+		assert.Contains(t, source, "func (value *"+name+") Serialize(serializer *srclient.Serializer, schema *srclient.Schema) ([]byte, error) {")
+		assert.Contains(t, source, "func Deserialize"+name+"(deserializer *srclient.Deserializer, data []byte) (*"+name+", error) {")
+	}
+
+	_, err = GenerateGoCode(`syntax = "proto3"; enum A { B = 0; }`, Protobuf, GoCodeOptions{Package: "events"})
+	assert.Error(t, err)
+	_, err = GenerateGoCode(`syntax = "proto3"; message A { message B {} } message AB {}`, Protobuf, GoCodeOptions{Package: "events"})
+	assert.Error(t, err)
+}
+
+func TestGenerateGoCode_Json(t *testing.T) {
+	code, err := GenerateGoCode(`{
+		"title": "user_profile",
+		"type": "object",
+		"description": "A user of the shop.",
+		"required": ["id", "name", "home"],
+		"properties": {
+			"id": {"type": "integer"},
+			"name": {"type": "string", "description": "The full name."},
+			"nickname": {"type": ["string", "null"]},
+			"created_at": {"type": "string", "format": "date-time"},
+			"scores": {"type": "array", "items": {"type": "number"}},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"home": {"$ref": "#/definitions/address"},
+			"work": {"$ref": "#/definitions/address"},
+			"settings": {"type": "object", "properties": {"dark": {"type": "boolean"}}}
+		},
+		"definitions": {
+			"address": {"type": "object", "properties": {"city": {"type": "string"}}}
+		}
+	}`, Json, GoCodeOptions{Package: "users"})
+	assert.NoError(t, err)
+	_, err = parser.ParseFile(token.NewFileSet(), "user.go", code, parser.AllErrors)
+	assert.NoError(t, err)
+
+	source := collapseSpaces(string(code))
+	assert.Contains(t, source, "// UserProfile is a user of the shop.")
+	assert.Contains(t, source, "type UserProfile struct {")
+	assert.Contains(t, source, "ID int64 `json:\"id\"`")
+	assert.Contains(t, source, "// The full name.\n Name string `json:\"name\"`")
+	assert.Contains(t, source, "Nickname *string `json:\"nickname,omitempty\"`")
+	assert.Contains(t, source, "CreatedAt *time.Time `json:\"created_at,omitempty\"`")
+	assert.Contains(t, source, "Scores []float64 `json:\"scores,omitempty\"`")
+	assert.Contains(t, source, "Labels map[string]string `json:\"labels,omitempty\"`")
+	assert.Contains(t, source, "Home Address `json:\"home\"`")
+	assert.Contains(t, source, "Work *Address `json:\"work,omitempty\"`")
+	assert.Contains(t, source, "Settings *UserProfileSettings `json:\"settings,omitempty\"`")
+	assert.Contains(t, source, "type Address struct {")
+	assert.Contains(t, source, "type UserProfileSettings struct {")
+	assert.Contains(t, source, "func DeserializeUserProfile(")
+
+	_, err = GenerateGoCode(`{"type": "object"}`, Json, GoCodeOptions{Package: "users"})
+	assert.Error(t, err)
+	_, err = GenerateGoCode(`{"type": "string"}`, Json, GoCodeOptions{Package: "users", TypeName: "Name"})
+	assert.Error(t, err)
+}
+
+func TestGoIdentifier(t *testing.T) {
+	cases := map[string]string{
+		"id":         "ID",
+		"user_id":    "UserID",
+		"placedAt":   "PlacedAt",
+		"IN_TRANSIT": "INTRANSIT",
+		"in-transit": "InTransit",
+		"3d":         "X3d",
+		"apiUrl":     "APIURL",
+	}
+	for name, expected := range cases {
+		assert.Equal(t, expected, goIdentifier(name), name)
+	}
+}
+
+// collapseSpaces makes generated code comparable regardless
+// of the alignment gofmt applies to fields.
+func collapseSpaces(code string) string {
+	return regexp.MustCompile(`[ \t]+`).ReplaceAllString(code, " ")
+}
@@ -0,0 +1,161 @@
+package srclient
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CompatibilityLevel defines how schemas of a subject are
+// allowed to evolve, using the same values as Schema Registry.
+type CompatibilityLevel string
+
+const (
+	None               CompatibilityLevel = "NONE"
+	Backward           CompatibilityLevel = "BACKWARD"
+	BackwardTransitive CompatibilityLevel = "BACKWARD_TRANSITIVE"
+	Forward            CompatibilityLevel = "FORWARD"
+	ForwardTransitive  CompatibilityLevel = "FORWARD_TRANSITIVE"
+	Full               CompatibilityLevel = "FULL"
+	FullTransitive     CompatibilityLevel = "FULL_TRANSITIVE"
+)
+
+func (level CompatibilityLevel) String() string {
+	return string(level)
+}
+
+// IncompatibilityType names the kind of problem that makes
+// two schemas incompatible, with the same values used in the
+// messages of Schema Registry.
+type IncompatibilityType string
+
+const (
+	NameMismatch                   IncompatibilityType = "NAME_MISMATCH"
+	FixedSizeMismatch              IncompatibilityType = "FIXED_SIZE_MISMATCH"
+	MissingEnumSymbols             IncompatibilityType = "MISSING_ENUM_SYMBOLS"
+	ReaderFieldMissingDefaultValue IncompatibilityType = "READER_FIELD_MISSING_DEFAULT_VALUE"
+	TypeMismatch                   IncompatibilityType = "TYPE_MISMATCH"
+	MissingUnionBranch             IncompatibilityType = "MISSING_UNION_BRANCH"
+)
+
+// Incompatibility is a single problem found while checking
+// the compatibility between a new schema and an old one.
+type Incompatibility struct {
+	Type           IncompatibilityType
+	Path           string
+	Description    string
+	AdditionalInfo string
+}
+
+// String formats the incompatibility the way Schema Registry
+// does in the messages of its verbose compatibility checks.
+func (incompatibility Incompatibility) String() string {
+	return fmt.Sprintf("{errorType:'%s', description:'%s', additionalInfo:'%s'}",
+		incompatibility.Type, incompatibility.Description, incompatibility.AdditionalInfo)
+}
+
+// offlineChecker checks compatibility for one schema type. The
+// reader is the schema that consumes data, while the writer is
+// the one that produced it. Descriptions talk about the "new"
+// and "old" schemas, and newIsReader tells which one is which.
+type offlineChecker interface {
+	parse(schema string) (interface{}, error)
+	check(reader, writer interface{}, newIsReader bool) []Incompatibility
+}
+
+var offlineCheckers = map[SchemaType]offlineChecker{
+	Avro:     avroChecker{},
+	Json:     jsonChecker{},
+	Protobuf: protobufChecker{},
+}
+
+// CheckCompatibility checks, without contacting Schema Registry, if the
+// given schema can be registered after the previous ones, which must be
+// sorted from the oldest to the newest. The check follows the semantics
+// of the registry for the given compatibility level: transitive levels
+// check the schema against all the previous ones while the others only
+// check it against the newest. Each problem found is described both in
+// the messages of the result, like the registry does, and in its list
+// of incompatibilities.
+func CheckCompatibility(schemaType SchemaType, level CompatibilityLevel, schema string, previous ...string) (*CompatibilityResult, error) {
+	checker, ok := offlineCheckers[schemaType]
+	if !ok {
+		return nil, fmt.Errorf("offline compatibility checks are not supported for %s schemas", schemaType)
+	}
+
+	var backward, forward, transitive bool
+	switch level {
+	case None:
+	case Backward:
+		backward = true
+	case BackwardTransitive:
+		backward, transitive = true, true
+	case Forward:
+		forward = true
+	case ForwardTransitive:
+		forward, transitive = true, true
+	case Full:
+		backward, forward = true, true
+	case FullTransitive:
+		backward, forward, transitive = true, true, true
+	default:
+		return nil, fmt.Errorf("invalid compatibility level %q", level)
+	}
+
+	candidate, err := checker.parse(schema)
+	if err != nil {
+		return nil, err
+	}
+	if !transitive && len(previous) > 1 {
+		previous = previous[len(previous)-1:]
+	}
+
+	result := &CompatibilityResult{IsCompatible: true, Messages: []string{}}
+	// Like the registry, start with the newest schema and report
+	// the problems found with the first incompatible one only.
+	for i := len(previous) - 1; i >= 0; i-- {
+		old, err := checker.parse(previous[i])
+		if err != nil {
+			return nil, err
+		}
+		var found []Incompatibility
+		if backward {
+			found = append(found, checker.check(candidate, old, true)...)
+		}
+		if forward {
+			found = append(found, checker.check(old, candidate, false)...)
+		}
+		if len(found) > 0 {
+			result.IsCompatible = false
+			for _, incompatibility := range found {
+				result.Messages = append(result.Messages, incompatibility.String())
+			}
+			result.Messages = append(result.Messages,
+				fmt.Sprintf("{oldSchema: '%s'}", previous[i]),
+				fmt.Sprintf("{compatibility: '%s'}", level))
+			result.Incompatibilities = found
+			break
+		}
+	}
+	return result, nil
+}
+
+// schemaPath tracks the location of the type being checked, using
+// the JSON pointer like notation that Schema Registry reports.
+type schemaPath []string
+
+func (path schemaPath) child(elements ...string) schemaPath {
+	child := make(schemaPath, 0, len(path)+len(elements))
+	return append(append(child, path...), elements...)
+}
+
+func (path schemaPath) String() string {
+	return "/" + strings.Join(path, "/")
+}
+
+// ages names the reader and the writer schemas as "new" or "old".
+func ages(newIsReader bool) (string, string) {
+	if newIsReader {
+		return "new", "old"
+	}
+	return "old", "new"
+}
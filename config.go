@@ -0,0 +1,399 @@
+package srclient
+
+import (
+	"bufio"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Configuration keys understood by CreateSchemaRegistryClientFromConfig,
+// which are the ones of the Schema Registry clients of Confluent.
+const (
+	ConfigURL                       = "schema.registry.url"
+	ConfigBasicAuthSource           = "basic.auth.credentials.source"
+	ConfigBasicAuthUserInfo         = "basic.auth.user.info"
+	ConfigBearerAuthSource          = "bearer.auth.credentials.source"
+	ConfigBearerAuthToken           = "bearer.auth.token"
+	ConfigBearerAuthIssuerURL       = "bearer.auth.issuer.endpoint.url"
+	ConfigBearerAuthClientID        = "bearer.auth.client.id"
+	ConfigBearerAuthClientSecret    = "bearer.auth.client.secret"
+	ConfigBearerAuthScope           = "bearer.auth.scope"
+	ConfigBearerAuthLogicalCluster  = "bearer.auth.logical.cluster"
+	ConfigBearerAuthIdentityPoolID  = "bearer.auth.identity.pool.id"
+	ConfigSSLTruststoreType         = "schema.registry.ssl.truststore.type"
+	ConfigSSLTruststoreLocation     = "schema.registry.ssl.truststore.location"
+	ConfigSSLTruststoreCerts        = "schema.registry.ssl.truststore.certificates"
+	ConfigSSLKeystoreType           = "schema.registry.ssl.keystore.type"
+	ConfigSSLKeystoreLocation       = "schema.registry.ssl.keystore.location"
+	ConfigSSLKeystoreCertChain      = "schema.registry.ssl.keystore.certificate.chain"
+	ConfigSSLKeystoreKey            = "schema.registry.ssl.keystore.key"
+	ConfigSSLProtocol               = "schema.registry.ssl.protocol"
+	ConfigSSLEndpointIdentification = "schema.registry.ssl.endpoint.identification.algorithm"
+)
+
+// configKeys lists the supported configuration keys.
+var configKeys = map[string]bool{
+	ConfigURL:                       true,
+	ConfigBasicAuthSource:           true,
+	ConfigBasicAuthUserInfo:         true,
+	ConfigBearerAuthSource:          true,
+	ConfigBearerAuthToken:           true,
+	ConfigBearerAuthIssuerURL:       true,
+	ConfigBearerAuthClientID:        true,
+	ConfigBearerAuthClientSecret:    true,
+	ConfigBearerAuthScope:           true,
+	ConfigBearerAuthLogicalCluster:  true,
+	ConfigBearerAuthIdentityPoolID:  true,
+	ConfigSSLTruststoreType:         true,
+	ConfigSSLTruststoreLocation:     true,
+	ConfigSSLTruststoreCerts:        true,
+	ConfigSSLKeystoreType:           true,
+	ConfigSSLKeystoreLocation:       true,
+	ConfigSSLKeystoreCertChain:      true,
+	ConfigSSLKeystoreKey:            true,
+	ConfigSSLProtocol:               true,
+	ConfigSSLEndpointIdentification: true,
+}
+
+// envPrefix starts the names of the environment variables
+// read by CreateSchemaRegistryClientFromEnv.
+const envPrefix = "SCHEMA_REGISTRY_"
+
+// CreateSchemaRegistryClientFromConfig creates a client from the
+// configuration shared with the Schema Registry clients of Confluent,
+// such as:
+//
+//	schema.registry.url=https://registry.internal
+//	basic.auth.credentials.source=USER_INFO
+//	basic.auth.user.info=user:password
+//
+// Several comma-separated URLs can be given, as Confluent clients fail
+// over between them, but only the first one is used. Basic
+// authentication takes its credentials from USER_INFO or from the URL,
+// and bearer authentication takes a STATIC_TOKEN or fetches tokens
+// with OAUTHBEARER. Key and trust stores must be of the PEM
+// type. Unknown keys are rejected, so typos don't go unnoticed. The
+// options given are applied after the configuration.
+func CreateSchemaRegistryClientFromConfig(config map[string]string, options ...Option) (*SchemaRegistryClient, error) {
+	var unknown []string
+	for key := range config {
+		if !configKeys[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown configuration keys: %s", strings.Join(unknown, ", "))
+	}
+
+	registryURL, configOptions, err := optionsFromConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewSchemaRegistryClient(registryURL, append(configOptions, options...)...)
+}
+
+// CreateSchemaRegistryClientFromPropertiesFile creates a client from
+// the configuration of a Java .properties file, as described by
+// CreateSchemaRegistryClientFromConfig.
+func CreateSchemaRegistryClientFromPropertiesFile(path string, options ...Option) (*SchemaRegistryClient, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	config, err := readProperties(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid properties file %s: %w", path, err)
+	}
+	return CreateSchemaRegistryClientFromConfig(config, options...)
+}
+
+// CreateSchemaRegistryClientFromEnv creates a client from environment
+// variables named after the configuration keys described by
+// CreateSchemaRegistryClientFromConfig: the key without its
+// "schema.registry." prefix, in upper case with dots replaced by
+// underscores, after SCHEMA_REGISTRY_. For instance, SCHEMA_REGISTRY_URL
+// sets schema.registry.url and SCHEMA_REGISTRY_BASIC_AUTH_USER_INFO sets
+// basic.auth.user.info. Other SCHEMA_REGISTRY_ variables are ignored, as
+// Kubernetes and the images of Schema Registry itself set some as well.
+func CreateSchemaRegistryClientFromEnv(options ...Option) (*SchemaRegistryClient, error) {
+	keys := make(map[string]string, len(configKeys))
+	for key := range configKeys {
+		keys[envName(key)] = key
+	}
+
+	config := map[string]string{}
+	for _, variable := range os.Environ() {
+		name, value := variable, ""
+		if separator := strings.Index(variable, "="); separator >= 0 {
+			name, value = variable[:separator], variable[separator+1:]
+		}
+		if key, ok := keys[name]; ok {
+			config[key] = value
+		}
+	}
+	return CreateSchemaRegistryClientFromConfig(config, options...)
+}
+
+func envName(key string) string {
+	key = strings.TrimPrefix(key, "schema.registry.")
+	return envPrefix + strings.ToUpper(strings.Replace(key, ".", "_", -1))
+}
+
+func optionsFromConfig(config map[string]string) (string, []Option, error) {
+	var options []Option
+
+	// Several URLs can be given for failover, which
+	// isn't supported, so the first one is used.
+	registryURL := strings.TrimSpace(strings.Split(config[ConfigURL], ",")[0])
+	if len(registryURL) == 0 {
+		return "", nil, fmt.Errorf("%s is required", ConfigURL)
+	}
+
+	basicSource := strings.ToUpper(config[ConfigBasicAuthSource])
+	bearerSource := strings.ToUpper(config[ConfigBearerAuthSource])
+	if len(basicSource) > 0 && len(bearerSource) > 0 {
+		return "", nil, fmt.Errorf("%s and %s can't be both set", ConfigBasicAuthSource, ConfigBearerAuthSource)
+	}
+
+	switch basicSource {
+	case "":
+	case "USER_INFO":
+		userInfo := config[ConfigBasicAuthUserInfo]
+		separator := strings.Index(userInfo, ":")
+		if separator <= 0 {
+			return "", nil, fmt.Errorf(`%s must be "username:password"`, ConfigBasicAuthUserInfo)
+		}
+		options = append(options, WithCredentials(userInfo[:separator], userInfo[separator+1:]))
+	case "URL":
+		parsed, err := url.Parse(registryURL)
+		if err != nil {
+			return "", nil, fmt.Errorf("invalid %s: %w", ConfigURL, err)
+		}
+		password, ok := parsed.User.Password()
+		if !ok {
+			return "", nil, fmt.Errorf("%s has no credentials", ConfigURL)
+		}
+		options = append(options, WithCredentials(parsed.User.Username(), password))
+		parsed.User = nil
+		registryURL = parsed.String()
+	default:
+		return "", nil, fmt.Errorf("unsupported %s: %s", ConfigBasicAuthSource, config[ConfigBasicAuthSource])
+	}
+
+	switch bearerSource {
+	case "":
+	case "STATIC_TOKEN":
+		if len(config[ConfigBearerAuthToken]) == 0 {
+			return "", nil, fmt.Errorf("%s is required", ConfigBearerAuthToken)
+		}
+		options = append(options, WithBearerToken(config[ConfigBearerAuthToken]))
+	case "OAUTHBEARER":
+		for _, key := range []string{ConfigBearerAuthIssuerURL, ConfigBearerAuthClientID, ConfigBearerAuthClientSecret} {
+			if len(config[key]) == 0 {
+				return "", nil, fmt.Errorf("%s is required", key)
+			}
+		}
+		scopes := strings.FieldsFunc(config[ConfigBearerAuthScope], func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+		provider := NewOAuthProvider(config[ConfigBearerAuthIssuerURL], config[ConfigBearerAuthClientID],
+			config[ConfigBearerAuthClientSecret], WithOAuthScopes(scopes...))
+		options = append(options, WithAuthProvider(provider))
+	default:
+		return "", nil, fmt.Errorf("unsupported %s: %s", ConfigBearerAuthSource, config[ConfigBearerAuthSource])
+	}
+
+	// Confluent Cloud routes requests with these headers.
+	if cluster := config[ConfigBearerAuthLogicalCluster]; len(cluster) > 0 {
+		options = append(options, WithLogicalCluster(cluster))
+	}
+	if pool := config[ConfigBearerAuthIdentityPoolID]; len(pool) > 0 {
+		options = append(options, WithIdentityPool(pool))
+	}
+
+	tlsOptions, err := tlsOptionsFromConfig(config)
+	if err != nil {
+		return "", nil, err
+	}
+	return registryURL, append(options, tlsOptions...), nil
+}
+
+func tlsOptionsFromConfig(config map[string]string) ([]Option, error) {
+	var options []Option
+	for _, key := range []string{ConfigSSLTruststoreType, ConfigSSLKeystoreType} {
+		if storeType, ok := config[key]; ok && !strings.EqualFold(storeType, "PEM") {
+			return nil, fmt.Errorf("unsupported %s: %s, only PEM is supported", key, storeType)
+		}
+	}
+
+	if location := config[ConfigSSLTruststoreLocation]; len(location) > 0 {
+		options = append(options, WithCACertFile(location))
+	}
+	if certificates := config[ConfigSSLTruststoreCerts]; len(certificates) > 0 {
+		options = append(options, WithCACertPEM([]byte(certificates)))
+	}
+
+	// PEM key stores hold both the certificate chain and the key.
+	if location := config[ConfigSSLKeystoreLocation]; len(location) > 0 {
+		options = append(options, WithClientCertFiles(location, location))
+	}
+	chain, key := config[ConfigSSLKeystoreCertChain], config[ConfigSSLKeystoreKey]
+	if len(chain) > 0 || len(key) > 0 {
+		options = append(options, WithClientCertPEM([]byte(chain), []byte(key)))
+	}
+
+	if protocol, ok := config[ConfigSSLProtocol]; ok {
+		version, err := tlsVersion(protocol)
+		if err != nil {
+			return nil, fmt.Errorf("unsupported %s: %w", ConfigSSLProtocol, err)
+		}
+		options = append(options, WithMinTLSVersion(version))
+	}
+	if algorithm, ok := config[ConfigSSLEndpointIdentification]; ok && !strings.EqualFold(algorithm, "https") {
+		return nil, fmt.Errorf("unsupported %s: %q, host names are always verified", ConfigSSLEndpointIdentification, algorithm)
+	}
+	return options, nil
+}
+
+// tlsVersion maps the names of TLS versions used by Java, such as
+// TLSv1.2, to their Go constant. Generic names, such as TLS, don't
+// set a minimum version.
+func tlsVersion(protocol string) (uint16, error) {
+	switch strings.ToUpper(protocol) {
+	case "TLS", "SSL":
+		return 0, nil
+	case "TLSV1", "TLSV1.0":
+		return tls.VersionTLS10, nil
+	case "TLSV1.1":
+		return tls.VersionTLS11, nil
+	case "TLSV1.2":
+		return tls.VersionTLS12, nil
+	case "TLSV1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown protocol %s", protocol)
+}
+
+// readProperties reads the keys and values of a Java .properties file.
+// Keys are separated from values by '=', ':' or white space, lines
+// starting with '#' or '!' are comments, lines ending with a backslash
+// continue on the next one, and the usual escapes are supported.
+func readProperties(r io.Reader) (map[string]string, error) {
+	properties := map[string]string{}
+	scanner := bufio.NewScanner(r)
+	var logical strings.Builder
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimLeftFunc(scanner.Text(), unicode.IsSpace)
+		if logical.Len() == 0 && (len(line) == 0 || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+		if trailingBackslashes(line)%2 == 1 {
+			logical.WriteString(line[:len(line)-1])
+			continue
+		}
+		logical.WriteString(line)
+
+		key, value, err := splitProperty(logical.String())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		properties[key] = value
+		logical.Reset()
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if logical.Len() > 0 {
+		key, value, err := splitProperty(logical.String())
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNumber, err)
+		}
+		properties[key] = value
+	}
+	return properties, nil
+}
+
+func trailingBackslashes(line string) int {
+	count := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		count++
+	}
+	return count
+}
+
+// splitProperty splits a logical line into its unescaped key and value.
+func splitProperty(line string) (string, string, error) {
+	end := len(line)
+	for i := 0; i < len(line); i++ {
+		if line[i] == '\\' {
+			i++
+			continue
+		}
+		if line[i] == '=' || line[i] == ':' || unicode.IsSpace(rune(line[i])) {
+			end = i
+			break
+		}
+	}
+	key, rest := line[:end], strings.TrimLeftFunc(line[end:], unicode.IsSpace)
+	if len(rest) > 0 && (rest[0] == '=' || rest[0] == ':') {
+		rest = strings.TrimLeftFunc(rest[1:], unicode.IsSpace)
+	}
+
+	key, err := unescapeProperty(key)
+	if err != nil {
+		return "", "", err
+	}
+	value, err := unescapeProperty(rest)
+	if err != nil {
+		return "", "", err
+	}
+	return key, value, nil
+}
+
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, `\`) {
+		return s, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i == len(s)-1 {
+			b.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("invalid escape %q", s[i-1:])
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("invalid escape %q", s[i-1:i+5])
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
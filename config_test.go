@@ -0,0 +1,188 @@
+package srclient
+
+import (
+	"crypto/tls"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfig_Properties(t *testing.T) {
+	properties, err := readProperties(strings.NewReader(`
+# A comment
+! Another comment
+schema.registry.url = https://registry.internal
+basic.auth.credentials.source:USER_INFO
+basic.auth.user.info   user:pass\=word
+bearer.auth.scope=read,\
+    write
+escaped\ key=tab\there é
+empty=
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"schema.registry.url":           "https://registry.internal",
+		"basic.auth.credentials.source": "USER_INFO",
+		"basic.auth.user.info":          "user:pass=word",
+		"bearer.auth.scope":             "read,write",
+		"escaped key":                   "tab\there é",
+		"empty":                         "",
+	}, properties)
+
+	_, err = readProperties(strings.NewReader(`key=\u12`))
+	assert.Error(t, err)
+}
+
+func TestConfig_Client(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		username, password, _ := req.BasicAuth()
+		rw.Write([]byte(`["` + username + ":" + password + `", "` + req.Header.Get("Authorization") + `"]`))
+	}))
+	defer server.Close()
+
+	srClient, err := CreateSchemaRegistryClientFromConfig(map[string]string{
+		ConfigURL:               server.URL + ",https://replica.internal",
+		ConfigBasicAuthSource:   "USER_INFO",
+		ConfigBasicAuthUserInfo: "user:pass:word",
+	})
+	assert.NoError(t, err)
+	subjects, err := srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, "user:pass:word", subjects[0])
+
+	srClient, err = CreateSchemaRegistryClientFromConfig(map[string]string{
+		ConfigURL:             strings.Replace(server.URL, "http://", "http://url:secret@", 1),
+		ConfigBasicAuthSource: "URL",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, server.URL, srClient.schemaRegistryURL)
+	subjects, err = srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, "url:secret", subjects[0])
+
+	// Options are applied after the configuration.
+	srClient, err = CreateSchemaRegistryClientFromConfig(map[string]string{
+		ConfigURL:              server.URL,
+		ConfigBearerAuthSource: "STATIC_TOKEN",
+		ConfigBearerAuthToken:  "static",
+	}, WithBearerToken("override"))
+	assert.NoError(t, err)
+	subjects, err = srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer override", subjects[1])
+
+	srClient, err = CreateSchemaRegistryClientFromConfig(map[string]string{
+		ConfigURL:                    server.URL,
+		ConfigBearerAuthSource:       "OAUTHBEARER",
+		ConfigBearerAuthIssuerURL:    "https://issuer.internal/token",
+		ConfigBearerAuthClientID:     "client",
+		ConfigBearerAuthClientSecret: "secret",
+		ConfigBearerAuthScope:        "read, write",
+	})
+	assert.NoError(t, err)
+	provider := srClient.authProvider.(*OAuthProvider)
+	assert.Equal(t, []string{"read", "write"}, provider.scopes)
+}
+
+func TestConfig_TLS(t *testing.T) {
+	authority := issueCertificate(t, "authority", nil)
+	client := issueCertificate(t, "client", authority)
+	dir, err := ioutil.TempDir("", "config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	keystore := filepath.Join(dir, "keystore.pem")
+	assert.NoError(t, ioutil.WriteFile(keystore, append(client.keyPEM, client.certPEM...), 0600))
+
+	srClient, err := CreateSchemaRegistryClientFromConfig(map[string]string{
+		ConfigURL:                       "https://registry.internal",
+		ConfigSSLTruststoreType:         "PEM",
+		ConfigSSLTruststoreCerts:        string(authority.certPEM),
+		ConfigSSLKeystoreType:           "pem",
+		ConfigSSLKeystoreLocation:       keystore,
+		ConfigSSLProtocol:               "TLSv1.3",
+		ConfigSSLEndpointIdentification: "https",
+	})
+	assert.NoError(t, err)
+	config := srClient.httpClient.Transport.(*http.Transport).TLSClientConfig
+	assert.NotNil(t, config.RootCAs)
+	assert.NotNil(t, config.GetClientCertificate)
+	assert.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+
+	_, err = CreateSchemaRegistryClientFromConfig(map[string]string{
+		ConfigURL:                 "https://registry.internal",
+		ConfigSSLKeystoreLocation: filepath.Join(dir, "missing.pem"),
+	})
+	assert.Error(t, err)
+}
+
+func TestConfig_Errors(t *testing.T) {
+	for _, config := range []map[string]string{
+		{},
+		{ConfigURL: "https://registry.internal", "schema.registry.ulr": "typo"},
+		{ConfigURL: "https://registry.internal", ConfigBasicAuthSource: "SASL_INHERIT"},
+		{ConfigURL: "https://registry.internal", ConfigBasicAuthSource: "USER_INFO", ConfigBasicAuthUserInfo: "user"},
+		{ConfigURL: "https://registry.internal", ConfigBasicAuthSource: "URL"},
+		{ConfigURL: "https://registry.internal", ConfigBasicAuthSource: "USER_INFO", ConfigBearerAuthSource: "STATIC_TOKEN"},
+		{ConfigURL: "https://registry.internal", ConfigBearerAuthSource: "OAUTHBEARER", ConfigBearerAuthClientID: "client"},
+		{ConfigURL: "https://registry.internal", ConfigSSLTruststoreType: "JKS"},
+		{ConfigURL: "https://registry.internal", ConfigSSLProtocol: "TLSv9"},
+		{ConfigURL: "https://registry.internal", ConfigSSLEndpointIdentification: ""},
+	} {
+		_, err := CreateSchemaRegistryClientFromConfig(config)
+		assert.Error(t, err, "%v", config)
+	}
+
+	_, err := CreateSchemaRegistryClientFromConfig(map[string]string{ConfigURL: "https://registry.internal", "b": "", "a": ""})
+	assert.EqualError(t, err, "unknown configuration keys: a, b")
+}
+
+func TestConfig_FileAndEnv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "client.properties")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("schema.registry.url=https://registry.internal\n"+
+		"basic.auth.credentials.source=USER_INFO\nbasic.auth.user.info=user:password\n"), 0600))
+
+	srClient, err := CreateSchemaRegistryClientFromPropertiesFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, "https://registry.internal", srClient.schemaRegistryURL)
+	assert.Equal(t, &credentials{"user", "password"}, srClient.credentials)
+	_, err = CreateSchemaRegistryClientFromPropertiesFile(filepath.Join(dir, "missing.properties"))
+	assert.Error(t, err)
+
+	assert.Equal(t, "SCHEMA_REGISTRY_SSL_TRUSTSTORE_LOCATION", envName(ConfigSSLTruststoreLocation))
+	env := map[string]string{
+		"SCHEMA_REGISTRY_URL":                           "https://env.internal",
+		"SCHEMA_REGISTRY_BASIC_AUTH_CREDENTIALS_SOURCE": "USER_INFO",
+		"SCHEMA_REGISTRY_BASIC_AUTH_USER_INFO":          "env:secret",
+	}
+	for name, value := range env {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+	srClient, err = CreateSchemaRegistryClientFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://env.internal", srClient.schemaRegistryURL)
+	assert.Equal(t, &credentials{"env", "secret"}, srClient.credentials)
+
+	// Variables set for other purposes, here by Kubernetes for a
+	// service named schema-registry, are ignored.
+	for name, value := range map[string]string{
+		"SCHEMA_REGISTRY_SERVICE_HOST": "10.0.0.1",
+		"SCHEMA_REGISTRY_PORT":         "tcp://10.0.0.1:8081",
+		"SCHEMA_REGISTRY_HOST_NAME":    "schema-registry",
+	} {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+	srClient, err = CreateSchemaRegistryClientFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "https://env.internal", srClient.schemaRegistryURL)
+}
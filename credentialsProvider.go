@@ -0,0 +1,132 @@
+package srclient
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CredentialsFunc returns the username and password of HTTP basic
+// authentication. It is called for every request, so credentials
+// that rotate are used as soon as they change.
+type CredentialsFunc func() (username, password string, err error)
+
+// Authenticate sets the credentials returned by the function.
+func (fn CredentialsFunc) Authenticate(req *http.Request) error {
+	username, password, err := fn()
+	if err != nil {
+		return fmt.Errorf("unable to get credentials: %w", err)
+	}
+	req.SetBasicAuth(username, password)
+	return nil
+}
+
+// Refresh does nothing, as the function is called for every request,
+// but makes the client retry the requests rejected as unauthorized.
+func (fn CredentialsFunc) Refresh() error {
+	return nil
+}
+
+// WithCredentialsFunc makes the client authenticate its requests with
+// the credentials returned by the given function, instead of the ones
+// of WithCredentials, which never change.
+func WithCredentialsFunc(fn CredentialsFunc) Option {
+	return WithAuthProvider(fn)
+}
+
+// WithCredentialsFile makes the client authenticate its requests with
+// the credentials stored in the given file as "username:password", such
+// as a mounted secret. The file is read again whenever it changes, and
+// when a request is rejected as unauthorized.
+func WithCredentialsFile(path string) Option {
+	return WithAuthProvider(NewCredentialsFile(path))
+}
+
+// CredentialsFile is an AuthProvider that reads the credentials of
+// HTTP basic authentication from a file holding "username:password".
+// The file is checked for changes before every request, which only
+// costs a stat, and read again when its size or modification time
+// changes.
+type CredentialsFile struct {
+	path string
+
+	username  string
+	password  string
+	modTime   time.Time
+	size      int64
+	loaded    bool
+	credsLock sync.Mutex
+}
+
+// NewCredentialsFile creates a CredentialsFile reading the given file.
+func NewCredentialsFile(path string) *CredentialsFile {
+	return &CredentialsFile{path: path}
+}
+
+// Authenticate sets the credentials of the file,
+// reading it again if it has changed.
+func (file *CredentialsFile) Authenticate(req *http.Request) error {
+	username, password, err := file.Credentials()
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(username, password)
+	return nil
+}
+
+// Credentials returns the username and password of
+// the file, reading it again if it has changed.
+func (file *CredentialsFile) Credentials() (string, string, error) {
+	file.credsLock.Lock()
+	defer file.credsLock.Unlock()
+	info, err := os.Stat(file.path)
+	if err != nil {
+		return "", "", fmt.Errorf("unable to read credentials: %w", err)
+	}
+	if !file.loaded || !info.ModTime().Equal(file.modTime) || info.Size() != file.size {
+		if err := file.load(info); err != nil {
+			return "", "", err
+		}
+	}
+	return file.username, file.password, nil
+}
+
+// Refresh reads the file again, which catches the changes made
+// within the resolution of modification times.
+func (file *CredentialsFile) Refresh() error {
+	file.credsLock.Lock()
+	defer file.credsLock.Unlock()
+	info, err := os.Stat(file.path)
+	if err != nil {
+		return fmt.Errorf("unable to read credentials: %w", err)
+	}
+	return file.load(info)
+}
+
+func (file *CredentialsFile) load(info os.FileInfo) error {
+	content, err := ioutil.ReadFile(file.path)
+	if err != nil {
+		return fmt.Errorf("unable to read credentials: %w", err)
+	}
+	username, password, err := parseCredentials(string(content))
+	if err != nil {
+		return fmt.Errorf("invalid credentials in %s: %w", file.path, err)
+	}
+	file.username, file.password = username, password
+	file.modTime, file.size, file.loaded = info.ModTime(), info.Size(), true
+	return nil
+}
+
+func parseCredentials(content string) (string, string, error) {
+	content = strings.TrimSpace(content)
+	separator := strings.Index(content, ":")
+	if separator <= 0 {
+		return "", "", errors.New(`expected "username:password"`)
+	}
+	return content[:separator], content[separator+1:], nil
+}
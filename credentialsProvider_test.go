@@ -0,0 +1,136 @@
+package srclient
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rotatingServer is a registry that only accepts the current password,
+// echoing back the request body to check it survives retries.
+func rotatingServer(t *testing.T, password *string) (*httptest.Server, *int) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		username, pass, ok := req.BasicAuth()
+		if !ok || username != "user" || pass != *password {
+			rw.WriteHeader(http.StatusUnauthorized)
+			rw.Write([]byte(`{"error_code": 401, "message": "Unauthorized"}`))
+			return
+		}
+		body, _ := ioutil.ReadAll(req.Body)
+		if len(body) == 0 {
+			body = []byte(`["test1"]`)
+		}
+		rw.Write(body)
+	}))
+	return server, &requests
+}
+
+func TestCredentialsFunc_Rotation(t *testing.T) {
+	password := "first"
+	server, requests := rotatingServer(t, &password)
+	defer server.Close()
+
+	// The function lags one call behind the rotations.
+	current := "first"
+	calls := 0
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL, WithCredentialsFunc(func() (string, string, error) {
+		calls++
+		pass := current
+		current = password
+		return "user", pass, nil
+	}))
+	_, err := srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, *requests)
+
+	// The first request after a rotation is retried with the new password.
+	password = "second"
+	result, err := srClient.CheckSchemaCompatibility("test1", `"int"`, Avro, false)
+	assert.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Equal(t, 3, *requests)
+	assert.Equal(t, 3, calls)
+
+	// Requests are retried only once.
+	password = "third"
+	srClient = CreateSchemaRegistryClientWithOptions(server.URL, WithCredentialsFunc(func() (string, string, error) {
+		return "user", "wrong", nil
+	}))
+	_, err = srClient.GetSubjects()
+	assert.EqualError(t, err, "401: Unauthorized")
+	assert.Equal(t, 5, *requests)
+
+	// Static credentials aren't retried.
+	srClient = CreateSchemaRegistryClientWithOptions(server.URL, WithCredentials("user", "wrong"))
+	_, err = srClient.GetSubjects()
+	assert.Error(t, err)
+	assert.Equal(t, 6, *requests)
+}
+
+func TestCredentialsFile_Rotation(t *testing.T) {
+	password := "first"
+	server, requests := rotatingServer(t, &password)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "credentials")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "credentials")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("user:first\n"), 0600))
+
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL, WithCredentialsFile(path))
+	_, err = srClient.GetSubjects()
+	assert.NoError(t, err)
+
+	// The file is read again when it changes, or else on a 401.
+	password = "second"
+	assert.NoError(t, ioutil.WriteFile(path, []byte("user:second\n"), 0600))
+	_, err = srClient.GetSubjects()
+	assert.NoError(t, err)
+	password = "third:with:colons"
+	assert.NoError(t, ioutil.WriteFile(path, []byte("user:third:with:colons"), 0600))
+	_, err = srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, *requests)
+
+	file := NewCredentialsFile(path)
+	username, pass, err := file.Credentials()
+	assert.NoError(t, err)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "third:with:colons", pass)
+
+	assert.NoError(t, ioutil.WriteFile(path, []byte("no separator"), 0600))
+	assert.Error(t, file.Refresh())
+	_, _, err = NewCredentialsFile(filepath.Join(dir, "missing")).Credentials()
+	assert.Error(t, err)
+}
+
+func TestOAuthProvider_RefreshOnUnauthorized(t *testing.T) {
+	tokens, issued := tokenServer(t, 300)
+	defer tokens.Close()
+
+	// The registry only accepts the latest token, as if the
+	// others had been revoked before they expired.
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Header.Get("Authorization") != "Bearer token-2" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			rw.Write([]byte(`{"error_code": 401, "message": "Unauthorized"}`))
+			return
+		}
+		rw.Write([]byte(`["test1"]`))
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL, WithAuthProvider(NewOAuthProvider(tokens.URL, "client", "secret")))
+	subjects, err := srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test1"}, subjects)
+	assert.Equal(t, 2, *issued)
+}
@@ -0,0 +1,309 @@
+package srclient
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// magicByte starts every record written in the wire format of
+// Schema Registry, followed by the schema ID in four bytes.
+const magicByte = byte(0)
+
+// ErrInvalidWireFormat is returned when a record doesn't start
+// with the magic byte and schema ID of the wire format.
+var ErrInvalidWireFormat = errors.New("record is not in the schema registry wire format")
+
+// Deserializer decodes Avro and JSON records written in the wire format
+// of Schema Registry, fetching their writer schemas with the client.
+// Protobuf records can be decoded as well, see Deserialize and
+// DeserializeInto.
+// By default Avro records are decoded with their writer schema. When a
+// reader schema is supplied with WithReaderSchema, the data is
+// resolved into the reader schema instead, as the Avro specification
+// describes, so applications only deal with the shape they expect.
+// Logical types are read into the Go types listed with LogicalType,
+// unless SetLogicalTypeEnabled turns them off.
+type Deserializer struct {
+	client       ISchemaRegistryClient
+	readerSchema string
+	reader       *avroType
+	readerKey    [sha256.Size]byte
+
+	resolvers     map[resolverKey]*recordDecoding
+	resolversLock sync.RWMutex
+	logicalTypes  logicalTypeMapping
+	generation    uint64
+	plans         *avroTypePlans
+	fingerprints  *FingerprintIndex
+}
+
+// DeserializerOption configures a Deserializer.
+type DeserializerOption func(*Deserializer)
+
+// WithReaderSchema sets the Avro schema records are read with.
+func WithReaderSchema(schema string) DeserializerOption {
+	return DeserializerOption(func(deserializer *Deserializer) {
+		deserializer.readerSchema = schema
+	})
+}
+
+// resolverKey identifies the decoding of the records written with a
+// schema ID. The reader key is empty when no reader schema is used.
+type resolverKey struct {
+	writerID   int
+	writerGUID string
+	readerKey  [sha256.Size]byte
+}
+
+// nativeDecoder is implemented by goavro.Codec and avroResolver.
+type nativeDecoder interface {
+	NativeFromBinary(buf []byte) (interface{}, []byte, error)
+}
+
+// recordDecoding decodes the records written with a schema ID. For
+// Avro, schema is the one of the native values the decoder returns.
+type recordDecoding struct {
+	schemaType SchemaType
+	decoder    nativeDecoder
+	schema     *avroType
+	key        resolverKey
+}
+
+// jsonDecoder decodes the records of JSON schemas.
+type jsonDecoder struct{}
+
+func (jsonDecoder) NativeFromBinary(buf []byte) (interface{}, []byte, error) {
+	var native interface{}
+	if err := json.Unmarshal(buf, &native); err != nil {
+		return nil, buf, err
+	}
+	return native, nil, nil
+}
+
+// NewDeserializer creates a Deserializer that uses the given client.
+func NewDeserializer(client ISchemaRegistryClient, opts ...DeserializerOption) (*Deserializer, error) {
+	deserializer := &Deserializer{
+		client:    client,
+		resolvers: make(map[resolverKey]*recordDecoding),
+		plans:     newAvroTypePlans(),
+	}
+	for _, opt := range opts {
+		opt(deserializer)
+	}
+	if len(deserializer.readerSchema) > 0 {
+		reader, err := parseAvroSchema(deserializer.readerSchema)
+		if err != nil {
+			return nil, err
+		}
+		deserializer.reader = reader
+		deserializer.readerKey = FingerprintSHA256(avroCanonicalForm(reader, true))
+	}
+	return deserializer, nil
+}
+
+// Deserialize decodes a record into the native values of goavro,
+// for the reader schema if there is one, or else the writer schema.
+// Logical types goavro doesn't know, such as UUIDs and local
+// timestamps, are decoded into their Go types as well. JSON records
+// are decoded with encoding/json, and Protobuf messages into maps
+// keyed by field name, where enums hold the name of their value.
+func (deserializer *Deserializer) Deserialize(data []byte) (interface{}, error) {
+	native, _, err := deserializer.deserialize(data)
+	return native, err
+}
+
+// DeserializeInto decodes a record into the value v points to,
+// typically a struct. JSON records are decoded with encoding/json.
+// For Avro records, the fields of the struct are matched to the
+// fields of the record by their avro tag, as in `avro:"name"`, or
+// else by their name. Nullable unions are read into pointers, enums
+// into strings, and logical types into time.Time, time.Duration,
+// *big.Rat and string values. Protobuf messages are matched to structs
+// the same way, by protobuf tag, as in `protobuf:"name"`, or by name.
+// Enums are read into integers, as their number, or into strings, as
+// their name, and messages of other schemas into bytes. Structs whose
+// blank field names a message, as Serializer describes, are only read
+// from records of that message.
+func (deserializer *Deserializer) DeserializeInto(data []byte, v interface{}) error {
+	schemaID, schema, payload, err := deserializer.splitSchemaID(data)
+	if err != nil {
+		return err
+	}
+	return deserializer.decodeInto(schemaID, schema, payload, v)
+}
+
+// decodeInto decodes a payload written with the given schema ID into
+// the value v points to. The schema is fetched with the client if nil.
+func (deserializer *Deserializer) decodeInto(schemaID int, schema *Schema, payload []byte, v interface{}) error {
+	if target := reflect.ValueOf(v); target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("deserializing requires a non-nil pointer, not %T", v)
+	}
+	decoding, err := deserializer.decodingFor(schemaID, schema)
+	if err != nil {
+		return err
+	}
+	if decoding.schemaType == Json {
+		return json.Unmarshal(payload, v)
+	}
+	if decoder, ok := decoding.decoder.(*protobufDecoder); ok {
+		if err := decoder.decodeInto(payload, reflect.ValueOf(v).Elem()); err != nil {
+			return fmt.Errorf("unable to decode record written with schema %d: %w", decoding.key.writerID, err)
+		}
+		return nil
+	}
+	native, err := decoding.decode(payload)
+	if err != nil {
+		return err
+	}
+	return deserializer.mapInto(decoding, native, v)
+}
+
+// mapInto stores the native values of an Avro record into
+// the value v points to, which must be a non-nil pointer.
+func (deserializer *Deserializer) mapInto(decoding *recordDecoding, native interface{}, v interface{}) error {
+	target := reflect.ValueOf(v)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("deserializing requires a non-nil pointer, not %T", v)
+	}
+	if decoding.schema == nil {
+		return fmt.Errorf("unable to map schema %d to %s", decoding.key.writerID, target.Elem().Type())
+	}
+	plan, err := deserializer.plans.planFor(decoding.key, decoding.schema, target.Elem().Type())
+	if err != nil {
+		return err
+	}
+	return plan.decode(native, target.Elem())
+}
+
+// SetLogicalTypeEnabled sets whether values of the given logical type
+// are read into the Go type it maps to, or else into the value of its
+// underlying type. All are enabled by default.
+func (deserializer *Deserializer) SetLogicalTypeEnabled(logicalType LogicalType, enabled bool) {
+	deserializer.resolversLock.Lock()
+	defer deserializer.resolversLock.Unlock()
+	deserializer.logicalTypes = deserializer.logicalTypes.with(logicalType, enabled)
+	deserializer.generation++
+	deserializer.resolvers = make(map[resolverKey]*recordDecoding)
+	deserializer.plans.setLogicalTypes(deserializer.logicalTypes)
+}
+
+func (deserializer *Deserializer) deserialize(data []byte) (interface{}, *recordDecoding, error) {
+	schemaID, schema, payload, err := deserializer.splitSchemaID(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return deserializer.decode(schemaID, schema, payload)
+}
+
+// decode decodes a payload written with the given schema ID into native
+// values. The schema is fetched with the client if nil.
+func (deserializer *Deserializer) decode(schemaID int, schema *Schema, payload []byte) (interface{}, *recordDecoding, error) {
+	decoding, err := deserializer.decodingFor(schemaID, schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	native, err := decoding.decode(payload)
+	if err != nil {
+		return nil, nil, err
+	}
+	return native, decoding, nil
+}
+
+func (decoding *recordDecoding) decode(payload []byte) (interface{}, error) {
+	native, _, err := decoding.decoder.NativeFromBinary(payload)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode record written with schema %d: %w", decoding.key.writerID, err)
+	}
+	return native, nil
+}
+
+// splitWireFormat returns the schema ID and the payload of a record.
+func splitWireFormat(data []byte) (int, []byte, error) {
+	if len(data) < 5 || data[0] != magicByte {
+		return 0, nil, ErrInvalidWireFormat
+	}
+	return int(binary.BigEndian.Uint32(data[1:5])), data[5:], nil
+}
+
+func (deserializer *Deserializer) decodingFor(schemaID int, schema *Schema) (*recordDecoding, error) {
+	key := resolverKey{writerID: schemaID}
+	if schema != nil {
+		key = writerKey(schema)
+	}
+	key.readerKey = deserializer.readerKey
+	deserializer.resolversLock.RLock()
+	decoding, ok := deserializer.resolvers[key]
+	logicalTypes, generation := deserializer.logicalTypes, deserializer.generation
+	deserializer.resolversLock.RUnlock()
+	if ok {
+		return decoding, nil
+	}
+
+	var err error
+	if schema == nil {
+		if schema, err = deserializer.client.GetSchemaByID(schemaID); err != nil {
+			return nil, err
+		}
+	}
+	decoding = &recordDecoding{schemaType: schema.SchemaType(), key: key}
+	switch {
+	case schema.SchemaType() == Json && deserializer.reader == nil:
+		decoding.decoder = jsonDecoder{}
+	case schema.SchemaType() == Protobuf && deserializer.reader == nil:
+		file, err := parseProtobufSchema(schema.Schema())
+		if err != nil {
+			return nil, err
+		}
+		decoding.decoder = newProtobufDecoder(file)
+	case schema.SchemaType() != Avro:
+		return nil, fmt.Errorf("schema %d is a %s schema, which can't be deserialized", schemaID, schema.SchemaType())
+	case deserializer.reader == nil:
+		// Schemas this package can't parse are left to goavro, whose
+		// values can't be mapped to structs. Neither of them resolves
+		// references, so schemas with references can't be decoded.
+		if writer, err := parseAvroSchema(schema.Schema()); err == nil {
+			resolver, err := newAvroResolver(writer, writer, logicalTypes)
+			if err != nil {
+				return nil, err
+			}
+			decoding.decoder = resolver
+			decoding.schema = writer
+			break
+		}
+		codec := schema.Codec()
+		if codec == nil {
+			if codec, err = goavro.NewCodec(schema.Schema()); err != nil {
+				return nil, err
+			}
+		}
+		decoding.decoder = codec
+	default:
+		writer, err := parseAvroSchema(schema.Schema())
+		if err != nil {
+			return nil, err
+		}
+		resolver, err := newAvroResolver(deserializer.reader, writer, logicalTypes)
+		if err != nil {
+			return nil, fmt.Errorf("unable to resolve schema %d into the reader schema: %w", schemaID, err)
+		}
+		decoding.decoder = resolver
+		decoding.schema = deserializer.reader
+	}
+
+	// Logical types that changed in the meantime dropped the
+	// decodings, which this one, built for the previous logical
+	// types, must not be added back to.
+	deserializer.resolversLock.Lock()
+	if deserializer.generation == generation {
+		deserializer.resolvers[key] = decoding
+	}
+	deserializer.resolversLock.Unlock()
+	return decoding, nil
+}
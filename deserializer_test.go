@@ -0,0 +1,74 @@
+package srclient
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func wireFormat(t *testing.T, schema *Schema, native interface{}) []byte {
+	codec, err := goavro.NewCodec(schema.Schema())
+	assert.NoError(t, err)
+	record := []byte{magicByte, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(record[1:], uint32(schema.ID()))
+	record, err = codec.BinaryFromNative(record, native)
+	assert.NoError(t, err)
+	return record
+}
+
+func TestDeserializer_WriterSchema(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	writer, err := mockClient.CreateSchema("deserializer", schema, Avro, false)
+	assert.NoError(t, err)
+
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+	native, err := deserializer.Deserialize(wireFormat(t, writer, map[string]interface{}{"aField": int32(3)}))
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"aField": int32(3)}, native)
+
+	_, err = deserializer.Deserialize([]byte{1, 0, 0, 0, 1, 6})
+	assert.Equal(t, ErrInvalidWireFormat, err)
+}
+
+func TestDeserializer_ReaderSchema(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	first, err := mockClient.CreateSchema("deserializer", schema, Avro, false)
+	assert.NoError(t, err)
+	second, err := mockClient.CreateSchema("deserializer", schema2, Avro, false)
+	assert.NoError(t, err)
+
+	// The reader renamed bField back to aField, and made it a long.
+	deserializer, err := NewDeserializer(mockClient, WithReaderSchema(`{
+		"type": "record", "namespace": "com.mycorp.mynamespace", "name": "value_cdc_fake_2",
+		"fields": [
+			{"name": "aField", "aliases": ["bField"], "type": "long"},
+			{"name": "note", "type": "string", "default": "none"}
+		]
+	}`))
+	assert.NoError(t, err)
+
+	for i := 0; i < 2; i++ {
+		native, err := deserializer.Deserialize(wireFormat(t, first, map[string]interface{}{"aField": int32(1)}))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"aField": int64(1), "note": "none"}, native)
+
+		native, err = deserializer.Deserialize(wireFormat(t, second, map[string]interface{}{"bField": int32(2)}))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"aField": int64(2), "note": "none"}, native)
+	}
+	assert.Len(t, deserializer.resolvers, 2)
+
+	incompatible, err := NewDeserializer(mockClient, WithReaderSchema(`{
+		"type": "record", "namespace": "com.mycorp.mynamespace", "name": "value_cdc_fake_2",
+		"fields": [{"name": "other", "type": "int"}]
+	}`))
+	assert.NoError(t, err)
+	_, err = incompatible.Deserialize(wireFormat(t, first, map[string]interface{}{"aField": int32(1)}))
+	assert.Error(t, err)
+
+	_, err = NewDeserializer(mockClient, WithReaderSchema(`{"type": "record"`))
+	assert.Error(t, err)
+}
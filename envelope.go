@@ -0,0 +1,168 @@
+package srclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// Envelope names the framing of a message.
+type Envelope string
+
+const (
+	// WireFormatEnvelope is the framing of Schema Registry: a magic
+	// byte, the schema ID in four bytes, or its GUID, and the data.
+	WireFormatEnvelope Envelope = "WIRE_FORMAT"
+	// SingleObjectEnvelope is the single object encoding of Avro, which
+	// identifies the schema by its fingerprint.
+	SingleObjectEnvelope Envelope = "SINGLE_OBJECT"
+	// PlainJSONEnvelope is a JSON document without any framing.
+	PlainJSONEnvelope Envelope = "PLAIN_JSON"
+)
+
+func (envelope Envelope) String() string {
+	return string(envelope)
+}
+
+// FramingCheck names a check made while decoding a message.
+type FramingCheck string
+
+const (
+	// MagicBytesCheck fails when a message starts with no known framing.
+	MagicBytesCheck FramingCheck = "magic bytes"
+	// HeaderLengthCheck fails when a message is shorter than its framing.
+	HeaderLengthCheck FramingCheck = "header length"
+	// SchemaLookupCheck fails when the schema ID of a message can't be
+	// fetched from the registry.
+	SchemaLookupCheck FramingCheck = "schema lookup"
+	// FingerprintCheck fails when the fingerprint of a single object
+	// isn't in the fingerprint index of the Deserializer.
+	FingerprintCheck FramingCheck = "fingerprint"
+	// MessageIndexesCheck fails when the message indexes that follow
+	// the schema ID of Protobuf messages don't name a message.
+	MessageIndexesCheck FramingCheck = "message indexes"
+	// PayloadCheck fails when the data can't be decoded with its schema.
+	PayloadCheck FramingCheck = "payload"
+)
+
+// FramingError is returned by EnvelopeDecoder when a message can't
+// be decoded, telling which check failed along with the cause.
+type FramingError struct {
+	Envelope Envelope
+	Check    FramingCheck
+	Err      error
+}
+
+func (e *FramingError) Error() string {
+	if len(e.Envelope) == 0 {
+		return fmt.Sprintf("%s check failed: %v", e.Check, e.Err)
+	}
+	return fmt.Sprintf("%s check failed for a %s message: %v", e.Check, e.Envelope, e.Err)
+}
+
+func (e *FramingError) Unwrap() error {
+	return e.Err
+}
+
+// DecodedMessage is a message decoded by EnvelopeDecoder. Schema
+// is nil for plain JSON, whose SchemaType is Json nonetheless.
+type DecodedMessage struct {
+	Envelope   Envelope
+	Schema     *Schema
+	SchemaType SchemaType
+	Value      interface{}
+}
+
+// EnvelopeDecoder decodes messages whose framing isn't known in
+// advance, such as the messages of a topic being inspected. It tells
+// apart the wire format of Schema Registry, the single object encoding
+// of Avro and plain JSON by their first bytes, and decodes messages
+// with the Deserializer, whatever the type of their schema.
+type EnvelopeDecoder struct {
+	deserializer *Deserializer
+}
+
+// NewEnvelopeDecoder creates an EnvelopeDecoder. Single objects can
+// only be decoded when the Deserializer has a fingerprint index.
+func NewEnvelopeDecoder(deserializer *Deserializer) *EnvelopeDecoder {
+	return &EnvelopeDecoder{deserializer: deserializer}
+}
+
+// SniffEnvelope tells the framing of a message from its first bytes.
+func SniffEnvelope(data []byte) (Envelope, error) {
+	switch {
+	case len(data) == 0:
+		return "", &FramingError{Check: HeaderLengthCheck, Err: errors.New("empty message")}
+	case data[0] == magicByte, data[0] == guidMagicByte:
+		return WireFormatEnvelope, nil
+	case data[0] == singleObjectMarker[0]:
+		if len(data) < 2 {
+			return "", &FramingError{Envelope: SingleObjectEnvelope, Check: HeaderLengthCheck, Err: ErrInvalidSingleObject}
+		}
+		if data[1] == singleObjectMarker[1] {
+			return SingleObjectEnvelope, nil
+		}
+	case json.Valid(data):
+		return PlainJSONEnvelope, nil
+	}
+	return "", &FramingError{Check: MagicBytesCheck, Err: fmt.Errorf("unknown framing starting with 0x%x", data[:minInt(len(data), 2)])}
+}
+
+// Decode decodes a message, returning a *FramingError if it fails.
+func (decoder *EnvelopeDecoder) Decode(data []byte) (*DecodedMessage, error) {
+	envelope, err := SniffEnvelope(data)
+	if err != nil {
+		return nil, err
+	}
+	fail := func(check FramingCheck, err error) (*DecodedMessage, error) {
+		return nil, &FramingError{Envelope: envelope, Check: check, Err: err}
+	}
+
+	var schema *Schema
+	var payload []byte
+	switch envelope {
+	case PlainJSONEnvelope:
+		var value interface{}
+		if err := json.Unmarshal(data, &value); err != nil {
+			return fail(PayloadCheck, err)
+		}
+		return &DecodedMessage{Envelope: envelope, SchemaType: Json, Value: value}, nil
+	case WireFormatEnvelope:
+		if len(data) < 5 || (data[0] == guidMagicByte && len(data) < 17) {
+			return fail(HeaderLengthCheck, ErrInvalidWireFormat)
+		}
+		schemaID, guidSchema, rest, err := decoder.deserializer.splitSchemaID(data)
+		if err != nil {
+			return fail(SchemaLookupCheck, err)
+		}
+		if schema = guidSchema; schema == nil {
+			if schema, err = decoder.deserializer.client.GetSchemaByID(schemaID); err != nil {
+				return fail(SchemaLookupCheck, err)
+			}
+		}
+		payload = rest
+	case SingleObjectEnvelope:
+		if len(data) < 10 {
+			return fail(HeaderLengthCheck, ErrInvalidSingleObject)
+		}
+		if schema, payload, err = decoder.deserializer.splitSingleObject(data); err != nil {
+			return fail(FingerprintCheck, err)
+		}
+	}
+
+	value, _, err := decoder.deserializer.decode(schema.ID(), schema, payload)
+	if err != nil {
+		if errors.Is(err, errInvalidMessageIndexes) {
+			return fail(MessageIndexesCheck, err)
+		}
+		return fail(PayloadCheck, err)
+	}
+	return &DecodedMessage{Envelope: envelope, Schema: schema, SchemaType: schema.SchemaType(), Value: value}, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,149 @@
+package srclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const orderProto = `
+syntax = "proto3";
+package example;
+
+message Other {
+  string x = 1;
+}
+
+message Order {
+  enum Status {
+    NEW = 0;
+    PAID = 1;
+  }
+  message Line {
+    string sku = 1;
+    int32 qty = 2;
+  }
+  int64 id = 1;
+  Status status = 2;
+  repeated Line lines = 3;
+  map<string, int32> counts = 4;
+  repeated int32 codes = 5;
+  sint32 delta = 6;
+}
+`
+
+func framingCheck(t *testing.T, err error) FramingCheck {
+	var framingErr *FramingError
+	if !assert.True(t, errors.As(err, &framingErr)) {
+		return ""
+	}
+	return framingErr.Check
+}
+
+func TestEnvelopeDecoder_Formats(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	avroSchema, err := mockClient.CreateSchema("avro", schema, Avro, false)
+	assert.NoError(t, err)
+	jsonSchema, err := mockClient.CreateSchema("json", `{"type": "object"}`, Json, false)
+	assert.NoError(t, err)
+	protoSchema, err := mockClient.CreateSchema("proto", orderProto, Protobuf, false)
+	assert.NoError(t, err)
+
+	index := NewFingerprintIndex()
+	assert.NoError(t, index.Add(avroSchema))
+	deserializer, err := NewDeserializer(mockClient, WithFingerprintIndex(index))
+	assert.NoError(t, err)
+	decoder := NewEnvelopeDecoder(deserializer)
+	serializer := NewSerializer(mockClient)
+
+	record, err := serializer.Serialize(avroSchema, map[string]interface{}{"aField": 1})
+	assert.NoError(t, err)
+	decoded, err := decoder.Decode(record)
+	assert.NoError(t, err)
+	assert.Equal(t, WireFormatEnvelope, decoded.Envelope)
+	assert.Equal(t, Avro, decoded.SchemaType)
+	assert.Equal(t, avroSchema.ID(), decoded.Schema.ID())
+	assert.Equal(t, map[string]interface{}{"aField": int32(1)}, decoded.Value)
+
+	record, err = serializer.SerializeSingleObject(avroSchema, map[string]interface{}{"aField": 2})
+	assert.NoError(t, err)
+	decoded, err = decoder.Decode(record)
+	assert.NoError(t, err)
+	assert.Equal(t, SingleObjectEnvelope, decoded.Envelope)
+	assert.Equal(t, map[string]interface{}{"aField": int32(2)}, decoded.Value)
+
+	record, err = serializer.Serialize(jsonSchema, map[string]interface{}{"name": "Gopher"})
+	assert.NoError(t, err)
+	decoded, err = decoder.Decode(record)
+	assert.NoError(t, err)
+	assert.Equal(t, Json, decoded.SchemaType)
+	assert.Equal(t, map[string]interface{}{"name": "Gopher"}, decoded.Value)
+
+	decoded, err = decoder.Decode([]byte(` {"name": "Gopher"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, PlainJSONEnvelope, decoded.Envelope)
+	assert.Nil(t, decoded.Schema)
+	assert.Equal(t, map[string]interface{}{"name": "Gopher"}, decoded.Value)
+
+	protoRecord := append([]byte{magicByte, 0, 0, 0, byte(protoSchema.ID())},
+		0x02, 0x02, // the message at index 1, Order
+		0x08, 0x07, // id
+		0x10, 0x01, // status
+		0x1a, 0x05, 0x0a, 0x01, 'a', 0x10, 0x02, // lines
+		0x22, 0x05, 0x0a, 0x01, 'k', 0x10, 0x03, // counts
+		0x2a, 0x02, 0x01, 0x02, // codes, packed
+		0x30, 0x03, // delta
+		0x78, 0x01, // an unknown field
+	)
+	decoded, err = decoder.Decode(protoRecord)
+	assert.NoError(t, err)
+	assert.Equal(t, Protobuf, decoded.SchemaType)
+	assert.Equal(t, map[string]interface{}{
+		"id":     int64(7),
+		"status": "PAID",
+		"lines":  []interface{}{map[string]interface{}{"sku": "a", "qty": int32(2)}},
+		"counts": map[string]interface{}{"k": int32(3)},
+		"codes":  []interface{}{int32(1), int32(2)},
+		"delta":  int32(-2),
+	}, decoded.Value)
+
+	// A single zero stands for the first message.
+	decoded, err = decoder.Decode([]byte{magicByte, 0, 0, 0, byte(protoSchema.ID()), 0x00, 0x0a, 0x01, 'x'})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"x": "x"}, decoded.Value)
+}
+
+func TestEnvelopeDecoder_Errors(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	avroSchema, err := mockClient.CreateSchema("avro", schema, Avro, false)
+	assert.NoError(t, err)
+	protoSchema, err := mockClient.CreateSchema("proto", orderProto, Protobuf, false)
+	assert.NoError(t, err)
+	deserializer, err := NewDeserializer(mockClient, WithFingerprintIndex(NewFingerprintIndex()))
+	assert.NoError(t, err)
+	decoder := NewEnvelopeDecoder(deserializer)
+
+	_, err = decoder.Decode(nil)
+	assert.Equal(t, HeaderLengthCheck, framingCheck(t, err))
+	_, err = decoder.Decode([]byte("not json"))
+	assert.Equal(t, MagicBytesCheck, framingCheck(t, err))
+	_, err = decoder.Decode([]byte{magicByte, 0, 0})
+	assert.Equal(t, HeaderLengthCheck, framingCheck(t, err))
+	_, err = decoder.Decode([]byte{0xC3, 0x01, 0})
+	assert.Equal(t, HeaderLengthCheck, framingCheck(t, err))
+	_, err = decoder.Decode([]byte{magicByte, 0, 0, 0, 99, 0})
+	assert.Equal(t, SchemaLookupCheck, framingCheck(t, err))
+
+	record, err := NewSerializer(mockClient).SerializeSingleObject(avroSchema, map[string]interface{}{"aField": 2})
+	assert.NoError(t, err)
+	_, err = decoder.Decode(record)
+	assert.Equal(t, FingerprintCheck, framingCheck(t, err))
+	assert.True(t, errors.Is(err, ErrUnknownFingerprint))
+
+	_, err = decoder.Decode([]byte{magicByte, 0, 0, 0, byte(protoSchema.ID()), 0x02, 0x08})
+	assert.Equal(t, MessageIndexesCheck, framingCheck(t, err))
+	_, err = decoder.Decode([]byte{magicByte, 0, 0, 0, byte(avroSchema.ID())})
+	assert.Equal(t, PayloadCheck, framingCheck(t, err))
+	assert.Contains(t, err.Error(), "payload check failed for a WIRE_FORMAT message")
+}
@@ -0,0 +1,158 @@
+package srclient
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// AvroCanonicalForm returns the Parsing Canonical Form of the given Avro
+// schema, as defined by the Avro specification. Schemas that only differ
+// by whitespace, attribute order, documentation, aliases or defaults have
+// the same canonical form, which makes it suitable for comparing schemas.
+func AvroCanonicalForm(schema string) (string, error) {
+	avroSchema, err := parseAvroSchema(schema)
+	if err != nil {
+		return "", err
+	}
+	return avroCanonicalForm(avroSchema, false), nil
+}
+
+// FingerprintCRC64 returns the CRC-64-AVRO (Rabin) fingerprint of a
+// canonical form, which is the one used by single object encoding.
+func FingerprintCRC64(canonicalForm string) uint64 {
+	fingerprint := crc64Empty
+	for i := 0; i < len(canonicalForm); i++ {
+		fingerprint = (fingerprint >> 8) ^ crc64Table[byte(fingerprint)^canonicalForm[i]]
+	}
+	return fingerprint
+}
+
+// FingerprintMD5 returns the MD5 fingerprint of a canonical form.
+func FingerprintMD5(canonicalForm string) [md5.Size]byte {
+	return md5.Sum([]byte(canonicalForm))
+}
+
+// FingerprintSHA256 returns the SHA-256 fingerprint of a canonical form.
+func FingerprintSHA256(canonicalForm string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(canonicalForm))
+}
+
+// crc64Empty is both the seed of CRC-64-AVRO and the polynomial it uses.
+const crc64Empty = uint64(0xc15d213aa4d7a795)
+
+var crc64Table = func() [256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		fingerprint := uint64(i)
+		for j := 0; j < 8; j++ {
+			fingerprint = (fingerprint >> 1) ^ (crc64Empty & -(fingerprint & 1))
+		}
+		table[i] = fingerprint
+	}
+	return table
+}()
+
+// avroCanonicalForm writes the Parsing Canonical Form of a parsed schema.
+// When extended is true, the attributes that change how goavro encodes
+// and decodes data (logical types and defaults) are kept as well, which
+// gives a form that identifies schemas that can share the same codec.
+func avroCanonicalForm(t *avroType, extended bool) string {
+	var builder strings.Builder
+	writeAvroCanonicalForm(&builder, t, extended, map[string]bool{})
+	return builder.String()
+}
+
+func writeAvroCanonicalForm(builder *strings.Builder, t *avroType, extended bool, seen map[string]bool) {
+	if t.isNamed() {
+		if seen[t.fullName()] {
+			builder.WriteString(canonicalString(t.fullName()))
+			return
+		}
+		seen[t.fullName()] = true
+	}
+
+	switch t.kind() {
+	case "union":
+		builder.WriteString("[")
+		for i, branch := range t.branches {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			writeAvroCanonicalForm(builder, branch, extended, seen)
+		}
+		builder.WriteString("]")
+		return
+	case "record", "enum", "fixed", "array", "map":
+	default:
+		if !extended || len(t.logicalType) == 0 {
+			builder.WriteString(canonicalString(t.typ))
+			return
+		}
+	}
+
+	builder.WriteString("{")
+	if t.isNamed() {
+		builder.WriteString(`"name":` + canonicalString(t.fullName()) + ",")
+	}
+	builder.WriteString(`"type":` + canonicalString(t.kind()))
+	switch t.kind() {
+	case "record":
+		builder.WriteString(`,"fields":[`)
+		for i, field := range t.fields {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			builder.WriteString(`{"name":` + canonicalString(field.name) + `,"type":`)
+			writeAvroCanonicalForm(builder, field.typ, extended, seen)
+			if extended && field.hasDefault {
+				builder.WriteString(`,"default":` + canonicalJSON(field.defaultValue))
+			}
+			builder.WriteString("}")
+		}
+		builder.WriteString("]")
+	case "enum":
+		builder.WriteString(`,"symbols":[`)
+		for i, symbol := range t.symbols {
+			if i > 0 {
+				builder.WriteString(",")
+			}
+			builder.WriteString(canonicalString(symbol))
+		}
+		builder.WriteString("]")
+	case "array":
+		builder.WriteString(`,"items":`)
+		writeAvroCanonicalForm(builder, t.items, extended, seen)
+	case "map":
+		builder.WriteString(`,"values":`)
+		writeAvroCanonicalForm(builder, t.values, extended, seen)
+	case "fixed":
+		builder.WriteString(`,"size":` + strconv.Itoa(t.size))
+	}
+	if extended && len(t.logicalType) > 0 {
+		builder.WriteString(`,"logicalType":` + canonicalString(t.logicalType))
+		if t.logicalType == "decimal" {
+			builder.WriteString(`,"precision":` + strconv.Itoa(t.precision) + `,"scale":` + strconv.Itoa(t.scale))
+		}
+	}
+	builder.WriteString("}")
+}
+
+// canonicalString quotes a JSON string, leaving
+// non ASCII characters unescaped as the spec asks.
+func canonicalString(s string) string {
+	return canonicalJSON(s)
+}
+
+func canonicalJSON(value interface{}) string {
+	var buffer bytes.Buffer
+	encoder := json.NewEncoder(&buffer)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return "null"
+	}
+	return strings.TrimSuffix(buffer.String(), "\n")
+}
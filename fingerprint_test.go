@@ -0,0 +1,88 @@
+package srclient
+
+import (
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAvroCanonicalForm(t *testing.T) {
+	cases := map[string]string{
+		`"int"`: `"int"`,
+		`{"type": "long", "logicalType": "timestamp-millis"}`:                                              `"long"`,
+		`{"type": "fixed", "name": "Hash", "namespace": "com.example", "size": 16, "aliases": ["Digest"]}`: `{"name":"com.example.Hash","type":"fixed","size":16}`,
+		`{
+			"namespace": "com.example", "doc": "A user",
+			"name": "User", "type": "record",
+			"fields": [
+				{"name": "id", "type": "long", "doc": "identifier"},
+				{"name": "tags", "type": {"type": "array", "items": "string"}, "default": []},
+				{"name": "status", "type": {"name": "Status", "type": "enum", "symbols": ["A", "B"]}},
+				{"name": "previous", "type": ["null", "Status"], "default": null},
+				{"name": "attributes", "type": {"type": "map", "values": "com.example.Status"}}
+			]
+		}`: `{"name":"com.example.User","type":"record","fields":[{"name":"id","type":"long"},` +
+			`{"name":"tags","type":{"type":"array","items":"string"}},` +
+			`{"name":"status","type":{"name":"com.example.Status","type":"enum","symbols":["A","B"]}},` +
+			`{"name":"previous","type":["null","com.example.Status"]},` +
+			`{"name":"attributes","type":{"type":"map","values":"com.example.Status"}}]}`,
+	}
+
+	for schema, expected := range cases {
+		canonicalForm, err := AvroCanonicalForm(schema)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, canonicalForm)
+	}
+}
+
+func TestFingerprints(t *testing.T) {
+	for _, schema := range []string{`"null"`, `"int"`, `{"type":"record","name":"A","fields":[{"name":"f","type":"int"}]}`} {
+		codec, err := goavro.NewCodec(schema)
+		assert.NoError(t, err)
+		assert.Equal(t, codec.Rabin, FingerprintCRC64(codec.CanonicalSchema()))
+	}
+	assert.Equal(t, uint64(0x63dd24e7cc258f8a), FingerprintCRC64(`"null"`))
+
+	md5 := FingerprintMD5(`"int"`)
+	assert.Equal(t, "ef524ea1b91e73173d938ade36c1db32", hex.EncodeToString(md5[:]))
+	sha256 := FingerprintSHA256(`"int"`)
+	assert.Equal(t, "3f2b87a9fe7cc9b13835598c3981cd45e3e355309e5090aa0933d7becb6fba45", hex.EncodeToString(sha256[:]))
+}
+
+func TestSchemaRegistryClient_SharesCodecs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/schemas/ids/1":
+			rw.Write([]byte(`{"schema": "{\"type\": \"record\", \"name\": \"A\", \"fields\": [{\"name\": \"f\", \"type\": \"int\"}]}"}`))
+		case "/schemas/ids/2":
+			rw.Write([]byte(`{"schema": "{\"name\":\"A\",\"type\":\"record\",\"doc\":\"same\",\"fields\":[{\"type\":\"int\",\"name\":\"f\"}]}"}`))
+		case "/schemas/ids/3":
+			rw.Write([]byte(`{"schema": "syntax = \"proto3\"; message A { int32 f = 1; }", "schemaType": "PROTOBUF"}`))
+		}
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClient(server.URL)
+	first, err := srClient.GetSchemaByID(1)
+	assert.NoError(t, err)
+	second, err := srClient.GetSchemaByID(2)
+	assert.NoError(t, err)
+	assert.True(t, first.Codec() == second.Codec())
+
+	firstFingerprint, err := first.Fingerprint()
+	assert.NoError(t, err)
+	secondFingerprint, err := second.Fingerprint()
+	assert.NoError(t, err)
+	assert.Equal(t, firstFingerprint, secondFingerprint)
+
+	third, err := srClient.GetSchemaByID(3)
+	assert.NoError(t, err)
+	assert.Equal(t, Protobuf, third.SchemaType())
+	assert.Nil(t, third.Codec())
+	_, err = third.Fingerprint()
+	assert.Error(t, err)
+}
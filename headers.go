@@ -0,0 +1,62 @@
+package srclient
+
+import "net/http"
+
+// Headers that Confluent Cloud uses to route requests: the ID of the
+// logical cluster of the registry, and the identity pool that OAuth
+// tokens are mapped to.
+const (
+	LogicalClusterHeader = "target-sr-cluster"
+	IdentityPoolHeader   = "Confluent-Identity-Pool-Id"
+)
+
+// HeaderFunc is called for every request sent to Schema Registry,
+// after all other headers are set, to add headers that change from
+// one request to the next, such as correlation IDs.
+type HeaderFunc func(req *http.Request)
+
+// WithHeaders adds the given headers to every request, such as the
+// tenant IDs API gateways expect. It can be given several times.
+func WithHeaders(headers map[string]string) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		if client.headers == nil {
+			client.headers = http.Header{}
+		}
+		for key, value := range headers {
+			client.headers.Set(key, value)
+		}
+	})
+}
+
+// WithHeaderFunc makes the client call the given function for every
+// request. It can be given several times, and functions are called in
+// order.
+func WithHeaderFunc(fn HeaderFunc) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		client.headerFuncs = append(client.headerFuncs, fn)
+	})
+}
+
+// WithLogicalCluster sets the ID of the logical cluster of the registry,
+// such as lsrc-123456, which Confluent Cloud needs to route requests.
+func WithLogicalCluster(id string) Option {
+	return WithHeaders(map[string]string{LogicalClusterHeader: id})
+}
+
+// WithIdentityPool sets the identity pool that the OAuth
+// tokens of the client are mapped to in Confluent Cloud.
+func WithIdentityPool(id string) Option {
+	return WithHeaders(map[string]string{IdentityPoolHeader: id})
+}
+
+func (client *SchemaRegistryClient) setHeaders(req *http.Request) {
+	for key, values := range client.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
+}
+
+func (client *SchemaRegistryClient) callHeaderFuncs(req *http.Request) {
+	for _, fn := range client.headerFuncs {
+		fn(req)
+	}
+}
@@ -0,0 +1,64 @@
+package srclient
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeaders(t *testing.T) {
+	var received []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		received = append(received, req.Header)
+		rw.Write([]byte(`["test1"]`))
+	}))
+	defer server.Close()
+
+	requests := 0
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL,
+		WithHeaders(map[string]string{"X-Tenant-Id": "tenant", "x-gateway": "first"}),
+		WithHeaders(map[string]string{"X-Gateway": "second"}),
+		WithLogicalCluster("lsrc-123456"),
+		WithBearerToken("token"),
+		WithHeaderFunc(func(req *http.Request) {
+			requests++
+			req.Header.Set("X-Correlation-Id", fmt.Sprintf("%s-%d", req.Method, requests))
+		}),
+		WithHeaderFunc(func(req *http.Request) {
+			// Hooks see the headers set before them.
+			assert.Equal(t, "Bearer token", req.Header.Get("Authorization"))
+			req.Header.Add("X-Gateway", "hook")
+		}))
+
+	for i := 0; i < 2; i++ {
+		_, err := srClient.GetSubjects()
+		assert.NoError(t, err)
+	}
+	assert.Len(t, received, 2)
+	for i, header := range received {
+		assert.Equal(t, "tenant", header.Get("X-Tenant-Id"))
+		assert.Equal(t, []string{"second", "hook"}, header["X-Gateway"])
+		assert.Equal(t, "lsrc-123456", header.Get(LogicalClusterHeader))
+		assert.Equal(t, fmt.Sprintf("GET-%d", i+1), header.Get("X-Correlation-Id"))
+	}
+
+	// Hooks don't change the static headers of later requests.
+	assert.Equal(t, []string{"second"}, srClient.headers["X-Gateway"])
+
+	// The headers of Confluent Cloud can be configured.
+	srClient, err := CreateSchemaRegistryClientFromConfig(map[string]string{
+		ConfigURL:                      server.URL,
+		ConfigBearerAuthSource:         "STATIC_TOKEN",
+		ConfigBearerAuthToken:          "token",
+		ConfigBearerAuthLogicalCluster: "lsrc-654321",
+		ConfigBearerAuthIdentityPoolID: "pool-1",
+	})
+	assert.NoError(t, err)
+	_, err = srClient.WithContextName("tenant").GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, "lsrc-654321", received[2].Get(LogicalClusterHeader))
+	assert.Equal(t, "pool-1", received[2].Get(IdentityPoolHeader))
+}
@@ -0,0 +1,99 @@
+package srclient
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Operation describes a call to a method of SchemaRegistryClient, as
+// seen by the interceptors set with WithOperationInterceptor. Name is
+// the name of the method, such as GetSchemaByID, and the other fields
+// are set when they apply to it. Subject is qualified with the suffix
+// of keys or values, and with the schema context of the client.
+type Operation struct {
+	Name    string
+	Subject string
+	Version string
+	ID      int
+	GUID    string
+
+	// Schema is the schema returned by the operation, set once it is
+	// done, and CacheHit tells whether it came from the cache.
+	Schema   *Schema
+	CacheHit bool
+
+	// Start is when the method was called. Duration, set once the
+	// operation is done, is the time from Start to its end, which
+	// includes what interceptors spent before calling next.
+	Start    time.Time
+	Duration time.Duration
+}
+
+// OperationInvoker runs an operation, or the rest of the interceptors.
+type OperationInvoker func(ctx context.Context, op *Operation) error
+
+// OperationInterceptor wraps the operations of the client, for logging,
+// metrics, tracing or auditing. It must call next to run the operation,
+// and may pass it a context of its own, which is the one of the HTTP
+// requests of the operation. The error returned by next is the result
+// of the operation. As the methods of the client take no context, the
+// context given to the outermost interceptor is context.Background(),
+// with no deadline: interceptors can derive one to bound operations.
+type OperationInterceptor func(ctx context.Context, op *Operation, next OperationInvoker) error
+
+// HTTPInvoker sends an HTTP request, or passes it to the rest of the
+// interceptors.
+type HTTPInvoker func(req *http.Request) (*http.Response, error)
+
+// HTTPInterceptor wraps the HTTP exchanges with Schema Registry. It
+// sees requests once all their headers are set, and must call next to
+// send them. Operations that retry requests, such as when credentials
+// are rotated, go through interceptors once per request.
+type HTTPInterceptor func(req *http.Request, next HTTPInvoker) (*http.Response, error)
+
+// WithOperationInterceptor adds interceptors around the operations of
+// the client. Interceptors are chained in the order they are given, the
+// first one being the outermost, and so are repeated options.
+func WithOperationInterceptor(interceptors ...OperationInterceptor) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		client.operationInterceptors = append(client.operationInterceptors, interceptors...)
+	})
+}
+
+// WithHTTPInterceptor adds interceptors around the HTTP exchanges of the
+// client, chained like the ones of WithOperationInterceptor.
+func WithHTTPInterceptor(interceptors ...HTTPInterceptor) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		client.httpInterceptors = append(client.httpInterceptors, interceptors...)
+	})
+}
+
+// invoke runs the operation through the chain of interceptors.
+func (client *SchemaRegistryClient) invoke(op *Operation, fn func(ctx context.Context) error) error {
+	op.Start = time.Now()
+	next := OperationInvoker(func(ctx context.Context, op *Operation) error {
+		err := fn(ctx)
+		op.Duration = time.Since(op.Start)
+		return err
+	})
+	for i := len(client.operationInterceptors) - 1; i >= 0; i-- {
+		interceptor, inner := client.operationInterceptors[i], next
+		next = func(ctx context.Context, op *Operation) error {
+			return interceptor(ctx, op, inner)
+		}
+	}
+	return next(context.Background(), op)
+}
+
+// roundTrip sends the request through the chain of interceptors.
+func (client *SchemaRegistryClient) roundTrip(req *http.Request) (*http.Response, error) {
+	next := HTTPInvoker(client.httpClient.Do)
+	for i := len(client.httpInterceptors) - 1; i >= 0; i-- {
+		interceptor, inner := client.httpInterceptors[i], next
+		next = func(req *http.Request) (*http.Response, error) {
+			return interceptor(req, inner)
+		}
+	}
+	return next(req)
+}
@@ -0,0 +1,128 @@
+package srclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type operationKey struct{}
+
+func TestInterceptors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/schemas/ids/1", "/subjects/test1-value/versions/latest":
+			response, _ := json.Marshal(schemaResponse{Subject: "test1-value", Version: 2, Schema: "test2", SchemaType: Json.String(), ID: 1})
+			rw.Write(response)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			rw.Write([]byte(`{"error_code": 40403, "message": "Schema not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	var events []string
+	var durations []time.Duration
+	logging := func(ctx context.Context, op *Operation, next OperationInvoker) error {
+		assert.False(t, op.Start.IsZero())
+		assert.Zero(t, op.Duration)
+		events = append(events, "start "+op.Name)
+		err := next(context.WithValue(ctx, operationKey{}, op.Name), op)
+		assert.True(t, op.Duration > 0)
+		assert.True(t, op.Duration <= time.Since(op.Start))
+		durations = append(durations, op.Duration)
+		result := "ok"
+		if err != nil {
+			result = err.Error()
+		} else if op.Schema != nil {
+			result = fmt.Sprintf("schema %d, version %d, cache hit %v", op.Schema.ID(), op.Schema.Version(), op.CacheHit)
+		}
+		events = append(events, fmt.Sprintf("end %s(%s %s %d): %s", op.Name, op.Subject, op.Version, op.ID, result))
+		return err
+	}
+	inner := func(ctx context.Context, op *Operation, next OperationInvoker) error {
+		events = append(events, "inner "+op.Name)
+		return next(ctx, op)
+	}
+	exchanges := func(req *http.Request, next HTTPInvoker) (*http.Response, error) {
+		resp, err := next(req)
+		if err == nil {
+			events = append(events, fmt.Sprintf("%s %s %s: %d", req.Context().Value(operationKey{}), req.Method, req.URL.Path, resp.StatusCode))
+		}
+		return resp, err
+	}
+
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL,
+		WithOperationInterceptor(logging), WithOperationInterceptor(inner), WithHTTPInterceptor(exchanges))
+	_, err := srClient.GetSchemaByID(1)
+	assert.NoError(t, err)
+	_, err = srClient.GetSchemaByID(1)
+	assert.NoError(t, err)
+	_, err = srClient.GetSchemaBySubject("test1", false)
+	assert.NoError(t, err)
+	_, err = srClient.GetSchemaByVersion("test1", "2", false)
+	assert.NoError(t, err)
+	_, err = srClient.GetSchemaByID(2)
+	assert.EqualError(t, err, "40403: Schema not found")
+
+	assert.Equal(t, []string{
+		"start GetSchemaByID",
+		"inner GetSchemaByID",
+		"GetSchemaByID GET /schemas/ids/1: 200",
+		"end GetSchemaByID(  1): schema 1, version 2, cache hit false",
+		"start GetSchemaByID",
+		"inner GetSchemaByID",
+		"end GetSchemaByID(  1): schema 1, version 2, cache hit true",
+		"start GetSchemaBySubject",
+		"inner GetSchemaBySubject",
+		"GetSchemaBySubject GET /subjects/test1-value/versions/latest: 200",
+		"end GetSchemaBySubject(test1-value latest 0): schema 1, version 2, cache hit false",
+		"start GetSchemaByVersion",
+		"inner GetSchemaByVersion",
+		"end GetSchemaByVersion(test1-value 2 0): schema 1, version 2, cache hit true",
+		"start GetSchemaByID",
+		"inner GetSchemaByID",
+		"GetSchemaByID GET /schemas/ids/2: 404",
+		"end GetSchemaByID(  2): 40403: Schema not found",
+	}, events)
+	assert.Len(t, durations, 5)
+}
+
+func TestInterceptors_ShortCircuit(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		rw.Write([]byte(`["test1"]`))
+	}))
+	defer server.Close()
+
+	// Interceptors can refuse operations and requests.
+	denied := errors.New("denied")
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL, WithOperationInterceptor(
+		func(ctx context.Context, op *Operation, next OperationInvoker) error {
+			if op.Name == "DeleteSubject" {
+				return denied
+			}
+			return next(ctx, op)
+		}))
+	assert.Equal(t, denied, srClient.DeleteSubject("test1-value", true))
+	subjects, err := srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"test1"}, subjects)
+	assert.Equal(t, 1, requests)
+
+	srClient = CreateSchemaRegistryClientWithOptions(server.URL, WithHTTPInterceptor(
+		func(req *http.Request, next HTTPInvoker) (*http.Response, error) {
+			return nil, denied
+		}))
+	_, err = srClient.GetSubjects()
+	assert.True(t, errors.Is(err, denied))
+	assert.Equal(t, 1, requests)
+}
@@ -4,21 +4,28 @@ package srclient
 // definition of the operations that
 // this Schema Registry client provides.
 type ISchemaRegistryClient interface {
-	GetSubjects() ([]string, error)
+	GetSubjects(opts ...ListOption) ([]string, error)
 	GetLatestSchema(subject string, isKey bool) (*Schema, error)
-	GetSchemaVersions(subject string, isKey bool) ([]int, error)
+	GetSchemaVersions(subject string, isKey bool, opts ...ListOption) ([]int, error)
 
 	GetSchemaByID(schemaID int) (*Schema, error)
+	GetSchemaByGUID(guid string) (*Schema, error)
 	GetSchemaBySubject(subject string, isKey bool) (*Schema, error)
 	GetSchemaByVersion(subject string, version string, isKey bool) (*Schema, error)
+	LookupSchema(subject string, schema string, schemaType SchemaType, isKey bool, references ...Reference) (*Schema, error)
+	LookupSchemaWithOptions(subject string, schema string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (*Schema, error)
 
 	CreateSchema(subject string, schema string, schemaType SchemaType, isKey bool, references ...Reference) (*Schema, error)
+	CreateSchemaWithOptions(subject string, schema string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (*Schema, error)
 	DeleteSubject(subject string, permanent bool) error
 
 	SetCachingEnabled(value bool)
 	SetCodecCreationEnabled(value bool)
 
 	IsSchemaCompatible(subject, schema, version string, schemaType SchemaType, isKey bool) (bool, error)
+	IsSchemaCompatibleWithOptions(subject, schema, version string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (bool, error)
+	CheckSchemaCompatibility(subject, schema string, schemaType SchemaType, isKey bool, references ...Reference) (*CompatibilityResult, error)
+	CheckSchemaCompatibilityWithOptions(subject, schema string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (*CompatibilityResult, error)
 }
 
 // ensure interface is implemented
@@ -0,0 +1,183 @@
+package srclient
+
+import "strconv"
+
+// defaultPageSize is the number of entries fetched per
+// request when an iterator is created without a page size.
+const defaultPageSize = 1000
+
+// SubjectIterator pages lazily through the subjects of a
+// registry, so that applications dealing with very large
+// registries don't need to hold every subject in memory.
+// Use it like a bufio.Scanner:
+//
+//	it := srclient.NewSubjectIterator(client, 500, srclient.WithSubjectPrefix("orders."))
+//	for it.Next() {
+//		fmt.Println(it.Subject())
+//	}
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type SubjectIterator struct {
+	client   ISchemaRegistryClient
+	options  []ListOption
+	pageSize int
+	offset   int
+	page     []string
+	last     []string
+	current  string
+	done     bool
+	err      error
+}
+
+// NewSubjectIterator creates an iterator over the subjects
+// of the registry. The subjects are requested pageSize at
+// a time, and the given options (for instance a subject
+// prefix) are applied to every page that is requested.
+func NewSubjectIterator(client ISchemaRegistryClient, pageSize int, opts ...ListOption) *SubjectIterator {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	options := newListOptions(opts)
+	return &SubjectIterator{
+		client:   client,
+		options:  opts,
+		pageSize: pageSize,
+		offset:   options.offset,
+	}
+}
+
+// Next advances the iterator to the next subject, fetching
+// a new page when needed. It returns false when there are
+// no more subjects or an error happened, see Err.
+//
+// Registries that don't support paging ignore the offset
+// and limit, and return every subject at once: iteration
+// stops after a page larger than the page size, or when
+// the same page is returned twice.
+func (it *SubjectIterator) Next() bool {
+	if len(it.page) == 0 && !it.done {
+		opts := append(append([]ListOption{}, it.options...), WithOffset(it.offset), WithLimit(it.pageSize))
+		it.page, it.err = it.client.GetSubjects(opts...)
+		if it.err != nil {
+			it.done = true
+			it.page = nil
+			return false
+		}
+		if len(it.page) > 0 && equalStrings(it.page, it.last) {
+			it.page = nil
+		}
+		it.last = it.page
+		it.offset += len(it.page)
+		it.done = len(it.page) != it.pageSize
+	}
+	if len(it.page) == 0 {
+		return false
+	}
+	it.current, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Subject returns the subject the iterator currently points to.
+func (it *SubjectIterator) Subject() string {
+	return it.current
+}
+
+// Err returns the first error that stopped the iteration, if any.
+func (it *SubjectIterator) Err() error {
+	return it.err
+}
+
+// VersionIterator pages lazily through the versions of
+// a subject. It is used the same way as SubjectIterator.
+type VersionIterator struct {
+	client   ISchemaRegistryClient
+	subject  string
+	isKey    bool
+	pageSize int
+	offset   int
+	page     []int
+	last     []int
+	current  int
+	done     bool
+	err      error
+}
+
+// NewVersionIterator creates an iterator over the versions of
+// the given subject, requesting them pageSize at a time.
+func NewVersionIterator(client ISchemaRegistryClient, subject string, isKey bool, pageSize int) *VersionIterator {
+	if pageSize <= 0 {
+		pageSize = defaultPageSize
+	}
+	return &VersionIterator{
+		client:   client,
+		subject:  subject,
+		isKey:    isKey,
+		pageSize: pageSize,
+	}
+}
+
+// Next advances the iterator to the next version, fetching
+// a new page when needed. It returns false when there are
+// no more versions or an error happened, see Err.
+func (it *VersionIterator) Next() bool {
+	if len(it.page) == 0 && !it.done {
+		it.page, it.err = it.client.GetSchemaVersions(it.subject, it.isKey, WithOffset(it.offset), WithLimit(it.pageSize))
+		if it.err != nil {
+			it.done = true
+			it.page = nil
+			return false
+		}
+		if len(it.page) > 0 && equalInts(it.page, it.last) {
+			it.page = nil
+		}
+		it.last = it.page
+		it.offset += len(it.page)
+		it.done = len(it.page) != it.pageSize
+	}
+	if len(it.page) == 0 {
+		return false
+	}
+	it.current, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Version returns the version the iterator currently points to.
+func (it *VersionIterator) Version() int {
+	return it.current
+}
+
+// Schema fetches the schema of the version the iterator
+// currently points to, taking advantage of the cache.
+func (it *VersionIterator) Schema() (*Schema, error) {
+	return it.client.GetSchemaByVersion(it.subject, strconv.Itoa(it.current), it.isKey)
+}
+
+// Err returns the first error that stopped the iteration, if any.
+func (it *VersionIterator) Err() error {
+	return it.err
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
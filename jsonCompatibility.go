@@ -0,0 +1,683 @@
+package srclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of JSON Schema changes that Schema Registry considers
+// incompatible. Changes that are not listed here, like adding
+// an optional property to a closed content model or extending
+// the type of a property, are compatible.
+const (
+	JsonTypeNarrowed                                  IncompatibilityType = "TYPE_NARROWED"
+	JsonTypeChanged                                   IncompatibilityType = "TYPE_CHANGED"
+	JsonMaxLengthAdded                                IncompatibilityType = "MAX_LENGTH_ADDED"
+	JsonMaxLengthDecreased                            IncompatibilityType = "MAX_LENGTH_DECREASED"
+	JsonMinLengthAdded                                IncompatibilityType = "MIN_LENGTH_ADDED"
+	JsonMinLengthIncreased                            IncompatibilityType = "MIN_LENGTH_INCREASED"
+	JsonPatternAdded                                  IncompatibilityType = "PATTERN_ADDED"
+	JsonPatternChanged                                IncompatibilityType = "PATTERN_CHANGED"
+	JsonMaximumAdded                                  IncompatibilityType = "MAXIMUM_ADDED"
+	JsonMaximumDecreased                              IncompatibilityType = "MAXIMUM_DECREASED"
+	JsonMinimumAdded                                  IncompatibilityType = "MINIMUM_ADDED"
+	JsonMinimumIncreased                              IncompatibilityType = "MINIMUM_INCREASED"
+	JsonExclusiveMaximumAdded                         IncompatibilityType = "EXCLUSIVE_MAXIMUM_ADDED"
+	JsonExclusiveMaximumDecreased                     IncompatibilityType = "EXCLUSIVE_MAXIMUM_DECREASED"
+	JsonExclusiveMinimumAdded                         IncompatibilityType = "EXCLUSIVE_MINIMUM_ADDED"
+	JsonExclusiveMinimumIncreased                     IncompatibilityType = "EXCLUSIVE_MINIMUM_INCREASED"
+	JsonMultipleOfAdded                               IncompatibilityType = "MULTIPLE_OF_ADDED"
+	JsonMultipleOfExpanded                            IncompatibilityType = "MULTIPLE_OF_EXPANDED"
+	JsonMultipleOfChanged                             IncompatibilityType = "MULTIPLE_OF_CHANGED"
+	JsonRequiredAttributeAdded                        IncompatibilityType = "REQUIRED_ATTRIBUTE_ADDED"
+	JsonMaxPropertiesAdded                            IncompatibilityType = "MAX_PROPERTIES_ADDED"
+	JsonMaxPropertiesDecreased                        IncompatibilityType = "MAX_PROPERTIES_DECREASED"
+	JsonMinPropertiesAdded                            IncompatibilityType = "MIN_PROPERTIES_ADDED"
+	JsonMinPropertiesIncreased                        IncompatibilityType = "MIN_PROPERTIES_INCREASED"
+	JsonAdditionalPropertiesRemoved                   IncompatibilityType = "ADDITIONAL_PROPERTIES_REMOVED"
+	JsonAdditionalPropertiesNarrowed                  IncompatibilityType = "ADDITIONAL_PROPERTIES_NARROWED"
+	JsonPropertyAddedToOpenContentModel               IncompatibilityType = "PROPERTY_ADDED_TO_OPEN_CONTENT_MODEL"
+	JsonRequiredPropertyAddedToUnopenContentModel     IncompatibilityType = "REQUIRED_PROPERTY_ADDED_TO_UNOPEN_CONTENT_MODEL"
+	JsonPropertyRemovedFromClosedContentModel         IncompatibilityType = "PROPERTY_REMOVED_FROM_CLOSED_CONTENT_MODEL"
+	JsonPropertyAddedNotCoveredByPartiallyOpenModel   IncompatibilityType = "PROPERTY_ADDED_NOT_COVERED_BY_PARTIALLY_OPEN_CONTENT_MODEL"
+	JsonPropertyRemovedNotCoveredByPartiallyOpenModel IncompatibilityType = "PROPERTY_REMOVED_NOT_COVERED_BY_PARTIALLY_OPEN_CONTENT_MODEL"
+	JsonMaxItemsAdded                                 IncompatibilityType = "MAX_ITEMS_ADDED"
+	JsonMaxItemsDecreased                             IncompatibilityType = "MAX_ITEMS_DECREASED"
+	JsonMinItemsAdded                                 IncompatibilityType = "MIN_ITEMS_ADDED"
+	JsonMinItemsIncreased                             IncompatibilityType = "MIN_ITEMS_INCREASED"
+	JsonUniqueItemsAdded                              IncompatibilityType = "UNIQUE_ITEMS_ADDED"
+	JsonItemAddedToOpenContentModel                   IncompatibilityType = "ITEM_ADDED_TO_OPEN_CONTENT_MODEL"
+	JsonItemRemovedFromClosedContentModel             IncompatibilityType = "ITEM_REMOVED_FROM_CLOSED_CONTENT_MODEL"
+	JsonEnumArrayNarrowed                             IncompatibilityType = "ENUM_ARRAY_NARROWED"
+	JsonEnumArrayChanged                              IncompatibilityType = "ENUM_ARRAY_CHANGED"
+	JsonCombinedTypeChanged                           IncompatibilityType = "COMBINED_TYPE_CHANGED"
+	JsonProductTypeExtended                           IncompatibilityType = "PRODUCT_TYPE_EXTENDED"
+	JsonSumTypeNarrowed                               IncompatibilityType = "SUM_TYPE_NARROWED"
+)
+
+var jsonDescriptions = map[IncompatibilityType]string{
+	JsonTypeNarrowed:                                  "the type is narrowed",
+	JsonTypeChanged:                                   "the type is changed",
+	JsonMaxLengthAdded:                                "a maxLength is added",
+	JsonMaxLengthDecreased:                            "the maxLength is decreased",
+	JsonMinLengthAdded:                                "a minLength is added",
+	JsonMinLengthIncreased:                            "the minLength is increased",
+	JsonPatternAdded:                                  "a pattern is added",
+	JsonPatternChanged:                                "the pattern is changed",
+	JsonMaximumAdded:                                  "a maximum is added",
+	JsonMaximumDecreased:                              "the maximum is decreased",
+	JsonMinimumAdded:                                  "a minimum is added",
+	JsonMinimumIncreased:                              "the minimum is increased",
+	JsonExclusiveMaximumAdded:                         "an exclusiveMaximum is added",
+	JsonExclusiveMaximumDecreased:                     "the exclusiveMaximum is decreased",
+	JsonExclusiveMinimumAdded:                         "an exclusiveMinimum is added",
+	JsonExclusiveMinimumIncreased:                     "the exclusiveMinimum is increased",
+	JsonMultipleOfAdded:                               "a multipleOf is added",
+	JsonMultipleOfExpanded:                            "the multipleOf is expanded",
+	JsonMultipleOfChanged:                             "the multipleOf is changed",
+	JsonRequiredAttributeAdded:                        "a required property without a default is added",
+	JsonMaxPropertiesAdded:                            "a maxProperties is added",
+	JsonMaxPropertiesDecreased:                        "the maxProperties is decreased",
+	JsonMinPropertiesAdded:                            "a minProperties is added",
+	JsonMinPropertiesIncreased:                        "the minProperties is increased",
+	JsonAdditionalPropertiesRemoved:                   "additional properties are no longer allowed",
+	JsonAdditionalPropertiesNarrowed:                  "the schema of additional properties is narrowed",
+	JsonPropertyAddedToOpenContentModel:               "a property is added to an open content model",
+	JsonRequiredPropertyAddedToUnopenContentModel:     "a required property is added to a closed content model",
+	JsonPropertyRemovedFromClosedContentModel:         "a property is removed from a closed content model",
+	JsonPropertyAddedNotCoveredByPartiallyOpenModel:   "a property that is not covered by additionalProperties is added",
+	JsonPropertyRemovedNotCoveredByPartiallyOpenModel: "a property that is not covered by additionalProperties is removed",
+	JsonMaxItemsAdded:                                 "a maxItems is added",
+	JsonMaxItemsDecreased:                             "the maxItems is decreased",
+	JsonMinItemsAdded:                                 "a minItems is added",
+	JsonMinItemsIncreased:                             "the minItems is increased",
+	JsonUniqueItemsAdded:                              "uniqueItems is added",
+	JsonItemAddedToOpenContentModel:                   "an item is added to an open content model",
+	JsonItemRemovedFromClosedContentModel:             "an item is removed from a closed content model",
+	JsonEnumArrayNarrowed:                             "the enum is narrowed",
+	JsonEnumArrayChanged:                              "the enum is changed",
+	JsonCombinedTypeChanged:                           "the combined type is changed",
+	JsonProductTypeExtended:                           "the allOf is extended",
+	JsonSumTypeNarrowed:                               "the oneOf or anyOf is narrowed",
+}
+
+// jsonChecker compares JSON Schemas the way Schema Registry does:
+// the schema that produced the data (the original) is diffed with
+// the one that consumes it (the update), and the schemas are only
+// compatible if none of the differences breaks the consumer.
+type jsonChecker struct{}
+
+// jsonSchema is a parsed JSON Schema document. Its root is kept
+// around to resolve the local references found in subschemas.
+type jsonSchema struct {
+	root interface{}
+}
+
+func (jsonChecker) parse(schema string) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewBufferString(schema))
+	decoder.UseNumber()
+	var root interface{}
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("invalid json schema: %w", err)
+	}
+	switch root.(type) {
+	case map[string]interface{}, bool:
+		return &jsonSchema{root: root}, nil
+	}
+	return nil, fmt.Errorf("invalid json schema: unexpected %v", root)
+}
+
+func (jsonChecker) check(reader, writer interface{}, newIsReader bool) []Incompatibility {
+	diff := &jsonDiff{
+		original:    writer.(*jsonSchema),
+		update:      reader.(*jsonSchema),
+		newIsReader: newIsReader,
+		visiting:    map[string]bool{},
+	}
+	diff.compare(diff.original.root, diff.update.root, "#")
+	return diff.found
+}
+
+type jsonDiff struct {
+	original    *jsonSchema
+	update      *jsonSchema
+	newIsReader bool
+	// visiting holds the pairs of references being compared,
+	// which keeps recursive schemas from looping forever.
+	visiting map[string]bool
+	found    []Incompatibility
+}
+
+func (diff *jsonDiff) report(incompatibilityType IncompatibilityType, path string) {
+	readerAge, writerAge := ages(diff.newIsReader)
+	diff.found = append(diff.found, Incompatibility{
+		Type: incompatibilityType,
+		Path: path,
+		Description: fmt.Sprintf("Found an incompatible change at path '%s' of the %s schema when compared to the %s schema: %s",
+			path, readerAge, writerAge, jsonDescriptions[incompatibilityType]),
+		AdditionalInfo: path,
+	})
+}
+
+// compatible tells if the update accepts everything the original
+// does without recording anything, which is needed to match the
+// subschemas of combined types.
+func (diff *jsonDiff) compatible(original, update interface{}) bool {
+	probe := &jsonDiff{original: diff.original, update: diff.update, newIsReader: diff.newIsReader, visiting: diff.visiting}
+	probe.compare(original, update, "#")
+	return len(probe.found) == 0
+}
+
+func (diff *jsonDiff) compare(original, update interface{}, path string) {
+	var originalRef, updateRef string
+	original, originalRef = diff.original.resolve(original)
+	update, updateRef = diff.update.resolve(update)
+	if len(originalRef) > 0 || len(updateRef) > 0 {
+		key := originalRef + "|" + updateRef
+		if diff.visiting[key] {
+			return
+		}
+		diff.visiting[key] = true
+		defer delete(diff.visiting, key)
+	}
+
+	// An empty schema accepts anything, while a false schema rejects everything.
+	if isJSONFalse(original) || isJSONEmpty(update) {
+		return
+	}
+	if isJSONEmpty(original) || isJSONFalse(update) {
+		diff.report(JsonTypeNarrowed, path)
+		return
+	}
+	o, u := original.(map[string]interface{}), update.(map[string]interface{})
+
+	if diff.compareCombined(o, u, path) {
+		return
+	}
+	if !diff.compareTypes(o, u, path) {
+		return
+	}
+	diff.compareEnums(o, u, path)
+
+	diff.compareUpperBound(o, u, "maxLength", path, JsonMaxLengthAdded, JsonMaxLengthDecreased)
+	diff.compareLowerBound(o, u, "minLength", path, JsonMinLengthAdded, JsonMinLengthIncreased)
+	if pattern, ok := u["pattern"]; ok {
+		if previous, existed := o["pattern"]; !existed {
+			diff.report(JsonPatternAdded, path)
+		} else if previous != pattern {
+			diff.report(JsonPatternChanged, path)
+		}
+	}
+
+	diff.compareUpperBound(o, u, "maximum", path, JsonMaximumAdded, JsonMaximumDecreased)
+	diff.compareLowerBound(o, u, "minimum", path, JsonMinimumAdded, JsonMinimumIncreased)
+	diff.compareUpperBound(o, u, "exclusiveMaximum", path, JsonExclusiveMaximumAdded, JsonExclusiveMaximumDecreased)
+	diff.compareLowerBound(o, u, "exclusiveMinimum", path, JsonExclusiveMinimumAdded, JsonExclusiveMinimumIncreased)
+	if multipleOf, ok := jsonNumber(u["multipleOf"]); ok {
+		previous, existed := jsonNumber(o["multipleOf"])
+		switch {
+		case !existed:
+			diff.report(JsonMultipleOfAdded, path)
+		case isMultiple(previous, multipleOf):
+			// Same value, or a divisor of it, which is less restrictive.
+		case isMultiple(multipleOf, previous):
+			diff.report(JsonMultipleOfExpanded, path)
+		default:
+			diff.report(JsonMultipleOfChanged, path)
+		}
+	}
+
+	diff.compareObjects(o, u, path)
+	diff.compareArrays(o, u, path)
+}
+
+// compareCombined handles the allOf, anyOf and oneOf keywords.
+// It returns true when the schemas were compared as combined.
+func (diff *jsonDiff) compareCombined(o, u map[string]interface{}, path string) bool {
+	originalKeyword, originalSchemas := jsonCombination(o)
+	updateKeyword, updateSchemas := jsonCombination(u)
+	switch {
+	case len(originalKeyword) == 0 && len(updateKeyword) == 0:
+		return false
+	case len(originalKeyword) == 0:
+		// Turning a schema into a union of schemas, one of which
+		// accepts the original, extends it (allOf would narrow it).
+		if updateKeyword != "allOf" {
+			for _, schema := range updateSchemas {
+				if diff.compatible(o, schema) {
+					return true
+				}
+			}
+		}
+		diff.report(JsonCombinedTypeChanged, path)
+		return true
+	case len(updateKeyword) == 0:
+		if originalKeyword == "allOf" {
+			for _, schema := range originalSchemas {
+				if diff.compatible(schema, u) {
+					return true
+				}
+			}
+		}
+		diff.report(JsonCombinedTypeChanged, path)
+		return true
+	case originalKeyword != updateKeyword && !(originalKeyword == "oneOf" && updateKeyword == "anyOf"):
+		diff.report(JsonCombinedTypeChanged, path)
+		return true
+	}
+
+	if updateKeyword == "allOf" && len(updateSchemas) > len(originalSchemas) {
+		diff.report(JsonProductTypeExtended, path)
+		return true
+	}
+	if updateKeyword != "allOf" && len(updateSchemas) < len(originalSchemas) {
+		diff.report(JsonSumTypeNarrowed, path)
+		return true
+	}
+	// Every original subschema must be accepted by a distinct update
+	// subschema; the closest match is reported when none is found.
+	used := map[int]bool{}
+	for i, originalSchema := range originalSchemas {
+		matched := -1
+		for j, updateSchema := range updateSchemas {
+			if !used[j] && diff.compatible(originalSchema, updateSchema) {
+				matched = j
+				break
+			}
+		}
+		if matched < 0 {
+			subPath := fmt.Sprintf("%s/%s/%d", path, originalKeyword, i)
+			if i < len(updateSchemas) && !used[i] {
+				diff.compare(originalSchema, updateSchemas[i], subPath)
+			} else {
+				diff.report(JsonCombinedTypeChanged, subPath)
+			}
+			continue
+		}
+		used[matched] = true
+	}
+	return true
+}
+
+// compareTypes checks that the update accepts all the types of the
+// original. It returns false when the types are so different that
+// comparing the rest of the keywords is meaningless.
+func (diff *jsonDiff) compareTypes(o, u map[string]interface{}, path string) bool {
+	originalTypes, updateTypes := jsonTypes(o), jsonTypes(u)
+	if updateTypes == nil {
+		return true
+	}
+	if originalTypes == nil {
+		diff.report(JsonTypeNarrowed, path)
+		return false
+	}
+	covers := func(types map[string]bool, t string) bool {
+		return types[t] || (t == "integer" && types["number"])
+	}
+	var uncovered bool
+	for t := range originalTypes {
+		if !covers(updateTypes, t) {
+			uncovered = true
+		}
+	}
+	if !uncovered {
+		return true
+	}
+	for t := range updateTypes {
+		if !covers(originalTypes, t) {
+			diff.report(JsonTypeChanged, path)
+			return false
+		}
+	}
+	diff.report(JsonTypeNarrowed, path)
+	return true
+}
+
+func (diff *jsonDiff) compareEnums(o, u map[string]interface{}, path string) {
+	updateEnum, ok := u["enum"].([]interface{})
+	if !ok {
+		return
+	}
+	originalEnum, ok := o["enum"].([]interface{})
+	if !ok {
+		diff.report(JsonEnumArrayNarrowed, path)
+		return
+	}
+	contains := func(values []interface{}, value interface{}) bool {
+		for _, v := range values {
+			if reflect.DeepEqual(v, value) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, value := range originalEnum {
+		if !contains(updateEnum, value) {
+			for _, value := range updateEnum {
+				if !contains(originalEnum, value) {
+					diff.report(JsonEnumArrayChanged, path)
+					return
+				}
+			}
+			diff.report(JsonEnumArrayNarrowed, path)
+			return
+		}
+	}
+}
+
+func (diff *jsonDiff) compareUpperBound(o, u map[string]interface{}, keyword, path string, added, decreased IncompatibilityType) {
+	bound, ok := jsonNumber(u[keyword])
+	if !ok {
+		return
+	}
+	if previous, existed := jsonNumber(o[keyword]); !existed {
+		diff.report(added, path)
+	} else if bound < previous {
+		diff.report(decreased, path)
+	}
+}
+
+func (diff *jsonDiff) compareLowerBound(o, u map[string]interface{}, keyword, path string, added, increased IncompatibilityType) {
+	bound, ok := jsonNumber(u[keyword])
+	if !ok {
+		return
+	}
+	if previous, existed := jsonNumber(o[keyword]); !existed {
+		diff.report(added, path)
+	} else if bound > previous {
+		diff.report(increased, path)
+	}
+}
+
+func (diff *jsonDiff) compareObjects(o, u map[string]interface{}, path string) {
+	originalProperties, _ := o["properties"].(map[string]interface{})
+	updateProperties, _ := u["properties"].(map[string]interface{})
+	originalRequired, updateRequired := jsonRequired(o), jsonRequired(u)
+	originalAdditional, updateAdditional := o["additionalProperties"], u["additionalProperties"]
+
+	for _, name := range sortedKeys(updateProperties) {
+		property := updateProperties[name]
+		propertyPath := path + "/properties/" + name
+		if previous, existed := originalProperties[name]; existed {
+			diff.compare(previous, property, propertyPath)
+			continue
+		}
+		switch {
+		case isJSONOpen(originalAdditional):
+			if !isJSONEmpty(diff.update.deref(property)) {
+				diff.report(JsonPropertyAddedToOpenContentModel, propertyPath)
+			}
+		case isJSONFalse(originalAdditional):
+			if updateRequired[name] && !jsonHasDefault(diff.update.deref(property)) {
+				diff.report(JsonRequiredPropertyAddedToUnopenContentModel, propertyPath)
+			}
+		default:
+			if !diff.compatible(originalAdditional, property) {
+				diff.report(JsonPropertyAddedNotCoveredByPartiallyOpenModel, propertyPath)
+			}
+		}
+	}
+	for _, name := range sortedKeys(originalProperties) {
+		if _, kept := updateProperties[name]; kept {
+			continue
+		}
+		propertyPath := path + "/properties/" + name
+		switch {
+		case isJSONOpen(updateAdditional):
+		case isJSONFalse(updateAdditional):
+			diff.report(JsonPropertyRemovedFromClosedContentModel, propertyPath)
+		default:
+			if !diff.compatible(originalProperties[name], updateAdditional) {
+				diff.report(JsonPropertyRemovedNotCoveredByPartiallyOpenModel, propertyPath)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(updateRequired) {
+		if originalRequired[name] {
+			continue
+		}
+		// Properties that were just added are reported above.
+		if _, existed := originalProperties[name]; !existed {
+			if _, declared := updateProperties[name]; declared {
+				continue
+			}
+		}
+		if !jsonHasDefault(diff.update.deref(updateProperties[name])) {
+			diff.report(JsonRequiredAttributeAdded, path+"/required/"+name)
+		}
+	}
+
+	switch {
+	case isJSONFalse(originalAdditional) || isJSONOpen(updateAdditional):
+	case isJSONFalse(updateAdditional):
+		diff.report(JsonAdditionalPropertiesRemoved, path+"/additionalProperties")
+	case isJSONOpen(originalAdditional):
+		diff.report(JsonAdditionalPropertiesNarrowed, path+"/additionalProperties")
+	default:
+		diff.compare(originalAdditional, updateAdditional, path+"/additionalProperties")
+	}
+
+	diff.compareUpperBound(o, u, "maxProperties", path, JsonMaxPropertiesAdded, JsonMaxPropertiesDecreased)
+	diff.compareLowerBound(o, u, "minProperties", path, JsonMinPropertiesAdded, JsonMinPropertiesIncreased)
+}
+
+func (diff *jsonDiff) compareArrays(o, u map[string]interface{}, path string) {
+	diff.compareUpperBound(o, u, "maxItems", path, JsonMaxItemsAdded, JsonMaxItemsDecreased)
+	diff.compareLowerBound(o, u, "minItems", path, JsonMinItemsAdded, JsonMinItemsIncreased)
+	if unique, _ := u["uniqueItems"].(bool); unique {
+		if previous, _ := o["uniqueItems"].(bool); !previous {
+			diff.report(JsonUniqueItemsAdded, path)
+		}
+	}
+
+	originalItems, originalTuple := o["items"].([]interface{})
+	updateItems, updateTuple := u["items"].([]interface{})
+	if !originalTuple && !updateTuple {
+		if items, ok := u["items"]; ok {
+			previous, existed := o["items"]
+			if !existed {
+				previous = true
+			}
+			diff.compare(previous, items, path+"/items")
+		}
+		return
+	}
+	if !originalTuple || !updateTuple {
+		diff.report(JsonTypeChanged, path+"/items")
+		return
+	}
+	for i := range updateItems {
+		itemPath := fmt.Sprintf("%s/items/%d", path, i)
+		if i < len(originalItems) {
+			diff.compare(originalItems[i], updateItems[i], itemPath)
+		} else if isJSONOpen(o["additionalItems"]) && !isJSONEmpty(diff.update.deref(updateItems[i])) {
+			diff.report(JsonItemAddedToOpenContentModel, itemPath)
+		}
+	}
+	if len(originalItems) > len(updateItems) && isJSONFalse(u["additionalItems"]) {
+		diff.report(JsonItemRemovedFromClosedContentModel, fmt.Sprintf("%s/items/%d", path, len(updateItems)))
+	}
+}
+
+// resolve follows local references, like "#/definitions/Address",
+// returning the referenced schema along with the reference itself.
+func (schema *jsonSchema) resolve(node interface{}) (interface{}, string) {
+	var ref string
+	for i := 0; i < 32; i++ {
+		object, ok := node.(map[string]interface{})
+		if !ok {
+			return node, ref
+		}
+		next, ok := object["$ref"].(string)
+		if !ok {
+			return node, ref
+		}
+		ref = next
+		node = schema.pointer(next)
+	}
+	return node, ref
+}
+
+func (schema *jsonSchema) deref(node interface{}) interface{} {
+	node, _ = schema.resolve(node)
+	return node
+}
+
+func (schema *jsonSchema) pointer(ref string) interface{} {
+	if !strings.HasPrefix(ref, "#") {
+		// Only local references can be resolved offline,
+		// anything else is treated as an empty schema.
+		return true
+	}
+	node := schema.root
+	for _, token := range strings.Split(strings.TrimPrefix(ref, "#"), "/") {
+		if len(token) == 0 {
+			continue
+		}
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch current := node.(type) {
+		case map[string]interface{}:
+			node = current[token]
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(current) {
+				return true
+			}
+			node = current[i]
+		default:
+			return true
+		}
+	}
+	if node == nil {
+		return true
+	}
+	return node
+}
+
+func jsonCombination(schema map[string]interface{}) (string, []interface{}) {
+	for _, keyword := range []string{"oneOf", "anyOf", "allOf"} {
+		if schemas, ok := schema[keyword].([]interface{}); ok {
+			return keyword, schemas
+		}
+	}
+	return "", nil
+}
+
+// jsonTypes returns the types accepted by the schema, inferring them
+// from the keywords in use when there is no "type", or nil when the
+// schema accepts any type.
+func jsonTypes(schema map[string]interface{}) map[string]bool {
+	types := map[string]bool{}
+	switch t := schema["type"].(type) {
+	case string:
+		types[t] = true
+		return types
+	case []interface{}:
+		for _, value := range t {
+			if s, ok := value.(string); ok {
+				types[s] = true
+			}
+		}
+		return types
+	}
+	inferred := map[string][]string{
+		"object": {"properties", "required", "additionalProperties", "maxProperties", "minProperties"},
+		"array":  {"items", "additionalItems", "maxItems", "minItems", "uniqueItems"},
+		"string": {"maxLength", "minLength", "pattern"},
+		"number": {"maximum", "minimum", "exclusiveMaximum", "exclusiveMinimum", "multipleOf"},
+	}
+	for t, keywords := range inferred {
+		for _, keyword := range keywords {
+			if _, ok := schema[keyword]; ok {
+				types[t] = true
+			}
+		}
+	}
+	if len(types) == 0 {
+		return nil
+	}
+	return types
+}
+
+func jsonRequired(schema map[string]interface{}) map[string]bool {
+	required := map[string]bool{}
+	names, _ := schema["required"].([]interface{})
+	for _, name := range names {
+		if s, ok := name.(string); ok {
+			required[s] = true
+		}
+	}
+	return required
+}
+
+func jsonHasDefault(schema interface{}) bool {
+	object, ok := schema.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	_, ok = object["default"]
+	return ok
+}
+
+func jsonNumber(value interface{}) (float64, bool) {
+	number, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	f, err := number.Float64()
+	return f, err == nil
+}
+
+func isMultiple(value, divisor float64) bool {
+	if divisor == 0 {
+		return false
+	}
+	quotient := value / divisor
+	return math.Abs(quotient-math.Round(quotient)) < 1e-9
+}
+
+// isJSONOpen tells if additional properties or items are allowed
+// without restriction, which is the default when they are absent.
+func isJSONOpen(additional interface{}) bool {
+	return additional == nil || isJSONEmpty(additional)
+}
+
+func isJSONEmpty(schema interface{}) bool {
+	if b, ok := schema.(bool); ok {
+		return b
+	}
+	object, ok := schema.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for keyword := range object {
+		switch keyword {
+		case "$id", "$schema", "title", "description", "default", "examples", "$comment":
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func isJSONFalse(schema interface{}) bool {
+	b, ok := schema.(bool)
+	return ok && !b
+}
+
+func sortedKeys(m interface{}) []string {
+	value := reflect.ValueOf(m)
+	keys := make([]string, 0, value.Len())
+	for _, key := range value.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
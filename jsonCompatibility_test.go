@@ -0,0 +1,108 @@
+package srclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const jsonOrderV1 = `{
+	"type": "object",
+	"properties": {
+		"id": {"type": "integer"},
+		"note": {"type": "string"},
+		"address": {"$ref": "#/definitions/Address"}
+	},
+	"required": ["id"],
+	"additionalProperties": false,
+	"definitions": {
+		"Address": {"type": "object", "properties": {"city": {"type": "string"}}}
+	}
+}`
+
+func TestCheckCompatibility_Json(t *testing.T) {
+	cases := []struct {
+		name     string
+		level    CompatibilityLevel
+		schema   string
+		expected []IncompatibilityType
+		paths    []string
+	}{
+		{
+			name:  "optional property added to closed content model and type extended",
+			level: Backward,
+			schema: `{"type": "object", "additionalProperties": false, "required": ["id"], "properties": {
+				"id": {"type": "number"}, "note": {"type": ["string", "null"]}, "address": {"type": "object"}, "total": {"type": "number"}
+			}}`,
+		},
+		{
+			name:  "required property added to closed content model",
+			level: Backward,
+			schema: `{"type": "object", "additionalProperties": false, "required": ["id", "total"], "properties": {
+				"id": {"type": "integer"}, "note": {"type": "string"}, "address": {"type": "object"}, "total": {"type": "number"}
+			}}`,
+			expected: []IncompatibilityType{JsonRequiredPropertyAddedToUnopenContentModel},
+			paths:    []string{"#/properties/total"},
+		},
+		{
+			name:  "property removed from closed content model",
+			level: Backward,
+			schema: `{"type": "object", "additionalProperties": false, "required": ["id"], "properties": {
+				"id": {"type": "integer"}, "address": {"type": "object"}
+			}}`,
+			expected: []IncompatibilityType{JsonPropertyRemovedFromClosedContentModel},
+			paths:    []string{"#/properties/note"},
+		},
+		{
+			name:  "type narrowed in a referenced definition",
+			level: Forward,
+			schema: `{"type": "object", "additionalProperties": false, "required": ["id"], "properties": {
+				"id": {"type": "integer"}, "note": {"type": "string"},
+				"address": {"type": "object", "properties": {"city": {"type": ["string", "null"]}}}
+			}}`,
+			expected: []IncompatibilityType{JsonTypeNarrowed},
+			paths:    []string{"#/properties/address/properties/city"},
+		},
+		{
+			name:  "constraints added",
+			level: Backward,
+			schema: `{"type": "object", "additionalProperties": false, "required": ["id", "note"], "properties": {
+				"id": {"type": "integer", "minimum": 1}, "note": {"type": "string", "maxLength": 10}, "address": {"type": "object"}
+			}}`,
+			expected: []IncompatibilityType{JsonMinimumAdded, JsonMaxLengthAdded, JsonRequiredAttributeAdded},
+			paths:    []string{"#/properties/id", "#/properties/note", "#/required/note"},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := CheckCompatibility(Json, c.level, c.schema, jsonOrderV1)
+			assert.NoError(t, err)
+			assert.Equal(t, len(c.expected) == 0, result.IsCompatible)
+			var types []IncompatibilityType
+			var paths []string
+			for _, incompatibility := range result.Incompatibilities {
+				types = append(types, incompatibility.Type)
+				paths = append(paths, incompatibility.Path)
+			}
+			assert.Equal(t, c.expected, types)
+			assert.Equal(t, c.paths, paths)
+		})
+	}
+}
+
+func TestCheckCompatibility_JsonOpenContentModel(t *testing.T) {
+	v1 := `{"type": "object", "properties": {"id": {"type": "integer"}}}`
+	v2 := `{"type": "object", "properties": {"id": {"type": "integer"}, "name": {"type": "string"}}}`
+
+	// An old producer with an open content model may have
+	// written "name" with any type, so adding it is unsafe.
+	result, err := CheckCompatibility(Json, Backward, v2, v1)
+	assert.NoError(t, err)
+	assert.False(t, result.IsCompatible)
+	assert.Equal(t, JsonPropertyAddedToOpenContentModel, result.Incompatibilities[0].Type)
+
+	result, err = CheckCompatibility(Json, Forward, v2, v1)
+	assert.NoError(t, err)
+	assert.True(t, result.IsCompatible)
+}
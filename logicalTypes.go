@@ -0,0 +1,207 @@
+package srclient
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+// LogicalType names an Avro logical type, which gives a meaning to
+// the values of an underlying type, such as a point in time to a long.
+type LogicalType string
+
+// Logical types that Serializer and Deserializer map to Go types.
+// Decimals map to *big.Rat, UUIDs to strings, durations of a day to
+// time.Duration, and dates and timestamps to time.Time. Local
+// timestamps have no time zone, so they are read as the wall clock
+// in UTC, and written from the wall clock of the time.Time given.
+const (
+	Decimal              LogicalType = "decimal"
+	UUID                 LogicalType = "uuid"
+	Date                 LogicalType = "date"
+	TimeMillis           LogicalType = "time-millis"
+	TimeMicros           LogicalType = "time-micros"
+	TimestampMillis      LogicalType = "timestamp-millis"
+	TimestampMicros      LogicalType = "timestamp-micros"
+	LocalTimestampMillis LogicalType = "local-timestamp-millis"
+	LocalTimestampMicros LogicalType = "local-timestamp-micros"
+)
+
+// logicalTypeMapping holds the logical types that are disabled, whose
+// values are read and written with their underlying type instead.
+type logicalTypeMapping map[LogicalType]bool
+
+// with returns a copy of the mapping with the logical type enabled or
+// disabled, so mappings captured by cached plans are never modified.
+func (disabled logicalTypeMapping) with(logicalType LogicalType, enabled bool) logicalTypeMapping {
+	mapping := logicalTypeMapping{}
+	for lt, off := range disabled {
+		mapping[lt] = off
+	}
+	if enabled {
+		delete(mapping, logicalType)
+	} else {
+		mapping[logicalType] = true
+	}
+	return mapping
+}
+
+// of returns the logical type of t that is mapped, if any. Logical
+// types that don't apply to their underlying type are ignored, as
+// the specification asks.
+func (disabled logicalTypeMapping) of(t *avroType) LogicalType {
+	logicalType := LogicalType(t.logicalType)
+	if len(logicalType) == 0 || disabled[logicalType] {
+		return ""
+	}
+	switch logicalType {
+	case Decimal:
+		if t.kind() == "bytes" || t.kind() == "fixed" {
+			return logicalType
+		}
+	case UUID:
+		if t.kind() == "string" || (t.kind() == "fixed" && t.size == 16) {
+			return logicalType
+		}
+	case Date, TimeMillis:
+		if t.kind() == "int" {
+			return logicalType
+		}
+	case TimeMicros, TimestampMillis, TimestampMicros, LocalTimestampMillis, LocalTimestampMicros:
+		if t.kind() == "long" {
+			return logicalType
+		}
+	}
+	return ""
+}
+
+// native turns a value of the underlying type of t into
+// the Go value its logical type maps to, if it is mapped.
+func (disabled logicalTypeMapping) native(t *avroType, value interface{}) interface{} {
+	switch disabled.of(t) {
+	case Date:
+		return time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(value.(int32)))
+	case TimeMillis:
+		return time.Duration(value.(int32)) * time.Millisecond
+	case TimeMicros:
+		return time.Duration(value.(int64)) * time.Microsecond
+	case TimestampMillis, LocalTimestampMillis:
+		v := value.(int64)
+		return time.Unix(floorDiv(v, 1e3), floorMod(v, 1e3)*int64(time.Millisecond)).UTC()
+	case TimestampMicros, LocalTimestampMicros:
+		v := value.(int64)
+		return time.Unix(floorDiv(v, 1e6), floorMod(v, 1e6)*int64(time.Microsecond)).UTC()
+	case Decimal:
+		b := value.([]byte)
+		unscaled := new(big.Int).SetBytes(b)
+		if len(b) > 0 && b[0]&0x80 != 0 {
+			unscaled.Sub(unscaled, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+		}
+		return new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.scale)), nil))
+	case UUID:
+		if b, ok := value.([]byte); ok {
+			return formatUUID(b)
+		}
+	}
+	return value
+}
+
+// raw turns the Go value of a logical type into a value of its
+// underlying type. Values given in the underlying type are kept.
+func (disabled logicalTypeMapping) raw(t *avroType, value interface{}) (interface{}, error) {
+	switch disabled.of(t) {
+	case Date:
+		if v, ok := value.(time.Time); ok {
+			days := floorDiv(time.Date(v.Year(), v.Month(), v.Day(), 0, 0, 0, 0, time.UTC).Unix(), 24*60*60)
+			return int32(days), nil
+		}
+	case TimeMillis:
+		if v, ok := value.(time.Duration); ok {
+			return int32(v / time.Millisecond), nil
+		}
+	case TimeMicros:
+		if v, ok := value.(time.Duration); ok {
+			return int64(v / time.Microsecond), nil
+		}
+	case TimestampMillis:
+		if v, ok := value.(time.Time); ok {
+			return v.Unix()*1e3 + int64(v.Nanosecond())/int64(time.Millisecond), nil
+		}
+	case TimestampMicros:
+		if v, ok := value.(time.Time); ok {
+			return v.Unix()*1e6 + int64(v.Nanosecond())/int64(time.Microsecond), nil
+		}
+	case LocalTimestampMillis, LocalTimestampMicros:
+		if v, ok := value.(time.Time); ok {
+			wall := time.Date(v.Year(), v.Month(), v.Day(), v.Hour(), v.Minute(), v.Second(), v.Nanosecond(), time.UTC)
+			if disabled.of(t) == LocalTimestampMillis {
+				return wall.Unix()*1e3 + int64(wall.Nanosecond())/int64(time.Millisecond), nil
+			}
+			return wall.Unix()*1e6 + int64(wall.Nanosecond())/int64(time.Microsecond), nil
+		}
+	case Decimal:
+		switch v := value.(type) {
+		case *big.Rat:
+			return decimalBytes(t, v)
+		case big.Rat:
+			return decimalBytes(t, &v)
+		}
+	case UUID:
+		if s, ok := value.(string); ok && t.kind() == "fixed" {
+			return parseUUID(s)
+		}
+	}
+	return value, nil
+}
+
+// decimalBytes writes the unscaled value of a decimal, truncated to
+// its scale like goavro does, in big-endian two's complement.
+func decimalBytes(t *avroType, value *big.Rat) ([]byte, error) {
+	unscaled := new(big.Int).Mul(value.Num(), new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(t.scale)), nil))
+	unscaled.Quo(unscaled, value.Denom())
+
+	size := unscaled.BitLen()/8 + 1
+	if t.kind() == "fixed" {
+		if size > t.size {
+			return nil, fmt.Errorf("decimal %s doesn't fit in %d bytes", value.FloatString(t.scale), t.size)
+		}
+		size = t.size
+	}
+	if unscaled.Sign() < 0 {
+		// Negative numbers are stored as 2^(8*size) + value.
+		unscaled.Add(new(big.Int).Lsh(big.NewInt(1), uint(size*8)), unscaled)
+	}
+	magnitude := unscaled.Bytes()
+	b := make([]byte, size)
+	copy(b[size-len(magnitude):], magnitude)
+	return b, nil
+}
+
+func formatUUID(b []byte) string {
+	s := hex.EncodeToString(b)
+	return s[0:8] + "-" + s[8:12] + "-" + s[12:16] + "-" + s[16:20] + "-" + s[20:]
+}
+
+func parseUUID(s string) ([]byte, error) {
+	b, err := hex.DecodeString(strings.ReplaceAll(s, "-", ""))
+	if err != nil || len(b) != 16 {
+		return nil, fmt.Errorf("invalid uuid %q", s)
+	}
+	return b, nil
+}
+
+// floorDiv divides rounding down, so that times before the epoch
+// keep a positive fraction of a second, as time.Unix expects.
+func floorDiv(a, b int64) int64 {
+	q := a / b
+	if a%b != 0 && (a < 0) != (b < 0) {
+		q--
+	}
+	return q
+}
+
+func floorMod(a, b int64) int64 {
+	return a - floorDiv(a, b)*b
+}
@@ -0,0 +1,213 @@
+package srclient
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+const logicalTypesSchema = `{
+	"type": "record", "name": "Event",
+	"fields": [
+		{"name": "id", "type": {"type": "fixed", "name": "Id", "size": 16, "logicalType": "uuid"}},
+		{"name": "trace", "type": {"type": "string", "logicalType": "uuid"}},
+		{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 40, "scale": 2}},
+		{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+		{"name": "at", "type": {"type": "int", "logicalType": "time-millis"}},
+		{"name": "elapsed", "type": {"type": "long", "logicalType": "time-micros"}},
+		{"name": "created", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+		{"name": "updated", "type": {"type": "long", "logicalType": "timestamp-micros"}},
+		{"name": "local", "type": {"type": "long", "logicalType": "local-timestamp-millis"}},
+		{"name": "localMicros", "type": ["null", {"type": "long", "logicalType": "local-timestamp-micros"}], "default": null}
+	]
+}`
+
+type event struct {
+	ID          string
+	Trace       string
+	Amount      *big.Rat
+	Day         time.Time
+	At          time.Duration
+	Elapsed     time.Duration
+	Created     time.Time
+	Updated     time.Time
+	Local       time.Time
+	LocalMicros *time.Time
+}
+
+func TestLogicalTypes_Structs(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("events", logicalTypesSchema, Avro, false)
+	assert.NoError(t, err)
+
+	amount, _ := new(big.Rat).SetString("-123456789012345678901234567890.25")
+	local := time.Date(2021, 3, 4, 5, 6, 7, 8000, time.FixedZone("CET", 3600))
+	value := event{
+		ID:          "0f8fad5b-d9cb-469f-a165-70867728950e",
+		Trace:       "7c9e6679-7425-40de-944b-e07fc1f90ae7",
+		Amount:      amount,
+		Day:         time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC),
+		At:          90 * time.Minute,
+		Elapsed:     1500 * time.Microsecond,
+		Created:     time.Unix(-1, 5e8).UTC(),
+		Updated:     time.Unix(1600000000, 123456000).UTC(),
+		Local:       local,
+		LocalMicros: &local,
+	}
+	record, err := NewSerializer(mockClient).Serialize(registered, &value)
+	assert.NoError(t, err)
+
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+	var decoded event
+	assert.NoError(t, deserializer.DeserializeInto(record, &decoded))
+	assert.Equal(t, value.ID, decoded.ID)
+	assert.Equal(t, value.Trace, decoded.Trace)
+	assert.Equal(t, "-123456789012345678901234567890.25", decoded.Amount.FloatString(2))
+	assert.Equal(t, value.Day, decoded.Day)
+	assert.Equal(t, value.At, decoded.At)
+	assert.Equal(t, value.Elapsed, decoded.Elapsed)
+	assert.Equal(t, value.Created, decoded.Created)
+	assert.Equal(t, value.Updated, decoded.Updated)
+
+	// Local timestamps keep the wall clock, read back in UTC.
+	wall := time.Date(2021, 3, 4, 5, 6, 7, 0, time.UTC)
+	assert.Equal(t, wall, decoded.Local)
+	assert.Equal(t, wall.Add(8*time.Microsecond), *decoded.LocalMicros)
+
+	// Generic decoding maps the same logical types.
+	native, err := deserializer.Deserialize(record)
+	assert.NoError(t, err)
+	fields := native.(map[string]interface{})
+	assert.Equal(t, value.ID, fields["id"])
+	assert.Equal(t, wall, fields["local"])
+	assert.Equal(t, map[string]interface{}{"long": wall.Add(8 * time.Microsecond)}, fields["localMicros"])
+	assert.Equal(t, 0, amount.Cmp(fields["amount"].(*big.Rat)))
+}
+
+func TestLogicalTypes_Disabled(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("days", `{
+		"type": "record", "name": "Day",
+		"fields": [
+			{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+			{"name": "id", "type": {"type": "fixed", "name": "Id", "size": 16, "logicalType": "uuid"}}
+		]
+	}`, Avro, false)
+	assert.NoError(t, err)
+
+	id := make([]byte, 16)
+	id[15] = 1
+	serializer := NewSerializer(mockClient)
+	record, err := serializer.Serialize(registered, map[string]interface{}{
+		"day": time.Date(1970, 1, 3, 0, 0, 0, 0, time.UTC),
+		"id":  "00000000-0000-0000-0000-000000000001",
+	})
+	assert.NoError(t, err)
+
+	// Values of the underlying types are accepted while mapped, too.
+	raw, err := serializer.Serialize(registered, map[string]interface{}{"day": 2, "id": id})
+	assert.NoError(t, err)
+	assert.Equal(t, record, raw)
+
+	serializer.SetLogicalTypeEnabled(Date, false)
+	_, err = serializer.Serialize(registered, map[string]interface{}{"day": time.Now(), "id": id})
+	assert.Error(t, err)
+
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+	native, err := deserializer.Deserialize(record)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Date(1970, 1, 3, 0, 0, 0, 0, time.UTC), native.(map[string]interface{})["day"])
+
+	deserializer.SetLogicalTypeEnabled(Date, false)
+	deserializer.SetLogicalTypeEnabled(UUID, false)
+	native, err = deserializer.Deserialize(record)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"day": int32(2), "id": id}, native)
+
+	var decoded struct {
+		Day int32
+		ID  [16]byte
+	}
+	assert.NoError(t, deserializer.DeserializeInto(record, &decoded))
+	assert.Equal(t, int32(2), decoded.Day)
+	assert.Equal(t, byte(1), decoded.ID[15])
+
+	deserializer.SetLogicalTypeEnabled(Date, true)
+	var mapped struct {
+		Day time.Time
+		ID  []byte
+	}
+	assert.Error(t, deserializer.DeserializeInto(record, &decoded))
+	assert.NoError(t, deserializer.DeserializeInto(record, &mapped))
+}
+
+// fetchHookClient runs a hook while fetching schemas by ID.
+type fetchHookClient struct {
+	MockSchemaRegistryClient
+	hook func()
+}
+
+func (client fetchHookClient) GetSchemaByID(schemaID int) (*Schema, error) {
+	client.hook()
+	return client.MockSchemaRegistryClient.GetSchemaByID(schemaID)
+}
+
+func TestLogicalTypes_DisabledWhileDecoding(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("days", `{
+		"type": "record", "name": "Day",
+		"fields": [{"name": "day", "type": {"type": "int", "logicalType": "date"}}]
+	}`, Avro, false)
+	assert.NoError(t, err)
+	record, err := NewSerializer(mockClient).Serialize(registered, map[string]interface{}{"day": 2})
+	assert.NoError(t, err)
+
+	var deserializer *Deserializer
+	client := fetchHookClient{MockSchemaRegistryClient: mockClient, hook: func() {
+		deserializer.SetLogicalTypeEnabled(Date, false)
+	}}
+	deserializer, err = NewDeserializer(client)
+	assert.NoError(t, err)
+
+	// The decoding built while dates were mapped isn't cached.
+	_, err = deserializer.Deserialize(record)
+	assert.NoError(t, err)
+	client.hook = func() {}
+	deserializer.client = client
+	native, err := deserializer.Deserialize(record)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"day": int32(2)}, native)
+}
+
+func TestLogicalTypes_MatchGoavro(t *testing.T) {
+	schema := `{
+		"type": "record", "name": "Payment",
+		"fields": [
+			{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+			{"name": "paid", "type": ["null", {"type": "long", "logicalType": "timestamp-millis"}]},
+			{"name": "tags", "type": {"type": "map", "values": "double"}},
+			{"name": "count", "type": "int"}
+		]
+	}`
+	native := map[string]interface{}{
+		"amount": big.NewRat(-1005, 100),
+		"paid":   map[string]interface{}{"long.timestamp-millis": time.Unix(1600000000, 0)},
+		"tags":   map[string]interface{}{"rate": 1.5},
+		"count":  int32(3),
+	}
+	codec, err := goavro.NewCodec(schema)
+	assert.NoError(t, err)
+	expected, err := codec.BinaryFromNative(nil, native)
+	assert.NoError(t, err)
+
+	parsed, err := parseAvroSchema(schema)
+	assert.NoError(t, err)
+	actual, err := appendAvro(nil, parsed, nil, native)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
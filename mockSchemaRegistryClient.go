@@ -1,6 +1,7 @@
 package srclient
 
 import (
+	"crypto/sha256"
 	"errors"
 	"fmt"
 	"net/url"
@@ -15,6 +16,7 @@ type MockSchemaRegistryClient struct {
 	credentials          *credentials
 	schemaCache          map[string]map[*Schema]int
 	idCache              map[int]*Schema
+	guidCache            map[string]*Schema
 	ids                  *Ids
 	codecCreationEnabled bool
 }
@@ -31,6 +33,7 @@ func CreateMockSchemaRegistryClient(mockURL string) MockSchemaRegistryClient {
 		credentials:          nil,
 		schemaCache:          map[string]map[*Schema]int{},
 		idCache:              map[int]*Schema{},
+		guidCache:            map[string]*Schema{},
 		ids:                  &Ids{ids: 0},
 		codecCreationEnabled: false,
 	}
@@ -50,15 +53,16 @@ we set this schema as the first version of the subject and store it in memory.
 Note that there is no enforcement of schema compatibility, any schema goes for all subjects.
 */
 func (mck MockSchemaRegistryClient) CreateSchema(subject string, schema string, schemaType SchemaType, isKey bool, references ...Reference) (*Schema, error) {
+	return mck.CreateSchemaWithOptions(subject, schema, schemaType, isKey, WithReferences(references...))
+}
+
+// CreateSchemaWithOptions registers the schema like CreateSchema,
+// normalizing it first when WithNormalize is given.
+func (mck MockSchemaRegistryClient) CreateSchemaWithOptions(subject string, schema string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (*Schema, error) {
 	concreteSubject := getConcreteSubject(subject, isKey)
-	switch schemaType {
-	case Avro, Json:
-		compiledRegex := regexp.MustCompile(`\r?\n`)
-		schema = compiledRegex.ReplaceAllString(schema, " ")
-	case Protobuf:
-		break
-	default:
-		return nil, fmt.Errorf("invalid schema type. valid values are Avro, Json, or Protobuf")
+	schema, err := mockSchemaText(schema, schemaType, newSchemaOptions(opts))
+	if err != nil {
+		return nil, err
 	}
 
 	// Subject exists, we just need a new version of the schema registered
@@ -77,12 +81,12 @@ func (mck MockSchemaRegistryClient) CreateSchema(subject string, schema string,
 		}
 
 		mck.ids.ids++
-		result := mck.generateVersion(concreteSubject, schema)
+		result := mck.generateVersion(concreteSubject, schema, schemaType)
 		return result, nil
 	}
 	//Subject does not exist, We need full registration
 	mck.ids.ids++
-	result := mck.generateVersion(concreteSubject, schema)
+	result := mck.generateVersion(concreteSubject, schema, schemaType)
 	return result, nil
 }
 
@@ -101,6 +105,22 @@ func (mck MockSchemaRegistryClient) GetSchemaByID(schemaID int) (*Schema, error)
 	return thisSchema, nil
 }
 
+// GetSchemaByGUID returns the schema with the given GUID. The mock gives
+// every schema a GUID derived from its type and text, so the same schema
+// gets the same GUID across mocks and runs.
+func (mck MockSchemaRegistryClient) GetSchemaByGUID(guid string) (*Schema, error) {
+	thisSchema, ok := mck.guidCache[guid]
+	if !ok {
+		posErr := url.Error{
+			Op:  "GET",
+			URL: mck.schemaRegistryURL + fmt.Sprintf("/schemas/guids/%s", guid),
+			Err: errors.New("Schema GUID is not registered"),
+		}
+		return nil, &posErr
+	}
+	return thisSchema, nil
+}
+
 // GetLatestSchema returns the highest ordinal version of a Schema for a given `concrete subject`
 func (mck MockSchemaRegistryClient) GetLatestSchema(subject string, isKey bool) (*Schema, error) {
 	versions, getSchemaVersionErr := mck.GetSchemaVersions(subject, isKey)
@@ -118,10 +138,11 @@ func (mck MockSchemaRegistryClient) GetLatestSchema(subject string, isKey bool)
 }
 
 // GetSchemaVersions returns the array of versions this subject has previously registered
-func (mck MockSchemaRegistryClient) GetSchemaVersions(subject string, isKey bool) ([]int, error) {
+func (mck MockSchemaRegistryClient) GetSchemaVersions(subject string, isKey bool, opts ...ListOption) ([]int, error) {
 	concreteSubject := getConcreteSubject(subject, isKey)
 	versions := mck.allVersions(concreteSubject)
-	return versions, nil
+	start, end := newListOptions(opts).bounds(len(versions))
+	return versions[start:end], nil
 }
 
 // GetSchemaByVersion returns the given Schema according to the passed in subject and version number
@@ -155,18 +176,55 @@ func (mck MockSchemaRegistryClient) GetSchemaByVersion(subject string, version s
 	return schema, nil
 }
 
+// LookupSchema returns the version of the subject that has the given schema.
+func (mck MockSchemaRegistryClient) LookupSchema(subject string, schema string, schemaType SchemaType, isKey bool, references ...Reference) (*Schema, error) {
+	return mck.LookupSchemaWithOptions(subject, schema, schemaType, isKey, WithReferences(references...))
+}
+
+// LookupSchemaWithOptions looks the schema up like LookupSchema. With
+// WithNormalize, schemas are compared after being normalized, so
+// formatting differences are ignored.
+func (mck MockSchemaRegistryClient) LookupSchemaWithOptions(subject string, schema string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (*Schema, error) {
+	concreteSubject := getConcreteSubject(subject, isKey)
+	posErr := url.Error{
+		Op:  "POST",
+		URL: mck.schemaRegistryURL + fmt.Sprintf("/subjects/%s", concreteSubject),
+		Err: errors.New("Schema Not found"),
+	}
+
+	options := newSchemaOptions(opts)
+	wanted, err := mockSchemaText(schema, schemaType, options)
+	if err != nil {
+		return nil, err
+	}
+	for s := range mck.schemaCache[concreteSubject] {
+		if s.SchemaType() != schemaType {
+			continue
+		}
+		if registered, err := mockSchemaText(s.schema, schemaType, options); err == nil && registered == wanted {
+			return s, nil
+		}
+	}
+	return nil, &posErr
+}
+
 // GetSchemaBySubject returns the given Schema according to the passed in subject
 func (mck MockSchemaRegistryClient) GetSchemaBySubject(subject string, isKey bool) (*Schema, error) {
 	return mck.GetLatestSchema(subject, isKey)
 }
 
-// GetSubjects returns all registered subjects
-func (mck MockSchemaRegistryClient) GetSubjects() ([]string, error) {
+// GetSubjects returns all registered subjects, sorted by name
+// like Schema Registry does, honoring the given ListOptions.
+func (mck MockSchemaRegistryClient) GetSubjects(opts ...ListOption) ([]string, error) {
 	allSubjects := make([]string, 0, len(mck.schemaCache))
 	for subject := range mck.schemaCache {
 		allSubjects = append(allSubjects, subject)
 	}
-	return allSubjects, nil
+	sort.Strings(allSubjects)
+	options := newListOptions(opts)
+	allSubjects = options.filter(allSubjects)
+	start, end := options.bounds(len(allSubjects))
+	return allSubjects[start:end], nil
 }
 
 // DeleteSubject removes given subject from cache
@@ -202,6 +260,31 @@ func (mck MockSchemaRegistryClient) IsSchemaCompatible(subject, schema, version
 	return false, errors.New("mock schema registry client can't check for schema compatibility")
 }
 
+func (mck MockSchemaRegistryClient) IsSchemaCompatibleWithOptions(subject, schema, version string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (bool, error) {
+	return mck.IsSchemaCompatible(subject, schema, version, schemaType, isKey)
+}
+
+// CheckSchemaCompatibility checks the schema against all the versions of the subject
+// offline, using the BACKWARD compatibility level that Schema Registry defaults to.
+func (mck MockSchemaRegistryClient) CheckSchemaCompatibility(subject, schema string, schemaType SchemaType, isKey bool, references ...Reference) (*CompatibilityResult, error) {
+	concreteSubject := getConcreteSubject(subject, isKey)
+	var previous []string
+	for _, version := range mck.allVersions(concreteSubject) {
+		for s, v := range mck.schemaCache[concreteSubject] {
+			if v == version {
+				previous = append(previous, s.schema)
+			}
+		}
+	}
+	return CheckCompatibility(schemaType, Backward, schema, previous...)
+}
+
+// CheckSchemaCompatibilityWithOptions checks the schema like CheckSchemaCompatibility,
+// which compares schemas structurally, so normalizing them changes nothing.
+func (mck MockSchemaRegistryClient) CheckSchemaCompatibilityWithOptions(subject, schema string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (*CompatibilityResult, error) {
+	return mck.CheckSchemaCompatibility(subject, schema, schemaType, isKey, newSchemaOptions(opts).references...)
+}
+
 /*
 These classes are written as helpers and therefore, are not exported.
 generateVersion will register a new version of the schema passed, it will NOT do any checks
@@ -210,7 +293,7 @@ handled beforehand by the environment.
 allVersions returns an ordered int[] with all versions for a given subject. It does NOT
 qualify for key/value subjects, it expects to have a `concrete subject` passed on to do the checks.
 */
-func (mck MockSchemaRegistryClient) generateVersion(subject string, schema string) *Schema {
+func (mck MockSchemaRegistryClient) generateVersion(subject string, schema string, schemaType SchemaType) *Schema {
 	versions := mck.allVersions(subject)
 	schemaVersionMap := map[*Schema]int{}
 	var currentVersion int
@@ -222,19 +305,52 @@ func (mck MockSchemaRegistryClient) generateVersion(subject string, schema strin
 	}
 
 	schemaToRegister := Schema{
-		id:      mck.ids.ids,
-		schema:  schema,
-		version: currentVersion,
-		codec:   nil,
+		id:         mck.ids.ids,
+		guid:       mockGUID(schema, schemaType),
+		schema:     schema,
+		schemaType: schemaType,
+		version:    currentVersion,
+		codec:      nil,
 	}
 
 	schemaVersionMap[&schemaToRegister] = currentVersion
 	mck.schemaCache[subject] = schemaVersionMap
 	mck.idCache[mck.ids.ids] = &schemaToRegister
+	if _, ok := mck.guidCache[schemaToRegister.guid]; !ok {
+		mck.guidCache[schemaToRegister.guid] = &schemaToRegister
+	}
 
 	return &schemaToRegister
 }
 
+// mockSchemaText returns the text the mock stores for a schema, which
+// is normalized when the options ask for it, like Schema Registry does.
+func mockSchemaText(schema string, schemaType SchemaType, options *schemaOptions) (string, error) {
+	switch schemaType {
+	case Avro, Json:
+		compiledRegex := regexp.MustCompile(`\r?\n`)
+		schema = compiledRegex.ReplaceAllString(schema, " ")
+	case Protobuf:
+		break
+	default:
+		return "", fmt.Errorf("invalid schema type. valid values are Avro, Json, or Protobuf")
+	}
+	if options.normalize {
+		return NormalizeSchema(schema, schemaType)
+	}
+	return schema, nil
+}
+
+// mockGUID derives a version 4 style GUID from the type and text of a
+// schema, so that mocks give the same schemas the same GUIDs.
+func mockGUID(schema string, schemaType SchemaType) string {
+	sum := sha256.Sum256([]byte(schemaType.String() + "\x00" + schema))
+	guid := sum[:16]
+	guid[6] = guid[6]&0x0f | 0x40
+	guid[8] = guid[8]&0x3f | 0x80
+	return formatUUID(guid)
+}
+
 func (mck MockSchemaRegistryClient) allVersions(subject string) []int {
 	versions := []int{}
 	result, ok := mck.schemaCache[subject]
@@ -111,3 +111,93 @@ func TestMockSchemaRegistryClient_GetSubjects(t *testing.T) {
 	sort.Strings(allSubjects)
 	assert.Equal(t, allSubjects, []string{"test1-key", "test1-value"})
 }
+
+func TestMockSchemaRegistryClient_GetSubjectsWithListOptions(t *testing.T) {
+	keySubjects, _ := srClient.GetSubjects(WithSubjectPrefix("test1-k"))
+	assert.Equal(t, []string{"test1-key"}, keySubjects)
+
+	secondPage, _ := srClient.GetSubjects(WithOffset(1), WithLimit(1))
+	assert.Equal(t, []string{"test1-value"}, secondPage)
+
+	versions, _ := srClient.GetSchemaVersions("test1", false, WithOffset(1))
+	assert.Equal(t, []int{2}, versions)
+}
+
+func TestMockSchemaRegistryClient_Iterators(t *testing.T) {
+	var subjects []string
+	subjectIterator := NewSubjectIterator(srClient, 1)
+	for subjectIterator.Next() {
+		subjects = append(subjects, subjectIterator.Subject())
+	}
+	assert.NoError(t, subjectIterator.Err())
+	assert.Equal(t, []string{"test1-key", "test1-value"}, subjects)
+
+	var versions []int
+	versionIterator := NewVersionIterator(srClient, "test1", true, 1)
+	for versionIterator.Next() {
+		versions = append(versions, versionIterator.Version())
+		schema, err := versionIterator.Schema()
+		assert.NoError(t, err)
+		assert.Equal(t, versionIterator.Version(), schema.Version())
+	}
+	assert.NoError(t, versionIterator.Err())
+	assert.Equal(t, []int{1, 2}, versions)
+}
+
+func TestMockSchemaRegistryClient_CheckSchemaCompatibility(t *testing.T) {
+	// schema2 renames aField to bField, so a schema with
+	// aField only can't read data written with schema2.
+	result, err := srClient.CheckSchemaCompatibility("test1", schema, Avro, false)
+	assert.NoError(t, err)
+	assert.False(t, result.IsCompatible)
+	assert.Equal(t, ReaderFieldMissingDefaultValue, result.Incompatibilities[0].Type)
+
+	result, err = srClient.CheckSchemaCompatibility("test1", schema2, Avro, false)
+	assert.NoError(t, err)
+	assert.True(t, result.IsCompatible)
+}
+
+func TestMockSchemaRegistryClient_LookupSchema(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("lookup", schema, Avro, false)
+	assert.NoError(t, err)
+
+	// Same schema, with its attributes in another order.
+	reordered := `{"name": "com.mycorp.mynamespace.value_cdc_fake_2", "type": "record",
+		"doc": "Sample schema to help you get started.",
+		"fields": [{"type": "int", "name": "aField", "doc": "The int type is a 32-bit signed integer."}]}`
+	_, err = mockClient.LookupSchema("lookup", reordered, Avro, false)
+	assert.Error(t, err)
+	found, err := mockClient.LookupSchemaWithOptions("lookup", reordered, Avro, false, WithNormalize())
+	assert.NoError(t, err)
+	assert.Equal(t, registered.ID(), found.ID())
+	found, err = mockClient.LookupSchema("lookup", schema, Avro, false)
+	assert.NoError(t, err)
+	assert.Equal(t, registered.ID(), found.ID())
+
+	_, err = mockClient.LookupSchema("lookup", schema2, Avro, false)
+	assert.Error(t, err)
+}
+
+func TestMockSchemaRegistryClient_GetSchemaByGUID(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("guid", schema, Avro, false)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, registered.GUID())
+
+	found, err := mockClient.GetSchemaByGUID(registered.GUID())
+	assert.NoError(t, err)
+	assert.Equal(t, registered.ID(), found.ID())
+
+	// GUIDs only depend on the schema.
+	other := CreateMockSchemaRegistryClient("mock://testingUrl")
+	again, err := other.CreateSchema("another", schema, Avro, true)
+	assert.NoError(t, err)
+	assert.Equal(t, registered.GUID(), again.GUID())
+	different, err := other.CreateSchema("guid", schema2, Avro, false)
+	assert.NoError(t, err)
+	assert.NotEqual(t, registered.GUID(), different.GUID())
+
+	_, err = mockClient.GetSchemaByGUID(different.GUID())
+	assert.Error(t, err)
+}
@@ -0,0 +1,327 @@
+package srclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// NormalizeSchema rewrites a schema in a normalized form, so that schemas
+// that are semantically identical but formatted differently are registered
+// and looked up as the same schema. Nothing that affects the meaning of the
+// schema is dropped:
+//
+//   - Avro and JSON schemas are written as compact JSON with sorted keys,
+//     and Avro names are fully qualified while primitive types written as
+//     objects, like {"type": "int"}, are collapsed into their name.
+//   - Protobuf schemas are reformatted, sorting imports and options and
+//     fully qualifying the types of fields.
+func NormalizeSchema(schema string, schemaType SchemaType) (string, error) {
+	switch schemaType {
+	case Avro:
+		return normalizeAvro(schema)
+	case Json:
+		return normalizeJSON(schema)
+	case Protobuf:
+		file, err := parseProtobufSchema(schema)
+		if err != nil {
+			return "", err
+		}
+		return file.normalized(), nil
+	default:
+		return "", fmt.Errorf("invalid schema type. valid values are Avro, Json, or Protobuf")
+	}
+}
+
+func decodeSchemaJSON(schema string) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewBufferString(schema))
+	decoder.UseNumber()
+	var raw interface{}
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+func normalizeJSON(schema string) (string, error) {
+	raw, err := decodeSchemaJSON(schema)
+	if err != nil {
+		return "", fmt.Errorf("invalid json schema: %w", err)
+	}
+	return canonicalJSON(raw), nil
+}
+
+func normalizeAvro(schema string) (string, error) {
+	// Parsing first validates the schema and collects its named types,
+	// which are needed to know how references have to be qualified.
+	parsed, err := parseAvroSchema(schema)
+	if err != nil {
+		return "", err
+	}
+	names := avroNames{}
+	collectAvroNames(parsed, names)
+
+	raw, err := decodeSchemaJSON(schema)
+	if err != nil {
+		return "", err
+	}
+	return canonicalJSON(names.normalize(raw, "")), nil
+}
+
+func collectAvroNames(t *avroType, names avroNames) {
+	if t.isNamed() {
+		if _, seen := names[t.fullName()]; seen {
+			return
+		}
+		names[t.fullName()] = t
+	}
+	for _, field := range t.fields {
+		collectAvroNames(field.typ, names)
+	}
+	for _, branch := range t.branches {
+		collectAvroNames(branch, names)
+	}
+	if t.items != nil {
+		collectAvroNames(t.items, names)
+	}
+	if t.values != nil {
+		collectAvroNames(t.values, names)
+	}
+}
+
+func (names avroNames) qualify(name, namespace string) string {
+	if avroPrimitives[name] || strings.Contains(name, ".") || len(namespace) == 0 {
+		return name
+	}
+	if _, ok := names[namespace+"."+name]; ok {
+		return namespace + "." + name
+	}
+	return name
+}
+
+func (names avroNames) normalize(raw interface{}, namespace string) interface{} {
+	switch value := raw.(type) {
+	case string:
+		return names.qualify(value, namespace)
+	case []interface{}:
+		branches := make([]interface{}, len(value))
+		for i, branch := range value {
+			branches[i] = names.normalize(branch, namespace)
+		}
+		return branches
+	case map[string]interface{}:
+		typ, _ := value["type"].(string)
+		if len(value) == 1 && avroPrimitives[typ] {
+			return typ
+		}
+		object := make(map[string]interface{}, len(value))
+		for key, attribute := range value {
+			object[key] = attribute
+		}
+		switch typ {
+		case "record", "error", "enum", "fixed":
+			name, _ := object["name"].(string)
+			if ns, ok := object["namespace"].(string); ok {
+				namespace = ns
+			}
+			if i := strings.LastIndex(name, "."); i >= 0 {
+				namespace = name[:i]
+			} else if len(namespace) > 0 {
+				name = namespace + "." + name
+			}
+			object["name"] = name
+			delete(object, "namespace")
+			if aliases, ok := object["aliases"].([]interface{}); ok {
+				qualified := make([]interface{}, len(aliases))
+				for i, alias := range aliases {
+					a, _ := alias.(string)
+					if !strings.Contains(a, ".") && len(namespace) > 0 {
+						a = namespace + "." + a
+					}
+					qualified[i] = a
+				}
+				object["aliases"] = qualified
+			}
+			if fields, ok := object["fields"].([]interface{}); ok {
+				normalized := make([]interface{}, len(fields))
+				for i, rawField := range fields {
+					field, ok := rawField.(map[string]interface{})
+					if !ok {
+						normalized[i] = rawField
+						continue
+					}
+					copied := make(map[string]interface{}, len(field))
+					for key, attribute := range field {
+						copied[key] = attribute
+					}
+					copied["type"] = names.normalize(field["type"], namespace)
+					normalized[i] = copied
+				}
+				object["fields"] = normalized
+			}
+		case "array":
+			object["items"] = names.normalize(object["items"], namespace)
+		case "map":
+			object["values"] = names.normalize(object["values"], namespace)
+		default:
+			object["type"] = names.normalize(object["type"], namespace)
+		}
+		return object
+	default:
+		return raw
+	}
+}
+
+// normalized prints the file in a canonical layout.
+func (file *protoFile) normalized() string {
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "syntax = %q;\n", file.syntax)
+	if len(file.pkg) > 0 {
+		fmt.Fprintf(&builder, "package %s;\n", file.pkg)
+	}
+	if len(file.imports) > 0 {
+		builder.WriteString("\n")
+		imports := append([]string{}, file.imports...)
+		sort.Strings(imports)
+		for _, imported := range imports {
+			fmt.Fprintf(&builder, "import %q;\n", imported)
+		}
+	}
+	if len(file.options) > 0 {
+		builder.WriteString("\n")
+		writeProtoOptions(&builder, "", file.options)
+	}
+	for _, message := range file.messages {
+		builder.WriteString("\n")
+		writeProtoMessage(&builder, "", message)
+	}
+	for _, enum := range file.enums {
+		builder.WriteString("\n")
+		writeProtoEnum(&builder, "", enum)
+	}
+	for _, service := range file.services {
+		builder.WriteString("\n")
+		fmt.Fprintf(&builder, "service %s {\n", service.name)
+		writeProtoOptions(&builder, "  ", service.options)
+		for _, method := range service.methods {
+			request, response := method.request, method.response
+			if method.clientStreams {
+				request = "stream " + request
+			}
+			if method.serverStreams {
+				response = "stream " + response
+			}
+			fmt.Fprintf(&builder, "  rpc %s(%s) returns (%s)", method.name, request, response)
+			if len(method.options) == 0 {
+				builder.WriteString(";\n")
+				continue
+			}
+			builder.WriteString(" {\n")
+			writeProtoOptions(&builder, "    ", method.options)
+			builder.WriteString("  }\n")
+		}
+		builder.WriteString("}\n")
+	}
+	return builder.String()
+}
+
+func writeProtoOptions(builder *strings.Builder, indent string, options []string) {
+	sorted := append([]string{}, options...)
+	sort.Strings(sorted)
+	for _, option := range sorted {
+		fmt.Fprintf(builder, "%soption %s;\n", indent, option)
+	}
+}
+
+func writeProtoMessage(builder *strings.Builder, indent string, message *protoMessage) {
+	fmt.Fprintf(builder, "%smessage %s {\n", indent, message.name)
+	inner := indent + "  "
+	writeProtoOptions(builder, inner, message.options)
+	for _, reserved := range message.reserved {
+		fmt.Fprintf(builder, "%sreserved %s;\n", inner, reserved)
+	}
+
+	// Fields are written by number, and each oneof where its first field is.
+	fields := append([]*protoField{}, message.fields...)
+	sort.SliceStable(fields, func(i, j int) bool { return fields[i].number < fields[j].number })
+	written := map[string]bool{}
+	for _, field := range fields {
+		if len(field.oneof) == 0 {
+			writeProtoField(builder, inner, field)
+			continue
+		}
+		if written[field.oneof] {
+			continue
+		}
+		written[field.oneof] = true
+		fmt.Fprintf(builder, "%soneof %s {\n", inner, field.oneof)
+		for _, oneof := range message.oneofs {
+			if oneof.name == field.oneof {
+				writeProtoOptions(builder, inner+"  ", oneof.options)
+			}
+		}
+		for _, member := range fields {
+			if member.oneof == field.oneof {
+				writeProtoField(builder, inner+"  ", member)
+			}
+		}
+		fmt.Fprintf(builder, "%s}\n", inner)
+	}
+
+	for _, nested := range message.messages {
+		writeProtoMessage(builder, inner, nested)
+	}
+	for _, enum := range message.enums {
+		writeProtoEnum(builder, inner, enum)
+	}
+	fmt.Fprintf(builder, "%s}\n", indent)
+}
+
+func writeProtoField(builder *strings.Builder, indent string, field *protoField) {
+	builder.WriteString(indent)
+	if len(field.label) > 0 {
+		builder.WriteString(field.label + " ")
+	}
+	switch field.kind {
+	case "map":
+		value := field.mapValue
+		if !protoScalars[value] {
+			value = "." + field.fullType
+		}
+		fmt.Fprintf(builder, "map<%s, %s>", field.mapKey, value)
+	case "scalar":
+		builder.WriteString(field.typ)
+	default:
+		builder.WriteString("." + field.fullType)
+	}
+	fmt.Fprintf(builder, " %s = %s", field.name, strconv.Itoa(field.number))
+	writeProtoOptionList(builder, field.options)
+	builder.WriteString(";\n")
+}
+
+func writeProtoOptionList(builder *strings.Builder, options []string) {
+	if len(options) == 0 {
+		return
+	}
+	sorted := append([]string{}, options...)
+	sort.Strings(sorted)
+	fmt.Fprintf(builder, " [%s]", strings.Join(sorted, ", "))
+}
+
+func writeProtoEnum(builder *strings.Builder, indent string, enum *protoEnum) {
+	fmt.Fprintf(builder, "%senum %s {\n", indent, enum.name)
+	inner := indent + "  "
+	writeProtoOptions(builder, inner, enum.options)
+	for _, reserved := range enum.reserved {
+		fmt.Fprintf(builder, "%sreserved %s;\n", inner, reserved)
+	}
+	for _, value := range enum.values {
+		fmt.Fprintf(builder, "%s%s = %d", inner, value.name, value.number)
+		writeProtoOptionList(builder, value.options)
+		builder.WriteString(";\n")
+	}
+	fmt.Fprintf(builder, "%s}\n", indent)
+}
@@ -0,0 +1,104 @@
+package srclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeSchema_Avro(t *testing.T) {
+	first := `{
+		"type": "record", "name": "User", "namespace": "com.example",
+		"fields": [
+			{"name": "id", "type": {"type": "long"}},
+			{"name": "address", "type": {"type": "record", "name": "Address", "fields": [{"name": "city", "type": "string"}]}},
+			{"name": "previous", "type": ["null", "Address"], "default": null}
+		]
+	}`
+	second := `{"namespace":"com.example","name":"com.example.User","type":"record","fields":[
+		{"type":"long","name":"id"},
+		{"name":"address","type":{"name":"com.example.Address","type":"record","fields":[{"type":"string","name":"city"}]}},
+		{"default":null,"name":"previous","type":["null","com.example.Address"]}]}`
+
+	normalized, err := NormalizeSchema(first, Avro)
+	assert.NoError(t, err)
+	other, err := NormalizeSchema(second, Avro)
+	assert.NoError(t, err)
+	assert.Equal(t, normalized, other)
+	assert.Equal(t, `{"fields":[{"name":"id","type":"long"},`+
+		`{"name":"address","type":{"fields":[{"name":"city","type":"string"}],"name":"com.example.Address","type":"record"}},`+
+		`{"default":null,"name":"previous","type":["null","com.example.Address"]}],"name":"com.example.User","type":"record"}`, normalized)
+
+	// Attributes that change the meaning of the schema are kept.
+	withDoc, err := NormalizeSchema(`{"type": "long", "logicalType": "timestamp-millis"}`, Avro)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"logicalType":"timestamp-millis","type":"long"}`, withDoc)
+
+	_, err = NormalizeSchema(`{"type": "record"`, Avro)
+	assert.Error(t, err)
+}
+
+func TestNormalizeSchema_Json(t *testing.T) {
+	normalized, err := NormalizeSchema(`{
+		"type": "object",
+		"properties": {"b": {"type": "number", "maximum": 1.50}, "a": {"type": "string"}}
+	}`, Json)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"properties":{"a":{"type":"string"},"b":{"maximum":1.50,"type":"number"}},"type":"object"}`, normalized)
+}
+
+func TestNormalizeSchema_Protobuf(t *testing.T) {
+	first := `
+syntax = "proto3";
+package com.example;
+import "b.proto";
+import "a.proto";
+option java_package = "com.example";
+option go_package = "example";
+
+message User {
+  Address address = 2;
+  string name = 1 [deprecated = true];
+  oneof contact {
+    string phone = 4;
+    string email = 3;
+  }
+  message Address {
+    string city = 1;
+  }
+}
+`
+	second := `syntax = "proto3"; package com.example;
+import "a.proto"; import "b.proto";
+option go_package = "example"; option java_package = "com.example";
+message User { string name = 1 [deprecated = true]; .com.example.User.Address address = 2;
+  oneof contact { string email = 3; string phone = 4; }
+  message Address { string city = 1; } }`
+
+	normalized, err := NormalizeSchema(first, Protobuf)
+	assert.NoError(t, err)
+	other, err := NormalizeSchema(second, Protobuf)
+	assert.NoError(t, err)
+	assert.Equal(t, normalized, other)
+	assert.Equal(t, `syntax = "proto3";
+package com.example;
+
+import "a.proto";
+import "b.proto";
+
+option go_package = "example";
+option java_package = "com.example";
+
+message User {
+  string name = 1 [deprecated = true];
+  .com.example.User.Address address = 2;
+  oneof contact {
+    string email = 3;
+    string phone = 4;
+  }
+  message Address {
+    string city = 1;
+  }
+}
+`, normalized)
+}
@@ -0,0 +1,433 @@
+package srclient
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+// Compression codecs of Avro Object Container Files.
+const (
+	OCFNullCompression    = "null"
+	OCFDeflateCompression = "deflate"
+)
+
+// Metadata keys that tie an Object Container File to the registry.
+const (
+	OCFSchemaIDKey = "schema.registry.id"
+	OCFSubjectKey  = "schema.registry.subject"
+)
+
+const (
+	ocfSchemaKey = "avro.schema"
+	ocfCodecKey  = "avro.codec"
+
+	// defaultOCFBlockLength is the number of records
+	// written per block unless configured otherwise.
+	defaultOCFBlockLength = 1000
+
+	// maxOCFBytesLength caps the length of the blocks and metadata
+	// read from files, which is read before their content.
+	maxOCFBytesLength = 1 << 30
+)
+
+var ocfMagic = []byte{'O', 'b', 'j', 1}
+
+// ErrInvalidOCF is returned when data doesn't start with
+// the header of an Avro Object Container File.
+var ErrInvalidOCF = errors.New("data is not an avro object container file")
+
+// OCFWriter writes records to an Avro Object Container File whose
+// metadata records the ID of the schema in the registry, and its
+// subject, so the file can be read back with the registry schema by
+// OCFReader. Records are encoded with a Serializer, so they can be
+// structs and logical types are mapped the way it is configured to.
+type OCFWriter struct {
+	writer      io.Writer
+	serializer  *Serializer
+	schema      *Schema
+	compression string
+	blockLength int
+	metadata    map[string][]byte
+	sync        [16]byte
+
+	block []byte
+	count int
+}
+
+// OCFWriterOption configures an OCFWriter.
+type OCFWriterOption func(*OCFWriter)
+
+// WithOCFCompression sets the codec that compresses the blocks of
+// the file, OCFNullCompression (the default) or OCFDeflateCompression.
+func WithOCFCompression(compression string) OCFWriterOption {
+	return OCFWriterOption(func(writer *OCFWriter) {
+		writer.compression = compression
+	})
+}
+
+// WithOCFBlockLength sets the number of records written per block.
+func WithOCFBlockLength(records int) OCFWriterOption {
+	return OCFWriterOption(func(writer *OCFWriter) {
+		writer.blockLength = records
+	})
+}
+
+// WithOCFMetadata adds application specific metadata to the file.
+// Keys starting with "avro." are reserved by the specification.
+func WithOCFMetadata(key string, value []byte) OCFWriterOption {
+	return OCFWriterOption(func(writer *OCFWriter) {
+		writer.metadata[key] = value
+	})
+}
+
+// NewOCFWriter writes the header of an Object Container File for the
+// given Avro schema, which comes from the registry, and returns a writer
+// for its records. The subject of the schema is recorded unless empty.
+func NewOCFWriter(w io.Writer, serializer *Serializer, schema *Schema, subject string, opts ...OCFWriterOption) (*OCFWriter, error) {
+	if schema.SchemaType() != Avro {
+		return nil, fmt.Errorf("schema %d is a %s schema, which can't be written to avro files", schema.ID(), schema.SchemaType())
+	}
+	writer := &OCFWriter{
+		writer:      w,
+		serializer:  serializer,
+		schema:      schema,
+		compression: OCFNullCompression,
+		blockLength: defaultOCFBlockLength,
+		metadata:    map[string][]byte{},
+	}
+	for _, opt := range opts {
+		opt(writer)
+	}
+	if writer.compression != OCFNullCompression && writer.compression != OCFDeflateCompression {
+		return nil, fmt.Errorf("unsupported avro file compression %s", writer.compression)
+	}
+	for key := range writer.metadata {
+		if strings.HasPrefix(key, "avro.") || key == OCFSchemaIDKey || key == OCFSubjectKey {
+			return nil, fmt.Errorf("the metadata key %s is reserved", key)
+		}
+	}
+	if writer.blockLength <= 0 {
+		writer.blockLength = defaultOCFBlockLength
+	}
+
+	writer.metadata[ocfSchemaKey] = []byte(schema.Schema())
+	writer.metadata[ocfCodecKey] = []byte(writer.compression)
+	writer.metadata[OCFSchemaIDKey] = []byte(strconv.Itoa(schema.ID()))
+	if len(subject) > 0 {
+		writer.metadata[OCFSubjectKey] = []byte(subject)
+	}
+	if _, err := rand.Read(writer.sync[:]); err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{}, ocfMagic...)
+	header = appendAvroLong(header, int64(len(writer.metadata)))
+	for key, value := range writer.metadata {
+		header = appendAvroBytes(header, []byte(key))
+		header = appendAvroBytes(header, value)
+	}
+	header = appendAvroLong(header, 0)
+	header = append(header, writer.sync[:]...)
+	if _, err := w.Write(header); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+// Append adds records to the file, writing a block
+// each time enough records have been appended.
+func (writer *OCFWriter) Append(values ...interface{}) error {
+	for _, value := range values {
+		block, err := writer.serializer.appendAvro(writer.block, writer.schema, value)
+		if err != nil {
+			return err
+		}
+		writer.block = block
+		writer.count++
+		if writer.count >= writer.blockLength {
+			if err := writer.Flush(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Flush writes the records appended so far as a block.
+func (writer *OCFWriter) Flush() error {
+	if writer.count == 0 {
+		return nil
+	}
+	data := writer.block
+	if writer.compression == OCFDeflateCompression {
+		compressed := &bytes.Buffer{}
+		deflater, err := flate.NewWriter(compressed, flate.DefaultCompression)
+		if err != nil {
+			return err
+		}
+		if _, err := deflater.Write(data); err != nil {
+			return err
+		}
+		if err := deflater.Close(); err != nil {
+			return err
+		}
+		data = compressed.Bytes()
+	}
+
+	block := appendAvroLong(nil, int64(writer.count))
+	block = appendAvroLong(block, int64(len(data)))
+	block = append(block, data...)
+	block = append(block, writer.sync[:]...)
+	if _, err := writer.writer.Write(block); err != nil {
+		return err
+	}
+	writer.block, writer.count = writer.block[:0], 0
+	return nil
+}
+
+// Close writes the records that are left. It doesn't
+// close the io.Writer the file is written to.
+func (writer *OCFWriter) Close() error {
+	return writer.Flush()
+}
+
+// OCFReader reads the records of an Avro Object Container File written
+// with the metadata of OCFWriter, fetching the schema it names from the
+// registry. Records are decoded with a Deserializer, so they can be
+// resolved into its reader schema and read into structs. Use it like a
+// bufio.Scanner:
+//
+//	reader, err := srclient.NewOCFReader(file, deserializer)
+//	...
+//	for reader.Next() {
+//		var order Order
+//		if err := reader.Decode(&order); err != nil {
+//			...
+//		}
+//	}
+//	if err := reader.Err(); err != nil {
+//		...
+//	}
+type OCFReader struct {
+	reader       *bufio.Reader
+	deserializer *Deserializer
+	schema       *Schema
+	metadata     map[string][]byte
+	compression  string
+	sync         [16]byte
+	decoding     *recordDecoding
+
+	block     []byte
+	remaining int64
+	current   interface{}
+	err       error
+}
+
+// NewOCFReader reads the header of an Object Container File and
+// fetches the schema recorded in its metadata with the client of the
+// Deserializer. The schema must match the one embedded in the file.
+func NewOCFReader(r io.Reader, deserializer *Deserializer) (*OCFReader, error) {
+	reader := &OCFReader{reader: bufio.NewReader(r), deserializer: deserializer, metadata: map[string][]byte{}}
+	magic := make([]byte, len(ocfMagic))
+	if _, err := io.ReadFull(reader.reader, magic); err != nil || !bytes.Equal(magic, ocfMagic) {
+		return nil, ErrInvalidOCF
+	}
+	err := readOCFBlocks(reader.reader, func() error {
+		key, err := readOCFBytes(reader.reader)
+		if err != nil {
+			return err
+		}
+		value, err := readOCFBytes(reader.reader)
+		reader.metadata[string(key)] = value
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid avro file metadata: %w", err)
+	}
+	if _, err := io.ReadFull(reader.reader, reader.sync[:]); err != nil {
+		return nil, fmt.Errorf("invalid avro file header: %w", err)
+	}
+
+	reader.compression = string(reader.metadata[ocfCodecKey])
+	switch reader.compression {
+	case "":
+		reader.compression = OCFNullCompression
+	case OCFNullCompression, OCFDeflateCompression:
+	default:
+		return nil, fmt.Errorf("unsupported avro file compression %s", reader.compression)
+	}
+
+	rawID, ok := reader.metadata[OCFSchemaIDKey]
+	if !ok {
+		return nil, fmt.Errorf("the avro file has no %s metadata", OCFSchemaIDKey)
+	}
+	schemaID, err := strconv.Atoi(string(rawID))
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s metadata %q", OCFSchemaIDKey, rawID)
+	}
+	if reader.schema, err = deserializer.client.GetSchemaByID(schemaID); err != nil {
+		return nil, err
+	}
+	if reader.schema.SchemaType() != Avro {
+		return nil, fmt.Errorf("schema %d is a %s schema, not the schema of an avro file", schemaID, reader.schema.SchemaType())
+	}
+	// Schemas with references can't be compared on their own.
+	registered, errRegistered := reader.schema.CanonicalForm()
+	embedded, errEmbedded := AvroCanonicalForm(string(reader.metadata[ocfSchemaKey]))
+	if errRegistered == nil && errEmbedded == nil && registered != embedded {
+		return nil, fmt.Errorf("the schema of the avro file doesn't match schema %d", schemaID)
+	}
+	if reader.decoding, err = deserializer.decodingFor(schemaID, reader.schema); err != nil {
+		return nil, err
+	}
+	return reader, nil
+}
+
+// Schema returns the registry schema the file was written with.
+func (reader *OCFReader) Schema() *Schema {
+	return reader.schema
+}
+
+// Subject returns the subject recorded in the file, if any.
+func (reader *OCFReader) Subject() string {
+	return string(reader.metadata[OCFSubjectKey])
+}
+
+// Metadata returns all the metadata of the file.
+func (reader *OCFReader) Metadata() map[string][]byte {
+	return reader.metadata
+}
+
+// Next decodes the next record, reading a new block when needed.
+// It returns false at the end of the file or on error, see Err.
+func (reader *OCFReader) Next() bool {
+	if reader.err != nil {
+		return false
+	}
+	for reader.remaining == 0 {
+		if !reader.readBlock() {
+			return false
+		}
+	}
+	native, rest, err := reader.decoding.decoder.NativeFromBinary(reader.block)
+	if err != nil {
+		reader.err = fmt.Errorf("unable to decode record written with schema %d: %w", reader.schema.ID(), err)
+		return false
+	}
+	reader.current, reader.block = native, rest
+	reader.remaining--
+	return true
+}
+
+// Value returns the native values of the current record.
+func (reader *OCFReader) Value() interface{} {
+	return reader.current
+}
+
+// Decode stores the current record into the value v points to,
+// like Deserializer.DeserializeInto does.
+func (reader *OCFReader) Decode(v interface{}) error {
+	return reader.deserializer.mapInto(reader.decoding, reader.current, v)
+}
+
+// Err returns the first error that stopped the reading, if any.
+func (reader *OCFReader) Err() error {
+	return reader.err
+}
+
+func (reader *OCFReader) readBlock() bool {
+	count, err := binary.ReadVarint(reader.reader)
+	if err == io.EOF {
+		return false
+	}
+	if err == nil && count < 0 {
+		err = fmt.Errorf("negative record count %d", count)
+	}
+	var data []byte
+	if err == nil {
+		data, err = readOCFBytes(reader.reader)
+	}
+	// Records take at least a byte, unless they are empty,
+	// such as nulls, which are bounded like Avro arrays.
+	if err == nil && count > int64(len(data)) && count > maxAvroBlockCount {
+		err = fmt.Errorf("record count %d exceeds the maximum of %d records", count, maxAvroBlockCount)
+	}
+	var sync [16]byte
+	if err == nil {
+		_, err = io.ReadFull(reader.reader, sync[:])
+	}
+	if err == nil && sync != reader.sync {
+		err = errors.New("sync marker mismatch")
+	}
+	if err == nil && reader.compression == OCFDeflateCompression {
+		data, err = ioutil.ReadAll(flate.NewReader(bytes.NewReader(data)))
+	}
+	if err != nil {
+		reader.err = fmt.Errorf("invalid avro file block: %w", err)
+		return false
+	}
+	reader.block, reader.remaining = data, count
+	return true
+}
+
+// readOCFBlocks reads the items of an Avro map or array from a stream.
+func readOCFBlocks(r *bufio.Reader, item func() error) error {
+	var total int64
+	for {
+		count, err := binary.ReadVarint(r)
+		if err != nil {
+			return err
+		}
+		if count == 0 {
+			return nil
+		}
+		if count < 0 {
+			// Negative counts are followed by the size of the block.
+			count = -count
+			if _, err := binary.ReadVarint(r); err != nil {
+				return err
+			}
+		}
+		total += count
+		if count < 0 || total > maxAvroBlockCount {
+			return fmt.Errorf("block count %d exceeds the maximum of %d items", count, maxAvroBlockCount)
+		}
+		for i := int64(0); i < count; i++ {
+			if err := item(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func readOCFBytes(r *bufio.Reader) ([]byte, error) {
+	size, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if size < 0 {
+		return nil, fmt.Errorf("negative length %d", size)
+	}
+	if size > maxOCFBytesLength {
+		return nil, fmt.Errorf("length %d exceeds the maximum of %d bytes", size, maxOCFBytesLength)
+	}
+	// The buffer grows with the data actually read,
+	// which may be much shorter than announced.
+	var value bytes.Buffer
+	if _, err := io.CopyN(&value, r, size); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return value.Bytes(), nil
+}
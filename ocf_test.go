@@ -0,0 +1,134 @@
+package srclient
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOCF_RoundTrip(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("native", schema, Avro, false)
+	assert.NoError(t, err)
+
+	for _, compression := range []string{OCFNullCompression, OCFDeflateCompression} {
+		file := &bytes.Buffer{}
+		writer, err := NewOCFWriter(file, NewSerializer(mockClient), registered, "native-value",
+			WithOCFCompression(compression), WithOCFBlockLength(2), WithOCFMetadata("exported.by", []byte("tests")))
+		assert.NoError(t, err)
+		type record struct {
+			A int32 `avro:"aField"`
+		}
+		assert.NoError(t, writer.Append(record{A: 1}, &record{A: 2}, map[string]interface{}{"aField": 3}))
+		assert.NoError(t, writer.Close())
+
+		// Other readers see a regular container file.
+		ocfReader, err := goavro.NewOCFReader(bytes.NewReader(file.Bytes()))
+		assert.NoError(t, err)
+		assert.Equal(t, compression, ocfReader.CompressionName())
+		assert.Equal(t, "1", string(ocfReader.MetaData()[OCFSchemaIDKey]))
+		count := 0
+		for ocfReader.Scan() {
+			_, err := ocfReader.Read()
+			assert.NoError(t, err)
+			count++
+		}
+		assert.Equal(t, 3, count)
+
+		deserializer, err := NewDeserializer(mockClient)
+		assert.NoError(t, err)
+		reader, err := NewOCFReader(bytes.NewReader(file.Bytes()), deserializer)
+		assert.NoError(t, err)
+		assert.Equal(t, registered.ID(), reader.Schema().ID())
+		assert.Equal(t, "native-value", reader.Subject())
+		assert.Equal(t, "tests", string(reader.Metadata()["exported.by"]))
+		var values []int32
+		for reader.Next() {
+			var decoded record
+			assert.NoError(t, reader.Decode(&decoded))
+			assert.Equal(t, map[string]interface{}{"aField": decoded.A}, reader.Value())
+			values = append(values, decoded.A)
+		}
+		assert.NoError(t, reader.Err())
+		assert.Equal(t, []int32{1, 2, 3}, values)
+	}
+}
+
+func TestOCF_Invalid(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("native", schema, Avro, false)
+	assert.NoError(t, err)
+	other, err := mockClient.CreateSchema("other", `{"type": "string"}`, Avro, false)
+	assert.NoError(t, err)
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+
+	_, err = NewOCFWriter(&bytes.Buffer{}, NewSerializer(mockClient), registered, "", WithOCFCompression("snappy"))
+	assert.Error(t, err)
+	_, err = NewOCFWriter(&bytes.Buffer{}, NewSerializer(mockClient), registered, "", WithOCFMetadata("avro.codec", nil))
+	assert.Error(t, err)
+
+	_, err = NewOCFReader(bytes.NewReader([]byte("not a file")), deserializer)
+	assert.Equal(t, ErrInvalidOCF, err)
+
+	// Files written without the registry metadata can't be read.
+	file := &bytes.Buffer{}
+	ocfWriter, err := goavro.NewOCFWriter(goavro.OCFConfig{W: file, Schema: schema})
+	assert.NoError(t, err)
+	assert.NoError(t, ocfWriter.Append([]interface{}{map[string]interface{}{"aField": 1}}))
+	_, err = NewOCFReader(bytes.NewReader(file.Bytes()), deserializer)
+	assert.Error(t, err)
+
+	// Neither can files whose schema isn't the one they name.
+	file.Reset()
+	ocfWriter, err = goavro.NewOCFWriter(goavro.OCFConfig{
+		W:        file,
+		Schema:   schema,
+		MetaData: map[string][]byte{OCFSchemaIDKey: []byte("2")},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, other.ID())
+	_, err = NewOCFReader(bytes.NewReader(file.Bytes()), deserializer)
+	assert.Error(t, err)
+}
+
+func TestOCF_Corrupt(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("nulls", `{"type": "null"}`, Avro, false)
+	assert.NoError(t, err)
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+
+	// Headers announcing more than they hold are rejected.
+	for _, header := range [][]byte{
+		append(append([]byte{}, ocfMagic...), 2, 0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f),
+		append(append([]byte{}, ocfMagic...), 2, 0xfe, 0xff, 0xff, 0x7f, 'a'),
+		append(append([]byte{}, ocfMagic...), 0xfe, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x7f),
+		appendAvroLong(appendAvroLong(append([]byte{}, ocfMagic...), -(1<<21)), 0),
+	} {
+		_, err = NewOCFReader(bytes.NewReader(header), deserializer)
+		assert.Error(t, err)
+	}
+
+	file := &bytes.Buffer{}
+	writer, err := NewOCFWriter(file, NewSerializer(mockClient), registered, "")
+	assert.NoError(t, err)
+	header := file.Bytes()
+	sync := header[len(header)-16:]
+	for _, block := range [][]byte{
+		// A block longer than the file.
+		appendAvroLong(appendAvroLong(nil, 1), 1<<20),
+		// A block longer than any block read.
+		appendAvroLong(appendAvroLong(nil, 1), 1<<40),
+		// Endless empty records.
+		append(appendAvroLong(appendAvroLong(nil, 1<<60), 0), sync...),
+	} {
+		reader, err := NewOCFReader(bytes.NewReader(append(append([]byte{}, header...), block...)), deserializer)
+		assert.NoError(t, err)
+		assert.False(t, reader.Next())
+		assert.Error(t, reader.Err())
+	}
+	assert.NoError(t, writer.Close())
+}
@@ -2,6 +2,9 @@ package srclient
 
 import (
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"golang.org/x/sync/semaphore"
@@ -30,8 +33,135 @@ func WithCredentials(username, password string) Option {
 	})
 }
 
+// WithTimeout sets the timeout of the requests, which
+// applies to the HTTP client of WithHttpClient as well.
 func WithTimeout(timeout time.Duration) Option {
 	return Option(func(client *SchemaRegistryClient) {
-		client.httpClient.Timeout = timeout
+		client.timeout = &timeout
 	})
 }
+
+// ListOption configures listing operations, such as GetSubjects
+// and GetSchemaVersions, that may return very large results.
+type ListOption func(*listOptions)
+
+type listOptions struct {
+	subjectPrefix string
+	offset        int
+	limit         int
+}
+
+// WithSubjectPrefix restricts a listing to the subjects
+// whose name starts with the given prefix.
+func WithSubjectPrefix(prefix string) ListOption {
+	return ListOption(func(options *listOptions) {
+		options.subjectPrefix = prefix
+	})
+}
+
+// WithOffset skips the given number of entries of a listing.
+func WithOffset(offset int) ListOption {
+	return ListOption(func(options *listOptions) {
+		options.offset = offset
+	})
+}
+
+// WithLimit caps the number of entries returned by a listing.
+// A value of zero or less means that no limit is applied.
+func WithLimit(limit int) ListOption {
+	return ListOption(func(options *listOptions) {
+		options.limit = limit
+	})
+}
+
+func newListOptions(opts []ListOption) *listOptions {
+	options := &listOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	return options
+}
+
+// query returns the URL query that asks Schema
+// Registry to apply these options server side.
+func (options *listOptions) query() string {
+	values := url.Values{}
+	if len(options.subjectPrefix) > 0 {
+		values.Set("subjectPrefix", options.subjectPrefix)
+	}
+	if options.offset > 0 {
+		values.Set("offset", strconv.Itoa(options.offset))
+	}
+	if options.limit > 0 {
+		values.Set("limit", strconv.Itoa(options.limit))
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return "?" + values.Encode()
+}
+
+// filter keeps the subjects that match the prefix. Along with bounds,
+// it is how MockSchemaRegistryClient honors these options locally.
+func (options *listOptions) filter(subjects []string) []string {
+	filtered := make([]string, 0, len(subjects))
+	for _, subject := range subjects {
+		if strings.HasPrefix(subject, options.subjectPrefix) {
+			filtered = append(filtered, subject)
+		}
+	}
+	return filtered
+}
+
+// bounds returns the window selected by offset
+// and limit within a listing of the given size.
+func (options *listOptions) bounds(size int) (int, int) {
+	start := options.offset
+	if start < 0 {
+		start = 0
+	}
+	if start > size {
+		start = size
+	}
+	end := size
+	if options.limit > 0 && start+options.limit < size {
+		end = start + options.limit
+	}
+	return start, end
+}
+
+// SchemaOption configures the registration, lookup or compatibility
+// check of a single schema, see CreateSchemaWithOptions.
+type SchemaOption func(*schemaOptions)
+
+type schemaOptions struct {
+	references []Reference
+	normalize  bool
+}
+
+// WithReferences sets the schemas that the schema references.
+func WithReferences(references ...Reference) SchemaOption {
+	return SchemaOption(func(options *schemaOptions) {
+		options.references = append(options.references, references...)
+	})
+}
+
+// WithNormalize normalizes the schema before it is sent, using
+// NormalizeSchema, and asks Schema Registry to normalize it as well,
+// so that schemas differing only in their formatting are the same.
+func WithNormalize() SchemaOption {
+	return SchemaOption(func(options *schemaOptions) {
+		options.normalize = true
+	})
+}
+
+func newSchemaOptions(opts []SchemaOption) *schemaOptions {
+	options := &schemaOptions{}
+	for _, opt := range opts {
+		opt(options)
+	}
+	if options.references == nil {
+		options.references = make([]Reference, 0)
+	}
+	return options
+}
@@ -0,0 +1,82 @@
+// Package otelsrclient traces the calls to Schema Registry made by
+// srclient with OpenTelemetry. It lives in its own module, so that
+// srclient itself doesn't depend on OpenTelemetry.
+package otelsrclient
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/riferrei/srclient"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the name of the tracer the spans are created with.
+const TracerName = "github.com/riferrei/srclient"
+
+// WithTracing traces the client with a tracer of the given provider,
+// or of the global provider when nil. See srclient.WithTracer.
+func WithTracing(provider trace.TracerProvider) srclient.Option {
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	return srclient.WithTracer(NewTracer(provider.Tracer(TracerName)))
+}
+
+// NewTracer adapts an OpenTelemetry tracer to srclient.Tracer.
+func NewTracer(tracer trace.Tracer) srclient.Tracer {
+	return otelTracer{tracer: tracer}
+}
+
+type otelTracer struct {
+	tracer trace.Tracer
+}
+
+func (t otelTracer) Start(ctx context.Context, name string, kind srclient.SpanKind) (context.Context, srclient.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(spanKind(kind)))
+	return ctx, otelSpan{span: span}
+}
+
+func spanKind(kind srclient.SpanKind) trace.SpanKind {
+	switch kind {
+	case srclient.SpanKindClient:
+		return trace.SpanKindClient
+	default:
+		return trace.SpanKindInternal
+	}
+}
+
+type otelSpan struct {
+	span trace.Span
+}
+
+func (s otelSpan) SetAttribute(key string, value interface{}) {
+	s.span.SetAttributes(keyValue(key, value))
+}
+
+func (s otelSpan) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s otelSpan) End() {
+	s.span.End()
+}
+
+func keyValue(key string, value interface{}) attribute.KeyValue {
+	switch v := value.(type) {
+	case string:
+		return attribute.String(key, v)
+	case int:
+		return attribute.Int(key, v)
+	case int64:
+		return attribute.Int64(key, v)
+	case bool:
+		return attribute.Bool(key, v)
+	default:
+		return attribute.String(key, fmt.Sprint(v))
+	}
+}
@@ -0,0 +1,60 @@
+package otelsrclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/riferrei/srclient"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestWithTracing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/schemas/ids/1" {
+			response, _ := json.Marshal(map[string]interface{}{"schema": `{"type": "string"}`})
+			rw.Write(response)
+			return
+		}
+		rw.WriteHeader(http.StatusNotFound)
+		rw.Write([]byte(`{"error_code": 40403, "message": "Schema not found"}`))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	client := srclient.CreateSchemaRegistryClientWithOptions(server.URL, WithTracing(provider))
+
+	_, err := client.GetSchemaByID(1)
+	assert.NoError(t, err)
+	_, err = client.GetSchemaByID(2)
+	assert.Error(t, err)
+
+	spans := recorder.Ended()
+	if !assert.Len(t, spans, 4) {
+		return
+	}
+	httpSpan, opSpan := spans[0], spans[1]
+	assert.Equal(t, "HTTP GET", httpSpan.Name())
+	assert.Equal(t, trace.SpanKindClient, httpSpan.SpanKind())
+	assert.Equal(t, opSpan.SpanContext().SpanID(), httpSpan.Parent().SpanID())
+	assert.Contains(t, httpSpan.Attributes(), attribute.Int(srclient.HTTPStatusAttribute, 200))
+	assert.Contains(t, httpSpan.Attributes(), attribute.String(srclient.HTTPURLAttribute, server.URL+"/schemas/ids/1"))
+
+	assert.Equal(t, "GetSchemaByID", opSpan.Name())
+	assert.Equal(t, trace.SpanKindInternal, opSpan.SpanKind())
+	assert.Contains(t, opSpan.Attributes(), attribute.Int(srclient.SchemaIDAttribute, 1))
+	assert.Contains(t, opSpan.Attributes(), attribute.Bool(srclient.CacheHitAttribute, false))
+	assert.Equal(t, codes.Unset, opSpan.Status().Code)
+
+	failed := spans[3]
+	assert.Equal(t, codes.Error, failed.Status().Code)
+	assert.Len(t, failed.Events(), 1)
+	assert.Equal(t, codes.Error, spans[2].Status().Code)
+}
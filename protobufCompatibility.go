@@ -0,0 +1,202 @@
+package srclient
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+)
+
+// Kinds of Protobuf changes that Schema Registry considers incompatible.
+// Adding or removing optional fields, renaming fields, adding messages
+// and oneofs, or changing packages and enums, which doesn't affect how
+// data is encoded, are compatible changes.
+const (
+	ProtobufMessageRemoved             IncompatibilityType = "MESSAGE_REMOVED"
+	ProtobufFieldKindChanged           IncompatibilityType = "FIELD_KIND_CHANGED"
+	ProtobufFieldScalarKindChanged     IncompatibilityType = "FIELD_SCALAR_KIND_CHANGED"
+	ProtobufFieldNamedTypeChanged      IncompatibilityType = "FIELD_NAMED_TYPE_CHANGED"
+	ProtobufFieldNumericLabelChanged   IncompatibilityType = "FIELD_NUMERIC_LABEL_CHANGED"
+	ProtobufRequiredFieldAdded         IncompatibilityType = "REQUIRED_FIELD_ADDED"
+	ProtobufRequiredFieldRemoved       IncompatibilityType = "REQUIRED_FIELD_REMOVED"
+	ProtobufOneofRemoved               IncompatibilityType = "ONEOF_REMOVED"
+	ProtobufOneofFieldRemoved          IncompatibilityType = "ONEOF_FIELD_REMOVED"
+	ProtobufMultipleFieldsMovedToOneof IncompatibilityType = "MULTIPLE_FIELDS_MOVED_TO_ONEOF"
+	ProtobufFieldMovedToExistingOneof  IncompatibilityType = "FIELD_MOVED_TO_EXISTING_ONEOF"
+)
+
+var protobufDescriptions = map[IncompatibilityType]string{
+	ProtobufMessageRemoved:             "a message is removed",
+	ProtobufFieldKindChanged:           "the kind of a field is changed",
+	ProtobufFieldScalarKindChanged:     "the scalar type of a field is changed to an incompatible one",
+	ProtobufFieldNamedTypeChanged:      "the message or enum type of a field is changed",
+	ProtobufFieldNumericLabelChanged:   "a numeric field is changed from or to repeated",
+	ProtobufRequiredFieldAdded:         "a required field is added",
+	ProtobufRequiredFieldRemoved:       "a required field is removed",
+	ProtobufOneofRemoved:               "a oneof is removed",
+	ProtobufOneofFieldRemoved:          "a field is removed from a oneof",
+	ProtobufMultipleFieldsMovedToOneof: "multiple existing fields are moved to a new oneof",
+	ProtobufFieldMovedToExistingOneof:  "an existing field is moved to an existing oneof",
+}
+
+// protobufWireTypes groups the scalar types that share their
+// encoding on the wire, so changing among them is compatible.
+var protobufWireTypes = map[string]string{
+	"int32": "varint", "uint32": "varint", "int64": "varint", "uint64": "varint", "bool": "varint",
+	"sint32": "zigzag", "sint64": "zigzag",
+	"fixed32": "fixed32", "sfixed32": "fixed32",
+	"fixed64": "fixed64", "sfixed64": "fixed64",
+	"string": "length", "bytes": "length",
+	"float": "float", "double": "double",
+}
+
+// protobufChecker compares two .proto files the way Schema Registry
+// does. Messages and enums are matched by their fully qualified name,
+// and fields by their number, which is what identifies them on the wire.
+type protobufChecker struct{}
+
+func (protobufChecker) parse(schema string) (interface{}, error) {
+	return parseProtobufSchema(schema)
+}
+
+func (protobufChecker) check(reader, writer interface{}, newIsReader bool) []Incompatibility {
+	diff := &protobufDiff{newIsReader: newIsReader}
+	diff.compare(writer.(*protoFile), reader.(*protoFile))
+	return diff.found
+}
+
+type protobufDiff struct {
+	newIsReader bool
+	found       []Incompatibility
+}
+
+func (diff *protobufDiff) report(incompatibilityType IncompatibilityType, path, additionalInfo string) {
+	readerAge, writerAge := ages(diff.newIsReader)
+	diff.found = append(diff.found, Incompatibility{
+		Type: incompatibilityType,
+		Path: path,
+		Description: fmt.Sprintf("Found an incompatible change at path '%s' of the %s schema when compared to the %s schema: %s",
+			path, readerAge, writerAge, protobufDescriptions[incompatibilityType]),
+		AdditionalInfo: additionalInfo,
+	})
+}
+
+func (diff *protobufDiff) compare(original, update *protoFile) {
+	updateMessages := map[string]*protoMessage{}
+	for _, message := range update.allMessages() {
+		updateMessages[message.fullName] = message
+	}
+	for _, message := range original.allMessages() {
+		updated, ok := updateMessages[message.fullName]
+		if !ok {
+			diff.report(ProtobufMessageRemoved, "#/"+message.fullName, message.fullName)
+			continue
+		}
+		diff.compareMessages(message, updated)
+	}
+}
+
+func (diff *protobufDiff) compareMessages(original, update *protoMessage) {
+	updateFields := map[int]*protoField{}
+	for _, field := range update.fields {
+		updateFields[field.number] = field
+	}
+	originalFields := map[int]*protoField{}
+	for _, field := range original.fields {
+		originalFields[field.number] = field
+	}
+	originalOneofs := map[string]bool{}
+	for _, oneof := range original.oneofs {
+		originalOneofs[oneof.name] = true
+	}
+	updateOneofs := map[string]bool{}
+	for _, oneof := range update.oneofs {
+		updateOneofs[oneof.name] = true
+	}
+
+	for _, field := range original.fields {
+		path := "#/" + original.fullName + "/" + strconv.Itoa(field.number)
+		updated, ok := updateFields[field.number]
+		if !ok {
+			switch {
+			case field.label == "required":
+				diff.report(ProtobufRequiredFieldRemoved, path, field.name)
+			case len(field.oneof) > 0 && updateOneofs[field.oneof]:
+				diff.report(ProtobufOneofFieldRemoved, path, field.name)
+			}
+			continue
+		}
+		diff.compareFields(field, updated, path)
+		if len(field.oneof) > 0 && updateOneofs[field.oneof] && updated.oneof != field.oneof {
+			diff.report(ProtobufOneofFieldRemoved, path, field.name)
+		}
+	}
+	for _, field := range update.fields {
+		if _, ok := originalFields[field.number]; !ok && field.label == "required" {
+			diff.report(ProtobufRequiredFieldAdded, "#/"+update.fullName+"/"+strconv.Itoa(field.number), field.name)
+		}
+	}
+
+	for _, name := range sortedKeys(originalOneofs) {
+		if !updateOneofs[name] {
+			diff.report(ProtobufOneofRemoved, "#/"+original.fullName+"/"+name, name)
+		}
+	}
+
+	// Moving a single existing field into a new oneof is safe, but
+	// moving several of them, or moving one to an existing oneof, may
+	// lose data when more than one of them is set by the writer.
+	moved := map[string][]int{}
+	for _, field := range original.fields {
+		updated, ok := updateFields[field.number]
+		if ok && len(field.oneof) == 0 && len(updated.oneof) > 0 {
+			moved[updated.oneof] = append(moved[updated.oneof], field.number)
+		}
+	}
+	for _, name := range sortedKeys(moved) {
+		numbers := moved[name]
+		sort.Ints(numbers)
+		path := "#/" + update.fullName + "/" + name
+		if originalOneofs[name] {
+			diff.report(ProtobufFieldMovedToExistingOneof, path, fmt.Sprint(numbers))
+		} else if len(numbers) > 1 {
+			diff.report(ProtobufMultipleFieldsMovedToOneof, path, fmt.Sprint(numbers))
+		}
+	}
+}
+
+func (diff *protobufDiff) compareFields(original, update *protoField, path string) {
+	if original.kind != update.kind {
+		// Enums are encoded like int32, so the two can be swapped.
+		if !(original.kind == "enum" && protobufWireTypes[update.typ] == "varint") &&
+			!(update.kind == "enum" && protobufWireTypes[original.typ] == "varint") {
+			diff.report(ProtobufFieldKindChanged, path, fmt.Sprintf("expected: %s, found: %s", original.kind, update.kind))
+			return
+		}
+	}
+
+	switch original.kind {
+	case "scalar":
+		if update.kind == "scalar" && protobufWireTypes[original.typ] != protobufWireTypes[update.typ] {
+			diff.report(ProtobufFieldScalarKindChanged, path, fmt.Sprintf("expected: %s, found: %s", original.typ, update.typ))
+			return
+		}
+	case "message", "enum":
+		if original.kind == update.kind && original.fullType != update.fullType {
+			diff.report(ProtobufFieldNamedTypeChanged, path, fmt.Sprintf("expected: %s, found: %s", original.fullType, update.fullType))
+			return
+		}
+	case "map":
+		if original.mapKey != update.mapKey || original.fullType != update.fullType {
+			diff.report(ProtobufFieldKindChanged, path, fmt.Sprintf("expected: map<%s, %s>, found: map<%s, %s>",
+				original.mapKey, original.fullType, update.mapKey, update.fullType))
+			return
+		}
+	}
+
+	// Singular and repeated are interchangeable for length delimited
+	// types, but packed numbers can't be read as a single value.
+	if (original.label == "repeated") != (update.label == "repeated") && original.kind != "message" &&
+		protobufWireTypes[original.typ] != "length" {
+		diff.report(ProtobufFieldNumericLabelChanged, path, original.name)
+	}
+}
@@ -0,0 +1,132 @@
+package srclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const protobufOrderV1 = `
+syntax = "proto3";
+package com.example;
+
+import "google/protobuf/timestamp.proto";
+
+message Order {
+  int64 id = 1;
+  string customer = 2;
+  Status status = 3;
+  google.protobuf.Timestamp created_at = 4;
+  string email = 5;
+  string phone = 6;
+  oneof payment {
+    string card = 7;
+    string iban = 8;
+  }
+
+  enum Status {
+    UNKNOWN = 0;
+    PLACED = 1;
+  }
+}
+`
+
+func TestCheckCompatibility_Protobuf(t *testing.T) {
+	cases := []struct {
+		name     string
+		schema   string
+		expected []IncompatibilityType
+	}{
+		{
+			name: "fields added, renamed and promoted",
+			schema: `syntax = "proto3"; package com.example;
+				import "google/protobuf/timestamp.proto";
+				message Order {
+				  uint64 id = 1;
+				  bytes customer_name = 2;
+				  Status status = 3;
+				  google.protobuf.Timestamp created_at = 4;
+				  string email = 5;
+				  string phone = 6;
+				  oneof payment { string card = 7; string iban = 8; string paypal = 10; }
+				  repeated string tags = 9;
+				  enum Status { UNKNOWN = 0; PLACED = 1; SHIPPED = 2; }
+				}
+				message Audit { string by = 1; }`,
+		},
+		{
+			name: "field number reused with another type",
+			schema: `syntax = "proto3"; package com.example;
+				message Order {
+				  int64 id = 1;
+				  string customer = 2;
+				  Status status = 3;
+				  double created_at = 4;
+				  string email = 5;
+				  string phone = 6;
+				  oneof payment { string card = 7; string iban = 8; }
+				  enum Status { UNKNOWN = 0; PLACED = 1; }
+				}`,
+			expected: []IncompatibilityType{ProtobufFieldKindChanged},
+		},
+		{
+			name: "fields moved to a new oneof",
+			schema: `syntax = "proto3"; package com.example;
+				message Order {
+				  int64 id = 1;
+				  string customer = 2;
+				  Status status = 3;
+				  oneof contact { string email = 5; string phone = 6; }
+				  oneof payment { string card = 7; string iban = 8; }
+				  enum Status { UNKNOWN = 0; }
+				}`,
+			expected: []IncompatibilityType{ProtobufMultipleFieldsMovedToOneof},
+		},
+		{
+			name: "message removed",
+			schema: `syntax = "proto3"; package com.example;
+				enum Status { UNKNOWN = 0; PLACED = 1; }
+				message Other { string card = 7; }`,
+			expected: []IncompatibilityType{ProtobufMessageRemoved},
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			result, err := CheckCompatibility(Protobuf, Backward, c.schema, protobufOrderV1)
+			assert.NoError(t, err)
+			assert.Equal(t, len(c.expected) == 0, result.IsCompatible, result.Messages)
+			var types []IncompatibilityType
+			for _, incompatibility := range result.Incompatibilities {
+				types = append(types, incompatibility.Type)
+			}
+			assert.Equal(t, c.expected, types)
+		})
+	}
+}
+
+func TestCheckCompatibility_ProtobufOneofFieldAdded(t *testing.T) {
+	v2 := `syntax = "proto3"; package com.example;
+		import "google/protobuf/timestamp.proto";
+		message Order {
+		  int64 id = 1;
+		  string customer = 2;
+		  Status status = 3;
+		  google.protobuf.Timestamp created_at = 4;
+		  string email = 5;
+		  string phone = 6;
+		  oneof payment { string card = 7; string iban = 8; string paypal = 9; }
+		  enum Status { UNKNOWN = 0; PLACED = 1; }
+		}`
+
+	result, err := CheckCompatibility(Protobuf, Backward, v2, protobufOrderV1)
+	assert.NoError(t, err)
+	assert.True(t, result.IsCompatible)
+
+	// Old consumers don't know about the new payment method.
+	result, err = CheckCompatibility(Protobuf, Full, v2, protobufOrderV1)
+	assert.NoError(t, err)
+	assert.False(t, result.IsCompatible)
+	assert.Equal(t, ProtobufOneofFieldRemoved, result.Incompatibilities[0].Type)
+	assert.Equal(t, "#/com.example.Order/9", result.Incompatibilities[0].Path)
+}
@@ -0,0 +1,331 @@
+package srclient
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// errInvalidMessageIndexes is wrapped by the errors about the message
+// indexes that follow the schema ID of Protobuf records.
+var errInvalidMessageIndexes = errors.New("invalid message indexes")
+
+// protobufDecoder decodes Protobuf records written in the wire format,
+// whose payload starts with the indexes of the message in the schema.
+// Messages are decoded into maps keyed by field name. Repeated fields
+// become slices, maps become maps keyed by the string form of their
+// keys, enums hold the name of their value, and messages imported from
+// other schemas are left as bytes. Fields missing from the data, which
+// hold their default value, are left out.
+type protobufDecoder struct {
+	file     *protoFile
+	messages map[string]*protoMessage
+	enums    map[string]*protoEnum
+}
+
+func newProtobufDecoder(file *protoFile) *protobufDecoder {
+	decoder := &protobufDecoder{file: file, messages: map[string]*protoMessage{}, enums: map[string]*protoEnum{}}
+	var collect func(messages []*protoMessage, enums []*protoEnum)
+	collect = func(messages []*protoMessage, enums []*protoEnum) {
+		for _, enum := range enums {
+			decoder.enums[enum.fullName] = enum
+		}
+		for _, message := range messages {
+			decoder.messages[message.fullName] = message
+			collect(message.messages, message.enums)
+		}
+	}
+	collect(file.messages, file.enums)
+	return decoder
+}
+
+func (decoder *protobufDecoder) NativeFromBinary(buf []byte) (interface{}, []byte, error) {
+	message, rest, err := decoder.messageFor(buf)
+	if err != nil {
+		return nil, buf, err
+	}
+	native, err := decoder.decodeMessage(message, rest)
+	if err != nil {
+		return nil, buf, err
+	}
+	return native, nil, nil
+}
+
+// messageFor reads the message indexes, which are the path to the
+// message in the schema: the index of a top level message followed by
+// the indexes of nested ones. The common case of the first message is
+// written as a single zero.
+func (decoder *protobufDecoder) messageFor(buf []byte) (*protoMessage, []byte, error) {
+	count, rest, err := readAvroLong(buf)
+	if err != nil {
+		return nil, buf, fmt.Errorf("%w: %v", errInvalidMessageIndexes, err)
+	}
+	indexes := []int64{0}
+	if count != 0 {
+		if count < 0 || count > int64(len(rest)) {
+			return nil, buf, fmt.Errorf("%w: invalid count %d", errInvalidMessageIndexes, count)
+		}
+		indexes = make([]int64, count)
+		for i := range indexes {
+			if indexes[i], rest, err = readAvroLong(rest); err != nil {
+				return nil, buf, fmt.Errorf("%w: %v", errInvalidMessageIndexes, err)
+			}
+		}
+	}
+
+	messages := decoder.file.messages
+	var message *protoMessage
+	for _, index := range indexes {
+		if index < 0 || index >= int64(len(messages)) {
+			return nil, buf, fmt.Errorf("%w: no message at index %v", errInvalidMessageIndexes, indexes)
+		}
+		message = messages[index]
+		messages = message.messages
+	}
+	return message, rest, nil
+}
+
+func (decoder *protobufDecoder) decodeMessage(message *protoMessage, buf []byte) (map[string]interface{}, error) {
+	fields := make(map[int]*protoField, len(message.fields))
+	for _, field := range message.fields {
+		fields[field.number] = field
+	}
+
+	native := map[string]interface{}{}
+	for len(buf) > 0 {
+		key, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, fmt.Errorf("invalid field key in %s", message.fullName)
+		}
+		buf = buf[n:]
+		number, wireType := int(key>>3), int(key&7)
+		value, rest, err := readProtoValue(wireType, buf)
+		if err != nil {
+			return nil, fmt.Errorf("field %d of %s: %w", number, message.fullName, err)
+		}
+		buf = rest
+
+		field, ok := fields[number]
+		if !ok {
+			continue
+		}
+		if err := decoder.setField(native, field, wireType, value); err != nil {
+			return nil, fmt.Errorf("field %s of %s: %w", field.name, message.fullName, err)
+		}
+	}
+	return native, nil
+}
+
+func (decoder *protobufDecoder) setField(native map[string]interface{}, field *protoField, wireType int, value interface{}) error {
+	if field.kind == "map" {
+		entry, ok := value.([]byte)
+		if !ok {
+			return fmt.Errorf("expected a map entry, found wire type %d", wireType)
+		}
+		key, item, err := decoder.decodeMapEntry(field, entry)
+		if err != nil {
+			return err
+		}
+		entries, _ := native[field.name].(map[string]interface{})
+		if entries == nil {
+			entries = map[string]interface{}{}
+			native[field.name] = entries
+		}
+		entries[fmt.Sprint(key)] = item
+		return nil
+	}
+
+	// Repeated numbers are usually packed in a single length
+	// delimited value, but can be written one by one as well.
+	if field.label == "repeated" {
+		items, _ := native[field.name].([]interface{})
+		if packed, ok := value.([]byte); ok && isPackableProto(field) {
+			for len(packed) > 0 {
+				item, rest, err := readProtoValue(packedWireType(field.fullType), packed)
+				if err != nil {
+					return err
+				}
+				converted, err := decoder.convert(field.kind, field.fullType, item)
+				if err != nil {
+					return err
+				}
+				items, packed = append(items, converted), rest
+			}
+			native[field.name] = items
+			return nil
+		}
+		converted, err := decoder.convert(field.kind, field.fullType, value)
+		if err != nil {
+			return err
+		}
+		native[field.name] = append(items, converted)
+		return nil
+	}
+
+	converted, err := decoder.convert(field.kind, field.fullType, value)
+	if err != nil {
+		return err
+	}
+	native[field.name] = converted
+	return nil
+}
+
+func (decoder *protobufDecoder) decodeMapEntry(field *protoField, entry []byte) (interface{}, interface{}, error) {
+	valueKind := decoder.valueKind(field.fullType)
+	var key, value interface{}
+	for len(entry) > 0 {
+		tag, n := binary.Uvarint(entry)
+		if n <= 0 {
+			return nil, nil, fmt.Errorf("invalid map entry")
+		}
+		raw, rest, err := readProtoValue(int(tag&7), entry[n:])
+		if err != nil {
+			return nil, nil, err
+		}
+		entry = rest
+		switch tag >> 3 {
+		case 1:
+			if key, err = decoder.convert("scalar", field.mapKey, raw); err != nil {
+				return nil, nil, err
+			}
+		case 2:
+			if value, err = decoder.convert(valueKind, field.fullType, raw); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+	if key == nil {
+		key, _ = decoder.convert("scalar", field.mapKey, zeroProtoValue(field.mapKey))
+	}
+	if value == nil && valueKind != "message" {
+		value, _ = decoder.convert(valueKind, field.fullType, zeroProtoValue(field.fullType))
+	}
+	return key, value, nil
+}
+
+// valueKind returns the kind of the values of map fields.
+func (decoder *protobufDecoder) valueKind(typ string) string {
+	switch {
+	case protoScalars[typ]:
+		return "scalar"
+	case decoder.enums[typ] != nil:
+		return "enum"
+	}
+	return "message"
+}
+
+// convert turns the raw value read from the wire, a uint64 for
+// varints and fixed numbers or bytes for length delimited values,
+// into the Go value of a field of the given kind and type.
+func (decoder *protobufDecoder) convert(kind, typ string, raw interface{}) (interface{}, error) {
+	switch kind {
+	case "enum":
+		number, ok := raw.(uint64)
+		if !ok {
+			return nil, fmt.Errorf("expected a varint for the enum %s", typ)
+		}
+		if enum := decoder.enums[typ]; enum != nil {
+			for _, value := range enum.values {
+				if value.number == int(int32(number)) {
+					return value.name, nil
+				}
+			}
+		}
+		return int32(number), nil
+	case "message":
+		b, ok := raw.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("expected a length delimited value for the message %s", typ)
+		}
+		message := decoder.messages[typ]
+		if message == nil {
+			return b, nil
+		}
+		return decoder.decodeMessage(message, b)
+	}
+
+	switch v := raw.(type) {
+	case uint64:
+		switch typ {
+		case "int32", "sfixed32":
+			return int32(v), nil
+		case "int64", "sfixed64":
+			return int64(v), nil
+		case "uint32", "fixed32":
+			return uint32(v), nil
+		case "uint64", "fixed64":
+			return v, nil
+		case "sint32":
+			return int32(int64(v>>1) ^ -int64(v&1)), nil
+		case "sint64":
+			return int64(v>>1) ^ -int64(v&1), nil
+		case "bool":
+			return v != 0, nil
+		case "float":
+			return math.Float32frombits(uint32(v)), nil
+		case "double":
+			return math.Float64frombits(v), nil
+		}
+	case []byte:
+		switch typ {
+		case "string":
+			return string(v), nil
+		case "bytes":
+			return v, nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected wire value for the type %s", typ)
+}
+
+// readProtoValue reads a value of the given wire type, returning
+// numbers as uint64 and length delimited values as bytes.
+func readProtoValue(wireType int, buf []byte) (interface{}, []byte, error) {
+	switch wireType {
+	case 0:
+		value, n := binary.Uvarint(buf)
+		if n <= 0 {
+			return nil, buf, errors.New("invalid varint")
+		}
+		return value, buf[n:], nil
+	case 1:
+		if len(buf) < 8 {
+			return nil, buf, errAvroShortBuffer
+		}
+		return binary.LittleEndian.Uint64(buf), buf[8:], nil
+	case 2:
+		size, n := binary.Uvarint(buf)
+		if n <= 0 || size > uint64(len(buf)-n) {
+			return nil, buf, errors.New("invalid length")
+		}
+		end := n + int(size)
+		return append([]byte{}, buf[n:end]...), buf[end:], nil
+	case 5:
+		if len(buf) < 4 {
+			return nil, buf, errAvroShortBuffer
+		}
+		return uint64(binary.LittleEndian.Uint32(buf)), buf[4:], nil
+	}
+	return nil, buf, fmt.Errorf("unsupported wire type %d", wireType)
+}
+
+func isPackableProto(field *protoField) bool {
+	return field.kind == "enum" || (field.kind == "scalar" && field.fullType != "string" && field.fullType != "bytes")
+}
+
+func packedWireType(typ string) int {
+	switch typ {
+	case "fixed64", "sfixed64", "double":
+		return 1
+	case "fixed32", "sfixed32", "float":
+		return 5
+	}
+	return 0
+}
+
+func zeroProtoValue(typ string) interface{} {
+	if typ == "string" || typ == "bytes" {
+		return []byte{}
+	}
+	return uint64(0)
+}
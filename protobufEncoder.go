@@ -0,0 +1,336 @@
+package srclient
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// protobufEncoder encodes Protobuf records in the wire format, the
+// payload starting with the indexes of the message in the schema.
+// Messages are encoded from structs, matched to the schema as described
+// by Deserializer.DeserializeInto, or from the maps keyed by field name
+// protobufDecoder returns. Fields holding the zero value of their type,
+// and nil pointers, slices and maps, are left out, as Protobuf does
+// for fields without presence.
+type protobufEncoder struct {
+	*protobufDecoder
+}
+
+func newProtobufEncoder(file *protoFile) *protobufEncoder {
+	return &protobufEncoder{protobufDecoder: newProtobufDecoder(file)}
+}
+
+// appendMessage appends the message indexes and the encoding of the
+// value, which is written as the message named by the marker field of
+// its struct, as in `_ struct{} protobuf:"com.example.Order"`, or as
+// the first message of the schema.
+func (encoder *protobufEncoder) appendMessage(buf []byte, value interface{}) ([]byte, error) {
+	v := protoIndirect(reflect.ValueOf(value))
+	if !v.IsValid() {
+		return nil, fmt.Errorf("a nil value can't be written as a protobuf message")
+	}
+	if len(encoder.file.messages) == 0 {
+		return nil, fmt.Errorf("the protobuf schema has no message")
+	}
+	message := encoder.file.messages[0]
+	if name := protoMessageName(v.Type()); len(name) > 0 {
+		if message = encoder.messages[strings.TrimPrefix(name, ".")]; message == nil {
+			return nil, fmt.Errorf("the protobuf schema has no message %s", name)
+		}
+	}
+
+	indexes := encoder.messageIndexes(message)
+	if len(indexes) == 1 && indexes[0] == 0 {
+		buf = append(buf, 0)
+	} else {
+		buf = appendAvroLong(buf, int64(len(indexes)))
+		for _, index := range indexes {
+			buf = appendAvroLong(buf, int64(index))
+		}
+	}
+	return encoder.appendFields(buf, message, v)
+}
+
+// messageIndexes returns the path to the message in the schema,
+// as read by protobufDecoder.messageFor.
+func (encoder *protobufEncoder) messageIndexes(message *protoMessage) []int {
+	var find func(messages []*protoMessage) []int
+	find = func(messages []*protoMessage) []int {
+		for i, candidate := range messages {
+			if candidate == message {
+				return []int{i}
+			}
+			if path := find(candidate.messages); path != nil {
+				return append([]int{i}, path...)
+			}
+		}
+		return nil
+	}
+	return find(encoder.file.messages)
+}
+
+// appendFields appends the fields of a message, read from a struct or
+// from a map keyed by field name, in the order of the schema.
+func (encoder *protobufEncoder) appendFields(buf []byte, message *protoMessage, v reflect.Value) ([]byte, error) {
+	v = protoIndirect(v)
+	var goFields map[string][]int
+	switch {
+	case v.Kind() == reflect.Struct:
+		goFields = protoStructFields(v.Type())
+	case v.Kind() == reflect.Map && v.Type().Key().Kind() == reflect.String:
+	case !v.IsValid():
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("the message %s can't be encoded from %s", message.fullName, v.Type())
+	}
+
+	for _, field := range message.fields {
+		var value reflect.Value
+		if goFields == nil {
+			value = v.MapIndex(reflect.ValueOf(field.name).Convert(v.Type().Key()))
+		} else if index, ok := lookupProtoField(goFields, field.name); ok {
+			value = v.FieldByIndex(index)
+		}
+		if !isProtoValuePresent(value) {
+			continue
+		}
+		var err error
+		if buf, err = encoder.appendField(buf, field, protoIndirect(value)); err != nil {
+			return nil, fmt.Errorf("field %s of %s: %w", field.name, message.fullName, err)
+		}
+	}
+	return buf, nil
+}
+
+func (encoder *protobufEncoder) appendField(buf []byte, field *protoField, v reflect.Value) ([]byte, error) {
+	if field.kind == "map" {
+		if v.Kind() != reflect.Map {
+			return nil, fmt.Errorf("expected a map, found %s", v.Type())
+		}
+		valueKind := encoder.valueKind(field.fullType)
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for _, key := range keys {
+			entry, err := encoder.appendValue(nil, 1, "scalar", field.mapKey, protoMapKey(field.mapKey, key))
+			if err != nil {
+				return nil, err
+			}
+			if value := protoIndirect(v.MapIndex(key)); value.IsValid() {
+				if entry, err = encoder.appendValue(entry, 2, valueKind, field.fullType, value); err != nil {
+					return nil, err
+				}
+			}
+			buf = appendProtoKey(buf, field.number, 2)
+			buf = append(appendProtoVarint(buf, uint64(len(entry))), entry...)
+		}
+		return buf, nil
+	}
+
+	if field.label != "repeated" {
+		return encoder.appendValue(buf, field.number, field.kind, field.fullType, v)
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("expected a slice, found %s", v.Type())
+	}
+	if isPackableProto(field) {
+		var packed []byte
+		for i := 0; i < v.Len(); i++ {
+			bits, err := encoder.numberBits(field.kind, field.fullType, protoIndirect(v.Index(i)))
+			if err != nil {
+				return nil, err
+			}
+			packed = appendProtoNumber(packed, packedWireType(field.fullType), bits)
+		}
+		buf = appendProtoKey(buf, field.number, 2)
+		return append(appendProtoVarint(buf, uint64(len(packed))), packed...), nil
+	}
+	for i := 0; i < v.Len(); i++ {
+		var err error
+		if buf, err = encoder.appendValue(buf, field.number, field.kind, field.fullType, protoIndirect(v.Index(i))); err != nil {
+			return nil, err
+		}
+	}
+	return buf, nil
+}
+
+// appendValue appends a single value with its key.
+func (encoder *protobufEncoder) appendValue(buf []byte, number int, kind, typ string, v reflect.Value) ([]byte, error) {
+	if message := encoder.messages[typ]; kind == "message" && message != nil {
+		encoded, err := encoder.appendFields(nil, message, v)
+		if err != nil {
+			return nil, err
+		}
+		buf = appendProtoKey(buf, number, 2)
+		return append(appendProtoVarint(buf, uint64(len(encoded))), encoded...), nil
+	}
+	if !v.IsValid() {
+		return nil, fmt.Errorf("missing value of the type %s", typ)
+	}
+
+	// Messages of other schemas are written as they are encoded.
+	if kind == "message" || typ == "string" || typ == "bytes" {
+		var data []byte
+		switch {
+		case v.Kind() == reflect.String:
+			data = []byte(v.String())
+		case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8:
+			data = v.Bytes()
+		default:
+			return nil, fmt.Errorf("expected a string or bytes for the type %s, found %s", typ, v.Type())
+		}
+		buf = appendProtoKey(buf, number, 2)
+		return append(appendProtoVarint(buf, uint64(len(data))), data...), nil
+	}
+	bits, err := encoder.numberBits(kind, typ, v)
+	if err != nil {
+		return nil, err
+	}
+	wireType := packedWireType(typ)
+	return appendProtoNumber(appendProtoKey(buf, number, wireType), wireType, bits), nil
+}
+
+// numberBits returns the bits numbers and enums are written with,
+// the reverse of what protobufDecoder.convert does.
+func (encoder *protobufEncoder) numberBits(kind, typ string, v reflect.Value) (uint64, error) {
+	if !v.IsValid() {
+		return 0, fmt.Errorf("missing value of the type %s", typ)
+	}
+	if kind == "enum" {
+		if v.Kind() == reflect.String {
+			if enum := encoder.enums[typ]; enum != nil {
+				for _, value := range enum.values {
+					if value.name == v.String() {
+						return uint64(int64(value.number)), nil
+					}
+				}
+			}
+			return 0, fmt.Errorf("unknown value %s of the enum %s", v.String(), typ)
+		}
+		typ = "int32"
+	}
+
+	switch {
+	case typ == "bool" && v.Kind() == reflect.Bool:
+		if v.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	case (typ == "float" || typ == "double") && (isFloat(v.Kind()) || isInteger(v.Kind())):
+		f := 0.0
+		switch {
+		case isFloat(v.Kind()):
+			f = v.Float()
+		case isUnsigned(v.Kind()):
+			f = float64(v.Uint())
+		default:
+			f = float64(v.Int())
+		}
+		if typ == "float" {
+			return uint64(math.Float32bits(float32(f))), nil
+		}
+		return math.Float64bits(f), nil
+	case isInteger(v.Kind()):
+		target := protoGoTypes[typ]
+		if target == nil {
+			break
+		}
+		if overflows(v, target) {
+			return 0, fmt.Errorf("%v overflows the type %s", v.Interface(), typ)
+		}
+		n := v.Convert(target)
+		switch typ {
+		case "sint32", "sint64":
+			i := n.Int()
+			return uint64(i<<1) ^ uint64(i>>63), nil
+		case "uint32", "uint64", "fixed32", "fixed64":
+			return n.Uint(), nil
+		case "sfixed32":
+			return uint64(uint32(n.Int())), nil
+		}
+		return uint64(n.Int()), nil
+	}
+	return 0, fmt.Errorf("unable to write %s as the type %s", v.Type(), typ)
+}
+
+// protoGoTypes are the Go types of the Protobuf integer types.
+var protoGoTypes = map[string]reflect.Type{
+	"int32": reflect.TypeOf(int32(0)), "sint32": reflect.TypeOf(int32(0)), "sfixed32": reflect.TypeOf(int32(0)),
+	"int64": reflect.TypeOf(int64(0)), "sint64": reflect.TypeOf(int64(0)), "sfixed64": reflect.TypeOf(int64(0)),
+	"uint32": reflect.TypeOf(uint32(0)), "fixed32": reflect.TypeOf(uint32(0)),
+	"uint64": reflect.TypeOf(uint64(0)), "fixed64": reflect.TypeOf(uint64(0)),
+}
+
+// protoMapKey returns the key of a map entry, parsing the string
+// keys of the maps protobufDecoder returns for other key types.
+func protoMapKey(typ string, key reflect.Value) reflect.Value {
+	if key.Kind() != reflect.String || typ == "string" {
+		return key
+	}
+	if typ == "bool" {
+		if b, err := strconv.ParseBool(key.String()); err == nil {
+			return reflect.ValueOf(b)
+		}
+	} else if target := protoGoTypes[typ]; target != nil && isUnsigned(target.Kind()) {
+		if u, err := strconv.ParseUint(key.String(), 10, 64); err == nil {
+			return reflect.ValueOf(u)
+		}
+	} else if i, err := strconv.ParseInt(key.String(), 10, 64); err == nil {
+		return reflect.ValueOf(i)
+	}
+	return key
+}
+
+func appendProtoKey(buf []byte, number, wireType int) []byte {
+	return appendProtoVarint(buf, uint64(number)<<3|uint64(wireType))
+}
+
+func appendProtoNumber(buf []byte, wireType int, bits uint64) []byte {
+	var scratch [binary.MaxVarintLen64]byte
+	switch wireType {
+	case 1:
+		binary.LittleEndian.PutUint64(scratch[:], bits)
+		return append(buf, scratch[:8]...)
+	case 5:
+		binary.LittleEndian.PutUint32(scratch[:], uint32(bits))
+		return append(buf, scratch[:4]...)
+	}
+	return append(buf, scratch[:binary.PutUvarint(scratch[:], bits)]...)
+}
+
+func appendProtoVarint(buf []byte, value uint64) []byte {
+	return appendProtoNumber(buf, 0, value)
+}
+
+// isProtoValuePresent tells whether a field is written: pointers are
+// written unless nil, other values unless they are zero or empty.
+func isProtoValuePresent(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return false
+	case reflect.Interface:
+		return !v.IsNil() && isProtoValuePresent(v.Elem())
+	case reflect.Ptr:
+		return !v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.Len() > 0
+	}
+	return !v.IsZero()
+}
+
+// protoIndirect returns the value pointers and interfaces hold.
+func protoIndirect(v reflect.Value) reflect.Value {
+	for (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && !v.IsNil() {
+		v = v.Elem()
+	}
+	if (v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface) && v.IsNil() {
+		return reflect.Value{}
+	}
+	return v
+}
@@ -0,0 +1,234 @@
+package srclient
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// protoFieldIndexes caches the struct fields matched to
+// Protobuf fields by protoStructFields, by struct type.
+var protoFieldIndexes sync.Map
+
+// protoStructFields returns the indexes of the exported fields of a
+// struct, keyed by their protobuf tag, as in `protobuf:"user_id"`, or
+// else by their name in lower case.
+func protoStructFields(typ reflect.Type) map[string][]int {
+	if fields, ok := protoFieldIndexes.Load(typ); ok {
+		return fields.(map[string][]int)
+	}
+	fields := map[string][]int{}
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if len(field.PkgPath) > 0 {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("protobuf"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if len(name) == 0 {
+			name = strings.ToLower(field.Name)
+			if _, tagged := fields[name]; tagged {
+				continue
+			}
+		}
+		fields[name] = field.Index
+	}
+	protoFieldIndexes.Store(typ, fields)
+	return fields
+}
+
+// lookupProtoField finds the struct field of a Protobuf field, by
+// tag or else by name, ignoring case and underscores.
+func lookupProtoField(fields map[string][]int, name string) ([]int, bool) {
+	if index, ok := fields[name]; ok {
+		return index, true
+	}
+	index, ok := fields[strings.ToLower(strings.ReplaceAll(name, "_", ""))]
+	return index, ok
+}
+
+// protoMessageName returns the full name of the message a struct is
+// written as, set by the tag of its blank field, as in
+//
+//	_ struct{} `protobuf:"com.example.Order"`
+func protoMessageName(typ reflect.Type) string {
+	if typ.Kind() != reflect.Struct {
+		return ""
+	}
+	if marker, ok := typ.FieldByName("_"); ok {
+		return marker.Tag.Get("protobuf")
+	}
+	return ""
+}
+
+// decodeInto decodes a payload, message indexes included, into the
+// value target holds, a struct or a map keyed by field name.
+func (decoder *protobufDecoder) decodeInto(payload []byte, target reflect.Value) error {
+	message, rest, err := decoder.messageFor(payload)
+	if err != nil {
+		return err
+	}
+	native, err := decoder.decodeMessage(message, rest)
+	if err != nil {
+		return err
+	}
+	return decoder.mapMessage(message, native, target)
+}
+
+func (decoder *protobufDecoder) mapMessage(message *protoMessage, native map[string]interface{}, target reflect.Value) error {
+	if target.Kind() != reflect.Struct {
+		return decoder.assign("message", message.fullName, native, target)
+	}
+	if name := protoMessageName(target.Type()); len(name) > 0 && strings.TrimPrefix(name, ".") != message.fullName {
+		return fmt.Errorf("the record holds the message %s, not %s", message.fullName, name)
+	}
+
+	target.Set(reflect.Zero(target.Type()))
+	goFields := protoStructFields(target.Type())
+	for _, field := range message.fields {
+		value, ok := native[field.name]
+		if !ok {
+			continue
+		}
+		index, ok := lookupProtoField(goFields, field.name)
+		if !ok {
+			continue
+		}
+		if err := decoder.assignField(field, value, target.FieldByIndex(index)); err != nil {
+			return fmt.Errorf("field %s of %s: %w", field.name, message.fullName, err)
+		}
+	}
+	return nil
+}
+
+func (decoder *protobufDecoder) assignField(field *protoField, native interface{}, target reflect.Value) error {
+	for target.Kind() == reflect.Ptr {
+		target.Set(reflect.New(target.Type().Elem()))
+		target = target.Elem()
+	}
+	if target.Kind() == reflect.Interface && reflect.TypeOf(native).AssignableTo(target.Type()) {
+		target.Set(reflect.ValueOf(native))
+		return nil
+	}
+
+	switch {
+	case field.kind == "map":
+		entries, ok := native.(map[string]interface{})
+		if !ok || target.Kind() != reflect.Map {
+			return fmt.Errorf("a map can't be read into %s", target.Type())
+		}
+		target.Set(reflect.MakeMapWithSize(target.Type(), len(entries)))
+		valueKind := decoder.valueKind(field.fullType)
+		for key, value := range entries {
+			mapKey := reflect.New(target.Type().Key()).Elem()
+			if err := assignProtoMapKey(key, mapKey); err != nil {
+				return err
+			}
+			mapValue := reflect.New(target.Type().Elem()).Elem()
+			if value != nil {
+				if err := decoder.assign(valueKind, field.fullType, value, mapValue); err != nil {
+					return err
+				}
+			}
+			target.SetMapIndex(mapKey, mapValue)
+		}
+		return nil
+	case field.label == "repeated":
+		items, ok := native.([]interface{})
+		if !ok || target.Kind() != reflect.Slice {
+			return fmt.Errorf("a repeated field can't be read into %s", target.Type())
+		}
+		slice := reflect.MakeSlice(target.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decoder.assign(field.kind, field.fullType, item, slice.Index(i)); err != nil {
+				return err
+			}
+		}
+		target.Set(slice)
+		return nil
+	}
+	return decoder.assign(field.kind, field.fullType, native, target)
+}
+
+// assign stores a single native value into target,
+// allocating the values pointers point to.
+func (decoder *protobufDecoder) assign(kind, typ string, native interface{}, target reflect.Value) error {
+	if native == nil {
+		return nil
+	}
+	for target.Kind() == reflect.Ptr {
+		target.Set(reflect.New(target.Type().Elem()))
+		target = target.Elem()
+	}
+	value := reflect.ValueOf(native)
+	if target.Kind() == reflect.Interface && value.Type().AssignableTo(target.Type()) {
+		target.Set(value)
+		return nil
+	}
+
+	switch kind {
+	case "message":
+		if message := decoder.messages[typ]; message != nil && target.Kind() == reflect.Struct {
+			fields, ok := native.(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("expected the message %s, found %T", typ, native)
+			}
+			return decoder.mapMessage(message, fields, target)
+		}
+	case "enum":
+		enum := decoder.enums[typ]
+		switch {
+		case target.Kind() == reflect.String:
+			target.SetString(fmt.Sprint(native))
+			return nil
+		case isInteger(target.Kind()) && value.Kind() == reflect.String && enum != nil:
+			for _, enumValue := range enum.values {
+				if enumValue.name == value.String() {
+					value = reflect.ValueOf(int32(enumValue.number))
+				}
+			}
+		}
+	}
+
+	if value.Type().AssignableTo(target.Type()) {
+		target.Set(value)
+		return nil
+	}
+	if !convertibleNative(value.Type(), target.Type()) || overflows(value, target.Type()) {
+		return fmt.Errorf("a value of the type %s can't be read into %s", typ, target.Type())
+	}
+	target.Set(value.Convert(target.Type()))
+	return nil
+}
+
+// assignProtoMapKey parses the string form of a map key,
+// which protobufDecoder returns, into target.
+func assignProtoMapKey(key string, target reflect.Value) error {
+	var err error
+	switch kind := target.Kind(); {
+	case kind == reflect.String:
+		target.SetString(key)
+	case kind == reflect.Bool:
+		var b bool
+		if b, err = strconv.ParseBool(key); err == nil {
+			target.SetBool(b)
+		}
+	case isUnsigned(kind):
+		var u uint64
+		if u, err = strconv.ParseUint(key, 10, target.Type().Bits()); err == nil {
+			target.SetUint(u)
+		}
+	case isInteger(kind):
+		var i int64
+		if i, err = strconv.ParseInt(key, 10, target.Type().Bits()); err == nil {
+			target.SetInt(i)
+		}
+	default:
+		return fmt.Errorf("map keys can't be read into %s", target.Type())
+	}
+	return err
+}
@@ -0,0 +1,653 @@
+package srclient
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// protoFile is a parsed .proto file. Like avroType, it exists because
+// reasoning about schemas (checking compatibility, normalizing them)
+// needs their structure, and the library doesn't depend on a Protobuf
+// implementation. Options are kept verbatim, as "name = value" pairs.
+type protoFile struct {
+	syntax   string
+	pkg      string
+	imports  []string
+	options  []string
+	messages []*protoMessage
+	enums    []*protoEnum
+	services []*protoService
+}
+
+type protoMessage struct {
+	name     string
+	fullName string
+	fields   []*protoField
+	oneofs   []*protoOneof
+	messages []*protoMessage
+	enums    []*protoEnum
+	options  []string
+	reserved []string
+}
+
+type protoField struct {
+	name    string
+	label   string
+	typ     string
+	number  int
+	oneof   string
+	options []string
+	// mapKey and mapValue are set for map fields, whose typ is "map".
+	mapKey   string
+	mapValue string
+	// kind is "scalar", "message", "enum" or "map", and fullType is
+	// the fully qualified name of message and enum types, both set
+	// once the types of the whole file are resolved.
+	kind     string
+	fullType string
+}
+
+type protoOneof struct {
+	name    string
+	options []string
+}
+
+type protoEnum struct {
+	name     string
+	fullName string
+	values   []*protoEnumValue
+	options  []string
+	reserved []string
+}
+
+type protoEnumValue struct {
+	name    string
+	number  int
+	options []string
+}
+
+type protoService struct {
+	name    string
+	methods []*protoMethod
+	options []string
+}
+
+type protoMethod struct {
+	name          string
+	request       string
+	response      string
+	clientStreams bool
+	serverStreams bool
+	options       []string
+}
+
+var protoScalars = map[string]bool{
+	"double": true, "float": true, "int32": true, "int64": true, "uint32": true, "uint64": true,
+	"sint32": true, "sint64": true, "fixed32": true, "fixed64": true, "sfixed32": true, "sfixed64": true,
+	"bool": true, "string": true, "bytes": true,
+}
+
+// parseProtobufSchema parses the given .proto file.
+func parseProtobufSchema(schema string) (*protoFile, error) {
+	tokens, err := tokenizeProto(schema)
+	if err != nil {
+		return nil, err
+	}
+	parser := &protoParser{tokens: tokens}
+	file, err := parser.parseFile()
+	if err != nil {
+		return nil, fmt.Errorf("invalid protobuf schema: %w", err)
+	}
+	file.resolve()
+	return file, nil
+}
+
+func tokenizeProto(schema string) ([]string, error) {
+	var tokens []string
+	runes := []rune(schema)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			i += 2
+			for i+1 < len(runes) && !(runes[i] == '*' && runes[i+1] == '/') {
+				i++
+			}
+			if i+1 >= len(runes) {
+				return nil, fmt.Errorf("invalid protobuf schema: unterminated comment")
+			}
+			i += 2
+		case r == '"' || r == '\'':
+			j := i + 1
+			for j < len(runes) && runes[j] != r {
+				if runes[j] == '\\' {
+					j++
+				}
+				j++
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("invalid protobuf schema: unterminated string")
+			}
+			tokens = append(tokens, "\""+string(runes[i+1:j])+"\"")
+			i = j + 1
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.' || r == '-' || r == '+':
+			j := i + 1
+			for j < len(runes) && (unicode.IsLetter(runes[j]) || unicode.IsDigit(runes[j]) || runes[j] == '_' || runes[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, string(runes[i:j]))
+			i = j
+		default:
+			tokens = append(tokens, string(r))
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+type protoParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *protoParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *protoParser) next() string {
+	token := p.peek()
+	p.pos++
+	return token
+}
+
+func (p *protoParser) expect(expected string) error {
+	if token := p.next(); token != expected {
+		return fmt.Errorf("expected %q but found %q", expected, token)
+	}
+	return nil
+}
+
+// statement collects the tokens up to the next semicolon, keeping
+// any aggregate value between braces, and returns them as a string.
+func (p *protoParser) statement() (string, error) {
+	var parts []string
+	depth := 0
+	for {
+		token := p.next()
+		switch {
+		case len(token) == 0 && p.pos > len(p.tokens):
+			return "", fmt.Errorf("unexpected end of schema")
+		case token == ";" && depth == 0:
+			return joinProtoTokens(parts), nil
+		case token == "{":
+			depth++
+		case token == "}":
+			depth--
+		}
+		parts = append(parts, token)
+	}
+}
+
+func (p *protoParser) parseFile() (*protoFile, error) {
+	file := &protoFile{syntax: "proto2"}
+	for p.pos < len(p.tokens) {
+		switch token := p.next(); token {
+		case "syntax", "edition":
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			file.syntax = strings.Trim(p.next(), "\"")
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case "package":
+			file.pkg = p.next()
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case "import":
+			if p.peek() == "public" || p.peek() == "weak" {
+				p.next()
+			}
+			file.imports = append(file.imports, strings.Trim(p.next(), "\""))
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+		case "option":
+			option, err := p.statement()
+			if err != nil {
+				return nil, err
+			}
+			file.options = append(file.options, option)
+		case "message":
+			message, err := p.parseMessage(file.pkg)
+			if err != nil {
+				return nil, err
+			}
+			file.messages = append(file.messages, message)
+		case "enum":
+			enum, err := p.parseEnum(file.pkg)
+			if err != nil {
+				return nil, err
+			}
+			file.enums = append(file.enums, enum)
+		case "service":
+			service, err := p.parseService()
+			if err != nil {
+				return nil, err
+			}
+			file.services = append(file.services, service)
+		case "extend":
+			if err := p.skipBlock(); err != nil {
+				return nil, err
+			}
+		case ";":
+		default:
+			return nil, fmt.Errorf("unexpected %q", token)
+		}
+	}
+	return file, nil
+}
+
+func qualify(scope, name string) string {
+	if len(scope) == 0 {
+		return name
+	}
+	return scope + "." + name
+}
+
+func (p *protoParser) parseMessage(scope string) (*protoMessage, error) {
+	message := &protoMessage{name: p.next()}
+	message.fullName = qualify(scope, message.name)
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for {
+		token := p.peek()
+		if token != "option" && p.pos+2 < len(p.tokens) && p.tokens[p.pos+2] == "=" {
+			// A field whose type is named like a keyword, e.g. "message message = 1;".
+			token = "field"
+		}
+		switch token {
+		case "}":
+			p.next()
+			return message, nil
+		case "":
+			return nil, fmt.Errorf("unterminated message %s", message.name)
+		case ";":
+			p.next()
+		case "message":
+			p.next()
+			nested, err := p.parseMessage(message.fullName)
+			if err != nil {
+				return nil, err
+			}
+			message.messages = append(message.messages, nested)
+		case "enum":
+			p.next()
+			enum, err := p.parseEnum(message.fullName)
+			if err != nil {
+				return nil, err
+			}
+			message.enums = append(message.enums, enum)
+		case "option":
+			p.next()
+			option, err := p.statement()
+			if err != nil {
+				return nil, err
+			}
+			message.options = append(message.options, option)
+		case "reserved":
+			p.next()
+			reserved, err := p.statement()
+			if err != nil {
+				return nil, err
+			}
+			message.reserved = append(message.reserved, reserved)
+		case "extensions":
+			p.next()
+			if _, err := p.statement(); err != nil {
+				return nil, err
+			}
+		case "extend":
+			p.next()
+			if err := p.skipBlock(); err != nil {
+				return nil, err
+			}
+		case "oneof":
+			p.next()
+			oneof := &protoOneof{name: p.next()}
+			if err := p.expect("{"); err != nil {
+				return nil, err
+			}
+			for p.peek() != "}" {
+				if p.peek() == "option" {
+					p.next()
+					option, err := p.statement()
+					if err != nil {
+						return nil, err
+					}
+					oneof.options = append(oneof.options, option)
+					continue
+				}
+				field, err := p.parseField()
+				if err != nil {
+					return nil, err
+				}
+				field.oneof = oneof.name
+				message.fields = append(message.fields, field)
+			}
+			p.next()
+			message.oneofs = append(message.oneofs, oneof)
+		default:
+			field, err := p.parseField()
+			if err != nil {
+				return nil, err
+			}
+			message.fields = append(message.fields, field)
+		}
+	}
+}
+
+func (p *protoParser) parseField() (*protoField, error) {
+	field := &protoField{}
+	switch p.peek() {
+	case "optional", "required", "repeated":
+		field.label = p.next()
+	}
+	field.typ = p.next()
+	if field.typ == "map" {
+		if err := p.expect("<"); err != nil {
+			return nil, err
+		}
+		field.mapKey = p.next()
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		field.mapValue = p.next()
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+	}
+	field.name = p.next()
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	number, err := strconv.Atoi(p.next())
+	if err != nil {
+		return nil, fmt.Errorf("invalid number for field %s", field.name)
+	}
+	field.number = number
+	options, err := p.parseOptionList()
+	if err != nil {
+		return nil, err
+	}
+	field.options = options
+	return field, p.expect(";")
+}
+
+// parseOptionList parses the options between brackets
+// that may follow fields, enum values and so on.
+func (p *protoParser) parseOptionList() ([]string, error) {
+	if p.peek() != "[" {
+		return nil, nil
+	}
+	p.next()
+	var options []string
+	var parts []string
+	depth := 0
+	for {
+		token := p.next()
+		switch {
+		case len(token) == 0:
+			return nil, fmt.Errorf("unterminated option list")
+		case token == "]" && depth == 0:
+			return append(options, joinProtoTokens(parts)), nil
+		case token == "," && depth == 0:
+			options = append(options, joinProtoTokens(parts))
+			parts = nil
+			continue
+		case token == "{" || token == "[":
+			depth++
+		case token == "}" || token == "]":
+			depth--
+		}
+		parts = append(parts, token)
+	}
+}
+
+func (p *protoParser) parseEnum(scope string) (*protoEnum, error) {
+	enum := &protoEnum{name: p.next()}
+	enum.fullName = qualify(scope, enum.name)
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for {
+		switch token := p.next(); token {
+		case "}":
+			return enum, nil
+		case "":
+			return nil, fmt.Errorf("unterminated enum %s", enum.name)
+		case ";":
+		case "option":
+			option, err := p.statement()
+			if err != nil {
+				return nil, err
+			}
+			enum.options = append(enum.options, option)
+		case "reserved":
+			reserved, err := p.statement()
+			if err != nil {
+				return nil, err
+			}
+			enum.reserved = append(enum.reserved, reserved)
+		default:
+			value := &protoEnumValue{name: token}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			number, err := strconv.Atoi(p.next())
+			if err != nil {
+				return nil, fmt.Errorf("invalid number for enum value %s", value.name)
+			}
+			value.number = number
+			if value.options, err = p.parseOptionList(); err != nil {
+				return nil, err
+			}
+			if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+			enum.values = append(enum.values, value)
+		}
+	}
+}
+
+func (p *protoParser) parseService() (*protoService, error) {
+	service := &protoService{name: p.next()}
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	for {
+		switch token := p.next(); token {
+		case "}":
+			return service, nil
+		case "":
+			return nil, fmt.Errorf("unterminated service %s", service.name)
+		case ";":
+		case "option":
+			option, err := p.statement()
+			if err != nil {
+				return nil, err
+			}
+			service.options = append(service.options, option)
+		case "rpc":
+			method := &protoMethod{name: p.next()}
+			if err := p.expect("("); err != nil {
+				return nil, err
+			}
+			if p.peek() == "stream" {
+				p.next()
+				method.clientStreams = true
+			}
+			method.request = p.next()
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			if err := p.expect("returns"); err != nil {
+				return nil, err
+			}
+			if err := p.expect("("); err != nil {
+				return nil, err
+			}
+			if p.peek() == "stream" {
+				p.next()
+				method.serverStreams = true
+			}
+			method.response = p.next()
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+			if p.peek() == "{" {
+				p.next()
+				for p.peek() != "}" {
+					if p.next() == "option" {
+						option, err := p.statement()
+						if err != nil {
+							return nil, err
+						}
+						method.options = append(method.options, option)
+					}
+					if p.pos >= len(p.tokens) {
+						return nil, fmt.Errorf("unterminated rpc %s", method.name)
+					}
+				}
+				p.next()
+			} else if err := p.expect(";"); err != nil {
+				return nil, err
+			}
+			service.methods = append(service.methods, method)
+		default:
+			return nil, fmt.Errorf("unexpected %q in service %s", token, service.name)
+		}
+	}
+}
+
+// skipBlock skips a declaration up to its closing brace.
+func (p *protoParser) skipBlock() error {
+	for p.peek() != "{" {
+		if p.pos >= len(p.tokens) {
+			return fmt.Errorf("unexpected end of schema")
+		}
+		p.next()
+	}
+	depth := 0
+	for {
+		switch p.next() {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		case "":
+			if p.pos > len(p.tokens) {
+				return fmt.Errorf("unexpected end of schema")
+			}
+		}
+	}
+}
+
+// joinProtoTokens rebuilds an option from its tokens,
+// with spaces only around the equal sign.
+func joinProtoTokens(tokens []string) string {
+	var builder strings.Builder
+	for i, token := range tokens {
+		if token == "=" || (i > 0 && tokens[i-1] == "=") || (i > 0 && token == "{") || (i > 0 && tokens[i-1] == "{") || token == "}" {
+			builder.WriteString(" ")
+		}
+		builder.WriteString(token)
+	}
+	return strings.TrimSpace(builder.String())
+}
+
+// resolve sets the kind and the fully qualified type of every field,
+// following the scoping rules of Protobuf. Types that are not defined
+// in the file, typically imported ones, are assumed to be messages.
+func (file *protoFile) resolve() {
+	kinds := map[string]string{}
+	var collect func(messages []*protoMessage, enums []*protoEnum)
+	collect = func(messages []*protoMessage, enums []*protoEnum) {
+		for _, enum := range enums {
+			kinds[enum.fullName] = "enum"
+		}
+		for _, message := range messages {
+			kinds[message.fullName] = "message"
+			collect(message.messages, message.enums)
+		}
+	}
+	collect(file.messages, file.enums)
+
+	lookup := func(scope, name string) (string, string) {
+		if protoScalars[name] {
+			return "scalar", name
+		}
+		if strings.HasPrefix(name, ".") {
+			name = name[1:]
+			if kind, ok := kinds[name]; ok {
+				return kind, name
+			}
+			return "message", name
+		}
+		for {
+			candidate := qualify(scope, name)
+			if kind, ok := kinds[candidate]; ok {
+				return kind, candidate
+			}
+			if len(scope) == 0 {
+				return "message", name
+			}
+			if i := strings.LastIndex(scope, "."); i >= 0 {
+				scope = scope[:i]
+			} else {
+				scope = ""
+			}
+		}
+	}
+
+	for _, message := range file.allMessages() {
+		for _, field := range message.fields {
+			if field.typ == "map" {
+				field.kind = "map"
+				_, field.fullType = lookup(message.fullName, field.mapValue)
+				continue
+			}
+			field.kind, field.fullType = lookup(message.fullName, field.typ)
+		}
+	}
+}
+
+// allMessages returns the messages of the file, nested ones included.
+func (file *protoFile) allMessages() []*protoMessage {
+	var all []*protoMessage
+	var walk func(messages []*protoMessage)
+	walk = func(messages []*protoMessage) {
+		for _, message := range messages {
+			all = append(all, message)
+			walk(message.messages)
+		}
+	}
+	walk(file.messages)
+	return all
+}
@@ -0,0 +1,169 @@
+package srclient
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Header is a header of a record, such as the headers of Kafka records.
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Headers that hold the schema ID of the key and of the value of a
+// record, when it isn't prefixed to the data.
+const (
+	KeySchemaIDHeader   = "__key_schema_id"
+	ValueSchemaIDHeader = "__value_schema_id"
+)
+
+// guidMagicByte starts the schema IDs written as GUIDs, followed by
+// the 16 bytes of the GUID, as magicByte starts numeric schema IDs.
+const guidMagicByte = byte(1)
+
+// SchemaIDPlacement tells where SerializeRecord writes the schema ID.
+type SchemaIDPlacement int
+
+const (
+	// PayloadPrefix writes the schema ID before the data, which is
+	// the wire format of Schema Registry.
+	PayloadPrefix SchemaIDPlacement = iota
+	// RecordHeader writes the schema ID in the KeySchemaIDHeader or
+	// ValueSchemaIDHeader header, leaving the data alone.
+	RecordHeader
+)
+
+// SchemaIDFormat tells how a Serializer writes schema IDs.
+type SchemaIDFormat int
+
+const (
+	// NumericSchemaID writes the magic byte 0 followed by the schema
+	// ID in four bytes.
+	NumericSchemaID SchemaIDFormat = iota
+	// GUIDSchemaID writes the magic byte 1 followed by the GUID newer
+	// registries give to schemas, in 16 bytes.
+	GUIDSchemaID
+)
+
+// WithSchemaIDPlacement sets where SerializeRecord writes schema IDs.
+// They are prefixed to the data by default.
+func WithSchemaIDPlacement(placement SchemaIDPlacement) SerializerOption {
+	return SerializerOption(func(serializer *Serializer) {
+		serializer.idPlacement = placement
+	})
+}
+
+// WithSchemaIDFormat sets how schema IDs are written, which is as
+// numbers by default.
+func WithSchemaIDFormat(format SchemaIDFormat) SerializerOption {
+	return SerializerOption(func(serializer *Serializer) {
+		serializer.idFormat = format
+	})
+}
+
+// SerializeRecord encodes the key or the value of a record with the
+// given schema, and returns the headers to add to the record along
+// with the data. Headers are only returned when schema IDs are placed
+// in headers, see WithSchemaIDPlacement.
+func (serializer *Serializer) SerializeRecord(schema *Schema, value interface{}, isKey bool) ([]Header, []byte, error) {
+	if serializer.idPlacement == PayloadPrefix {
+		payload, err := serializer.Serialize(schema, value)
+		return nil, payload, err
+	}
+	id, err := serializer.schemaIDBytes(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	payload, err := serializer.appendValue(nil, schema, value)
+	if err != nil {
+		return nil, nil, err
+	}
+	return []Header{{Key: schemaIDHeader(isKey), Value: id}}, payload, nil
+}
+
+func (serializer *Serializer) schemaIDBytes(schema *Schema) ([]byte, error) {
+	if serializer.idFormat == GUIDSchemaID {
+		if len(schema.guid) == 0 {
+			return nil, fmt.Errorf("schema %d has no GUID", schema.ID())
+		}
+		guid, err := parseUUID(schema.guid)
+		if err != nil {
+			return nil, fmt.Errorf("schema %d has an invalid GUID: %w", schema.ID(), err)
+		}
+		return append([]byte{guidMagicByte}, guid...), nil
+	}
+	id := make([]byte, 5)
+	id[0] = magicByte
+	binary.BigEndian.PutUint32(id[1:], uint32(schema.ID()))
+	return id, nil
+}
+
+// DeserializeRecord decodes the key or the value of a record like
+// Deserialize does. The schema ID is read from the KeySchemaIDHeader
+// or ValueSchemaIDHeader header when the record has it, or else from
+// the prefix of the data. Both numeric IDs and GUIDs are supported.
+func (deserializer *Deserializer) DeserializeRecord(headers []Header, payload []byte, isKey bool) (interface{}, error) {
+	schemaID, schema, payload, err := deserializer.splitRecord(headers, payload, isKey)
+	if err != nil {
+		return nil, err
+	}
+	native, _, err := deserializer.decode(schemaID, schema, payload)
+	return native, err
+}
+
+// DeserializeRecordInto decodes the key or the value of a record
+// into the value v points to, like DeserializeInto does, reading the
+// schema ID as DeserializeRecord does.
+func (deserializer *Deserializer) DeserializeRecordInto(headers []Header, payload []byte, isKey bool, v interface{}) error {
+	schemaID, schema, payload, err := deserializer.splitRecord(headers, payload, isKey)
+	if err != nil {
+		return err
+	}
+	return deserializer.decodeInto(schemaID, schema, payload, v)
+}
+
+// splitRecord returns the schema ID of a record and its data. The last
+// header holding the schema ID wins, like Kafka clients do.
+func (deserializer *Deserializer) splitRecord(headers []Header, payload []byte, isKey bool) (int, *Schema, []byte, error) {
+	key := schemaIDHeader(isKey)
+	for i := len(headers) - 1; i >= 0; i-- {
+		if headers[i].Key != key {
+			continue
+		}
+		schemaID, schema, rest, err := deserializer.splitSchemaID(headers[i].Value)
+		if err != nil {
+			return 0, nil, nil, fmt.Errorf("invalid %s header: %w", key, err)
+		}
+		if len(rest) > 0 {
+			return 0, nil, nil, fmt.Errorf("invalid %s header: %w", key, ErrInvalidWireFormat)
+		}
+		return schemaID, schema, payload, nil
+	}
+	return deserializer.splitSchemaID(payload)
+}
+
+// splitSchemaID reads a schema ID written as a number or a GUID,
+// returning the bytes that follow it. Schemas identified by a GUID
+// are looked up, so they are returned along with their ID.
+func (deserializer *Deserializer) splitSchemaID(data []byte) (int, *Schema, []byte, error) {
+	if len(data) > 0 && data[0] == guidMagicByte {
+		if len(data) < 17 {
+			return 0, nil, nil, ErrInvalidWireFormat
+		}
+		schema, err := deserializer.client.GetSchemaByGUID(formatUUID(data[1:17]))
+		if err != nil {
+			return 0, nil, nil, err
+		}
+		return schema.ID(), schema, data[17:], nil
+	}
+	schemaID, rest, err := splitWireFormat(data)
+	return schemaID, nil, rest, err
+}
+
+func schemaIDHeader(isKey bool) string {
+	if isKey {
+		return KeySchemaIDHeader
+	}
+	return ValueSchemaIDHeader
+}
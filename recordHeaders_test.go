@@ -0,0 +1,88 @@
+package srclient
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordHeaders_Placement(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("native", schema, Avro, false)
+	assert.NoError(t, err)
+	value := map[string]interface{}{"aField": 5}
+
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+
+	headers, payload, err := NewSerializer(mockClient).SerializeRecord(registered, value, false)
+	assert.NoError(t, err)
+	assert.Nil(t, headers)
+	assert.Equal(t, []byte{magicByte, 0, 0, 0, byte(registered.ID())}, payload[:5])
+	native, err := deserializer.DeserializeRecord(nil, payload, false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"aField": int32(5)}, native)
+
+	serializer := NewSerializer(mockClient, WithSchemaIDPlacement(RecordHeader))
+	headers, payload, err = serializer.SerializeRecord(registered, value, true)
+	assert.NoError(t, err)
+	assert.Equal(t, []Header{{Key: KeySchemaIDHeader, Value: []byte{magicByte, 0, 0, 0, byte(registered.ID())}}}, headers)
+	assert.Equal(t, []byte{10}, payload)
+
+	headers = append([]Header{{Key: "trace", Value: []byte("abc")}}, headers...)
+	native, err = deserializer.DeserializeRecord(headers, payload, true)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"aField": int32(5)}, native)
+	var decoded struct {
+		A int32 `avro:"aField"`
+	}
+	assert.NoError(t, deserializer.DeserializeRecordInto(headers, payload, true, &decoded))
+	assert.Equal(t, int32(5), decoded.A)
+
+	// The header of the key isn't used for values.
+	_, err = deserializer.DeserializeRecord(headers, payload, false)
+	assert.Equal(t, ErrInvalidWireFormat, err)
+	_, err = deserializer.DeserializeRecord([]Header{{Key: ValueSchemaIDHeader, Value: []byte{9}}}, payload, false)
+	assert.True(t, errors.Is(err, ErrInvalidWireFormat))
+}
+
+func TestRecordHeaders_GUID(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("native", schema, Avro, false)
+	assert.NoError(t, err)
+	value := map[string]interface{}{"aField": 5}
+
+	guid, err := parseUUID(registered.GUID())
+	assert.NoError(t, err)
+
+	serializer := NewSerializer(mockClient, WithSchemaIDFormat(GUIDSchemaID), WithSchemaIDPlacement(RecordHeader))
+	headers, payload, err := serializer.SerializeRecord(registered, value, false)
+	assert.NoError(t, err)
+	assert.Equal(t, ValueSchemaIDHeader, headers[0].Key)
+	assert.Equal(t, append([]byte{guidMagicByte}, guid...), headers[0].Value)
+
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+	native, err := deserializer.DeserializeRecord(headers, payload, false)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"aField": int32(5)}, native)
+
+	// GUIDs can prefix the data as well.
+	record, err := NewSerializer(mockClient, WithSchemaIDFormat(GUIDSchemaID)).Serialize(registered, value)
+	assert.NoError(t, err)
+	assert.Len(t, record, 18)
+	native, err = deserializer.Deserialize(record)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"aField": int32(5)}, native)
+
+	// Schemas without a GUID can't be written with one.
+	_, err = serializer.Serialize(&Schema{id: 9, schema: schema}, value)
+	assert.Error(t, err)
+
+	// The GUID isn't known to other registries.
+	unknown, err := NewDeserializer(CreateMockSchemaRegistryClient("mock://testingUrl"))
+	assert.NoError(t, err)
+	_, err = unknown.Deserialize(record)
+	assert.Error(t, err)
+}
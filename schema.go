@@ -1,6 +1,11 @@
 package srclient
 
-import "github.com/linkedin/goavro/v2"
+import (
+	"fmt"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+)
 
 type SchemaType string
 
@@ -23,6 +28,17 @@ type Reference struct {
 	Version int    `json:"version"`
 }
 
+// CompatibilityResult holds the outcome of a compatibility
+// check. Messages lists the incompatibilities that were
+// found, and is empty when the schema is compatible. The
+// structured Incompatibilities are only available for the
+// checks done offline by CheckCompatibility.
+type CompatibilityResult struct {
+	IsCompatible      bool              `json:"is_compatible"`
+	Messages          []string          `json:"messages"`
+	Incompatibilities []Incompatibility `json:"-"`
+}
+
 type credentials struct {
 	username string
 	password string
@@ -35,19 +51,27 @@ type schemaRequest struct {
 }
 
 type schemaResponse struct {
-	Subject string `json:"subject"`
-	Version int    `json:"version"`
-	Schema  string `json:"schema"`
-	ID      int    `json:"id"`
+	Subject    string `json:"subject"`
+	Version    int    `json:"version"`
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
+	ID         int    `json:"id"`
+	GUID       string `json:"guid,omitempty"`
 }
 
 // Schema is a data structure that holds all
 // the relevant information about schemas.
 type Schema struct {
-	id      int
-	schema  string
-	version int
-	codec   *goavro.Codec
+	id         int
+	guid       string
+	schema     string
+	schemaType SchemaType
+	version    int
+	codec      *goavro.Codec
+
+	canonicalOnce sync.Once
+	canonicalForm string
+	canonicalErr  error
 }
 
 // ID ensures access to ID
@@ -55,11 +79,26 @@ func (schema *Schema) ID() int {
 	return schema.id
 }
 
+// GUID ensures access to the GUID that newer registries give to
+// schemas, which is empty for the registries that don't.
+func (schema *Schema) GUID() string {
+	return schema.guid
+}
+
 // Schema ensures access to Schema
 func (schema *Schema) Schema() string {
 	return schema.schema
 }
 
+// SchemaType ensures access to SchemaType. Schema Registry
+// omits the type of Avro schemas, which is the default.
+func (schema *Schema) SchemaType() SchemaType {
+	if len(schema.schemaType) == 0 {
+		return Avro
+	}
+	return schema.schemaType
+}
+
 // Version ensures access to Version
 func (schema *Schema) Version() int {
 	return schema.version
@@ -69,3 +108,27 @@ func (schema *Schema) Version() int {
 func (schema *Schema) Codec() *goavro.Codec {
 	return schema.codec
 }
+
+// CanonicalForm returns the Parsing Canonical Form of Avro schemas,
+// which identifies a schema regardless of its formatting.
+func (schema *Schema) CanonicalForm() (string, error) {
+	schema.canonicalOnce.Do(func() {
+		if schema.SchemaType() != Avro {
+			schema.canonicalErr = fmt.Errorf("canonical form is only defined for Avro schemas, not %s", schema.SchemaType())
+			return
+		}
+		schema.canonicalForm, schema.canonicalErr = AvroCanonicalForm(schema.schema)
+	})
+	return schema.canonicalForm, schema.canonicalErr
+}
+
+// Fingerprint returns the CRC-64-AVRO fingerprint of the canonical
+// form of the schema. FingerprintMD5 and FingerprintSHA256 can be
+// used on the canonical form for the other common fingerprints.
+func (schema *Schema) Fingerprint() (uint64, error) {
+	canonicalForm, err := schema.CanonicalForm()
+	if err != nil {
+		return 0, err
+	}
+	return FingerprintCRC64(canonicalForm), nil
+}
@@ -0,0 +1,81 @@
+package srclient
+
+import (
+	"net/url"
+	"strings"
+)
+
+// defaultContext is the name of the default schema context, in
+// which subjects and schema IDs are used as they are.
+const defaultContext = "."
+
+// WithSchemaContext makes the client work in the given schema context,
+// which registries use to keep apart the subjects and schema IDs of
+// tenants. Subjects are qualified with the context, as in
+// ":.tenant:orders-value", unless they are qualified already, and
+// schema IDs are looked up in the context. Context names start with a
+// dot, which is added when missing.
+func WithSchemaContext(name string) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		client.contextName = normalizeContextName(name)
+	})
+}
+
+// WithContextName returns a view of the client in the given schema
+// context, as set by WithSchemaContext. The view has the settings of
+// the client, and shares its connection and its cache, in which
+// schemas are kept apart by context. Passing "." returns a view in
+// the default context.
+func (client *SchemaRegistryClient) WithContextName(name string) *SchemaRegistryClient {
+	view := &SchemaRegistryClient{
+		clientSettings:       client.clientSettings,
+		cachingEnabled:       client.isCachingEnabled(),
+		codecCreationEnabled: client.isCodecCreationEnabled(),
+		schemaCache:          client.schemaCache,
+	}
+	view.contextName = normalizeContextName(name)
+	return view
+}
+
+// ContextName returns the schema context of the client, which is
+// empty for the default context.
+func (client *SchemaRegistryClient) ContextName() string {
+	return client.contextName
+}
+
+// QualifiedSubject returns the subject name qualified with the schema
+// context of the client, such as ":.tenant:orders".
+func (client *SchemaRegistryClient) QualifiedSubject(subject string) string {
+	return client.qualifySubject(subject)
+}
+
+func (client *SchemaRegistryClient) concreteSubject(subject string, isKey bool) string {
+	return client.qualifySubject(getConcreteSubject(subject, isKey))
+}
+
+func (client *SchemaRegistryClient) qualifySubject(subject string) string {
+	if len(client.contextName) == 0 || strings.HasPrefix(subject, ":.") {
+		return subject
+	}
+	return ":" + client.contextName + ":" + subject
+}
+
+// contextQuery returns the URL query that scopes lookups
+// by schema ID to the schema context of the client.
+func (client *SchemaRegistryClient) contextQuery() string {
+	if len(client.contextName) == 0 {
+		return ""
+	}
+	return "?" + url.Values{"subject": {client.qualifySubject("")}}.Encode()
+}
+
+func normalizeContextName(name string) string {
+	name = strings.Trim(strings.TrimSpace(name), ":")
+	if len(name) == 0 || name == defaultContext {
+		return ""
+	}
+	if !strings.HasPrefix(name, ".") {
+		name = "." + name
+	}
+	return name
+}
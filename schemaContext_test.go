@@ -0,0 +1,115 @@
+package srclient
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSchemaContext_Subjects(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		paths = append(paths, req.URL.String())
+		switch req.URL.Path {
+		case "/subjects":
+			rw.Write([]byte(`[":.tenant:orders-value"]`))
+		case "/subjects/:.tenant:orders-key/versions":
+			rw.Write([]byte(`[1]`))
+		default:
+			response, _ := json.Marshal(schemaResponse{Subject: ":.tenant:orders-value", Version: 1, Schema: "test2", ID: 1})
+			rw.Write(response)
+		}
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL, WithSchemaContext("tenant"))
+	srClient.SetCodecCreationEnabled(false)
+	assert.Equal(t, ".tenant", srClient.ContextName())
+	assert.Equal(t, ":.tenant:orders", srClient.QualifiedSubject("orders"))
+	assert.Equal(t, ":.other:orders", srClient.QualifiedSubject(":.other:orders"))
+
+	subjects, err := srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{":.tenant:orders-value"}, subjects)
+	_, err = srClient.GetLatestSchema("orders", false)
+	assert.NoError(t, err)
+	_, err = srClient.GetSchemaVersions("orders", true)
+	assert.NoError(t, err)
+	assert.NoError(t, srClient.DeleteSubject("orders-value", false))
+
+	assert.Equal(t, []string{
+		"/subjects?subjectPrefix=%3A.tenant%3A",
+		"/subjects/:.tenant:orders-value/versions/latest",
+		"/subjects/:.tenant:orders-key/versions",
+		"/subjects/:.tenant:orders-value",
+	}, paths)
+
+	// The default context leaves subjects alone.
+	for _, name := range []string{"", ".", ":.:"} {
+		assert.Equal(t, "orders", srClient.WithContextName(name).QualifiedSubject("orders"))
+	}
+}
+
+func TestSchemaContext_SchemaIDs(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		assert.Equal(t, "/schemas/ids/1", req.URL.Path)
+		// The same ID names another schema in each context.
+		schema := "default"
+		if subject := req.URL.Query().Get("subject"); len(subject) > 0 {
+			schema = subject
+		}
+		response, _ := json.Marshal(schemaResponse{Schema: schema, SchemaType: Json.String(), ID: 1})
+		rw.Write(response)
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClient(server.URL)
+	tenant := srClient.WithContextName(".tenant")
+	other := srClient.WithContextName("other")
+
+	for i := 0; i < 2; i++ {
+		schema, err := srClient.GetSchemaByID(1)
+		assert.NoError(t, err)
+		assert.Equal(t, "default", schema.Schema())
+		schema, err = tenant.GetSchemaByID(1)
+		assert.NoError(t, err)
+		assert.Equal(t, ":.tenant:", schema.Schema())
+		schema, err = other.GetSchemaByID(1)
+		assert.NoError(t, err)
+		assert.Equal(t, ":.other:", schema.Schema())
+	}
+	// The views share the cache of the client.
+	assert.Equal(t, 3, requests)
+	assert.Len(t, srClient.idSchemaCache, 3)
+}
+
+func TestSchemaContext_ViewKeepsSettings(t *testing.T) {
+	client := CreateSchemaRegistryClientWithOptions("https://localhost:8081",
+		WithTimeout(3*time.Second),
+		WithTLSServerName("registry.internal"),
+		WithMinTLSVersion(tls.VersionTLS12),
+		WithHeaders(map[string]string{"X-Team": "orders"}),
+		WithBearerToken("token"))
+	client.SetCachingEnabled(false)
+	view := client.WithContextName("tenant")
+
+	assert.Equal(t, ".tenant", view.ContextName())
+	assert.Equal(t, 3*time.Second, *view.timeout)
+	assert.Equal(t, 3*time.Second, view.httpClient.Timeout)
+	assert.Equal(t, "registry.internal", view.tls.serverName)
+	transport := view.httpClient.Transport.(*http.Transport)
+	assert.Equal(t, "registry.internal", transport.TLSClientConfig.ServerName)
+	assert.Equal(t, uint16(tls.VersionTLS12), transport.TLSClientConfig.MinVersion)
+	assert.False(t, view.isCachingEnabled())
+
+	settings := view.clientSettings
+	settings.contextName = client.contextName
+	assert.Equal(t, client.clientSettings, settings)
+}
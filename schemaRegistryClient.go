@@ -3,6 +3,7 @@ package srclient
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -24,21 +25,70 @@ import (
 // which in turn can be used to serialize and
 // deserialize data.
 type SchemaRegistryClient struct {
-	schemaRegistryURL        string
-	credentials              *credentials
-	httpClient               *http.Client
+	clientSettings
+
 	cachingEnabled           bool
 	cachingEnabledLock       sync.RWMutex
 	codecCreationEnabled     bool
 	codecCreationEnabledLock sync.RWMutex
 
-	idSchemaCache     map[int]*Schema
+	*schemaCache
+}
+
+// clientSettings holds what options set on a client, which
+// is copied as a whole into its views, see WithContextName.
+type clientSettings struct {
+	schemaRegistryURL     string
+	credentials           *credentials
+	authProvider          AuthProvider
+	headers               http.Header
+	headerFuncs           []HeaderFunc
+	operationInterceptors []OperationInterceptor
+	httpInterceptors      []HTTPInterceptor
+	httpClient            *http.Client
+	timeout               *time.Duration
+	tls                   *tlsSettings
+	configErr             error
+
+	// contextName is the schema context the client works in,
+	// see WithSchemaContext, which is empty for the default one.
+	contextName string
+
+	sem *semaphore.Weighted
+}
+
+// schemaCache holds the schemas returned by the registry. Schema IDs
+// are only unique within a schema context, so schemas are cached by
+// context and ID, which lets the views of a client in other contexts,
+// see WithContextName, share the cache.
+type schemaCache struct {
+	idSchemaCache     map[schemaIDKey]*Schema
 	idSchemaCacheLock sync.RWMutex
 
+	guidSchemaCache     map[string]*Schema
+	guidSchemaCacheLock sync.RWMutex
+
 	subjectSchemaCache     map[string]*Schema
 	subjectSchemaCacheLock sync.RWMutex
 
-	sem *semaphore.Weighted
+	// codecCache shares codecs among the schemas that only differ
+	// by formatting, which is common when subjects share schemas.
+	codecCache     map[[sha256.Size]byte]*goavro.Codec
+	codecCacheLock sync.RWMutex
+}
+
+type schemaIDKey struct {
+	contextName string
+	id          int
+}
+
+func newSchemaCache() *schemaCache {
+	return &schemaCache{
+		idSchemaCache:      make(map[schemaIDKey]*Schema),
+		guidSchemaCache:    make(map[string]*Schema),
+		subjectSchemaCache: make(map[string]*Schema),
+		codecCache:         make(map[[sha256.Size]byte]*goavro.Codec),
+	}
 }
 
 type isCompatibleResponse struct {
@@ -46,11 +96,14 @@ type isCompatibleResponse struct {
 }
 
 const (
-	schemaByID       = "/schemas/ids/%d"
-	subjectVersions  = "/subjects/%s/versions"
-	subjectByVersion = "/subjects/%s/versions/%s"
-	subjects         = "/subjects"
-	contentType      = "application/vnd.schemaregistry.v1+json"
+	schemaByID             = "/schemas/ids/%d"
+	schemaByGUIDPath       = "/schemas/guids/%s"
+	subjectVersions        = "/subjects/%s/versions"
+	subjectByVersion       = "/subjects/%s/versions/%s"
+	subjectBySchema        = "/subjects/%s"
+	subjects               = "/subjects"
+	compatibilityBySubject = "/compatibility/subjects/%s/versions"
+	contentType            = "application/vnd.schemaregistry.v1+json"
 )
 
 // CreateSchemaRegistryClient creates a client that allows
@@ -59,28 +112,56 @@ const (
 // in turn can be used to serialize and deserialize records.
 func CreateSchemaRegistryClient(schemaRegistryURL string) *SchemaRegistryClient {
 	return &SchemaRegistryClient{
-		schemaRegistryURL:    schemaRegistryURL,
-		httpClient:           &http.Client{Timeout: 5 * time.Second},
+		clientSettings: clientSettings{
+			schemaRegistryURL: schemaRegistryURL,
+			httpClient:        &http.Client{Timeout: 5 * time.Second},
+			sem:               semaphore.NewWeighted(16),
+		},
 		cachingEnabled:       true,
 		codecCreationEnabled: true,
-		idSchemaCache:        make(map[int]*Schema),
-		subjectSchemaCache:   make(map[string]*Schema),
-		sem:                  semaphore.NewWeighted(16),
+		schemaCache:          newSchemaCache(),
 	}
 }
 
-// CreateSchemaRegistryClientWithOptions exposes the ability to give custom options
+// CreateSchemaRegistryClientWithOptions exposes the ability to give custom options.
+// Options that can't be applied, such as unreadable certificates, make every
+// request of the client fail: NewSchemaRegistryClient reports them right away.
 func CreateSchemaRegistryClientWithOptions(schemaRegistryURL string, options ...Option) *SchemaRegistryClient {
 	client := CreateSchemaRegistryClient(schemaRegistryURL)
 	for _, option := range options {
 		option(client)
 	}
+	client.configureHTTPClient()
 	return client
 }
 
-// GetSubjects returns a list of all subjects in the registry
-func (client *SchemaRegistryClient) GetSubjects() ([]string, error) {
-	resp, err := client.httpRequest("GET", subjects, nil)
+// NewSchemaRegistryClient creates a client with the given options, like
+// CreateSchemaRegistryClientWithOptions, failing if they can't be applied.
+func NewSchemaRegistryClient(schemaRegistryURL string, options ...Option) (*SchemaRegistryClient, error) {
+	client := CreateSchemaRegistryClientWithOptions(schemaRegistryURL, options...)
+	if client.configErr != nil {
+		return nil, client.configErr
+	}
+	return client, nil
+}
+
+// GetSubjects returns a list of all subjects in the registry. The
+// listing can be narrowed with WithSubjectPrefix and paged through
+// with WithOffset and WithLimit, which are applied by the registry.
+// Clients in a schema context only list the subjects of the context.
+func (client *SchemaRegistryClient) GetSubjects(opts ...ListOption) ([]string, error) {
+	var allSubjects []string
+	err := client.invoke(&Operation{Name: "GetSubjects"}, func(ctx context.Context) (err error) {
+		allSubjects, err = client.getSubjects(ctx, opts)
+		return err
+	})
+	return allSubjects, err
+}
+
+func (client *SchemaRegistryClient) getSubjects(ctx context.Context, opts []ListOption) ([]string, error) {
+	options := newListOptions(opts)
+	options.subjectPrefix = client.qualifySubject(options.subjectPrefix)
+	resp, err := client.httpRequest(ctx, "GET", subjects+options.query(), nil)
 	if err != nil {
 		return nil, err
 	}
@@ -95,14 +176,31 @@ func (client *SchemaRegistryClient) GetSubjects() ([]string, error) {
 // GetLatestSchema gets the schema associated with the given subject.
 // The schema returned contains the last version for that subject.
 func (client *SchemaRegistryClient) GetLatestSchema(subject string, isKey bool) (*Schema, error) {
-	return client.requestSchemaByVersion(subject, "latest", isKey)
+	op := &Operation{Name: "GetLatestSchema", Subject: client.concreteSubject(subject, isKey), Version: "latest"}
+	var schema *Schema
+	err := client.invoke(op, func(ctx context.Context) (err error) {
+		schema, err = client.requestSchemaByVersion(ctx, subject, "latest", isKey)
+		op.Schema = schema
+		return err
+	})
+	return schema, err
 }
 
 // GetSchemaVersions returns a list of versions from a given subject.
-func (client *SchemaRegistryClient) GetSchemaVersions(subject string, isKey bool) ([]int, error) {
+// WithOffset and WithLimit can be used to page through the versions.
+func (client *SchemaRegistryClient) GetSchemaVersions(subject string, isKey bool, opts ...ListOption) ([]int, error) {
+	concreteSubject := client.concreteSubject(subject, isKey)
+	var versions []int
+	err := client.invoke(&Operation{Name: "GetSchemaVersions", Subject: concreteSubject}, func(ctx context.Context) (err error) {
+		versions, err = client.getSchemaVersions(ctx, concreteSubject, opts)
+		return err
+	})
+	return versions, err
+}
 
-	concreteSubject := getConcreteSubject(subject, isKey)
-	resp, err := client.httpRequest("GET", fmt.Sprintf(subjectVersions, concreteSubject), nil)
+func (client *SchemaRegistryClient) getSchemaVersions(ctx context.Context, concreteSubject string, opts []ListOption) ([]int, error) {
+	uri := fmt.Sprintf(subjectVersions, concreteSubject) + newListOptions(opts).query()
+	resp, err := client.httpRequest(ctx, "GET", uri, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -118,37 +216,86 @@ func (client *SchemaRegistryClient) GetSchemaVersions(subject string, isKey bool
 
 // GetSchemaByID gets the schema associated with the given id.
 func (client *SchemaRegistryClient) GetSchemaByID(id int) (*Schema, error) {
-	if schema, ok := client.getFromIDCache(id); ok {
-		return schema, nil
-	}
-	return client.requestSchemaByID(id)
+	op := &Operation{Name: "GetSchemaByID", ID: id}
+	var schema *Schema
+	err := client.invoke(op, func(ctx context.Context) (err error) {
+		if schema, op.CacheHit = client.getFromIDCache(id); !op.CacheHit {
+			schema, err = client.requestSchemaByID(ctx, id)
+		}
+		op.Schema = schema
+		return err
+	})
+	return schema, err
+}
+
+// GetSchemaByGUID gets the schema associated with the given GUID,
+// which newer registries give to schemas along with their ID.
+func (client *SchemaRegistryClient) GetSchemaByGUID(guid string) (*Schema, error) {
+	op := &Operation{Name: "GetSchemaByGUID", GUID: guid}
+	var schema *Schema
+	err := client.invoke(op, func(ctx context.Context) (err error) {
+		if schema, op.CacheHit = client.getFromGUIDCache(guid); !op.CacheHit {
+			schema, err = client.requestSchemaByGUID(ctx, guid)
+		}
+		op.Schema = schema
+		return err
+	})
+	return schema, err
 }
 
 // GetSchemaBySubject gets the schema associated with the given subject.
 func (client *SchemaRegistryClient) GetSchemaBySubject(subject string, isKey bool) (*Schema, error) {
-	concreteSubject := getConcreteSubject(subject, isKey)
-	if schema, ok := client.getFromSubjectCache(concreteSubject); ok {
-		return schema, nil
-	}
-	return client.GetLatestSchema(subject, isKey)
+	concreteSubject := client.concreteSubject(subject, isKey)
+	op := &Operation{Name: "GetSchemaBySubject", Subject: concreteSubject, Version: "latest"}
+	var schema *Schema
+	err := client.invoke(op, func(ctx context.Context) (err error) {
+		if schema, op.CacheHit = client.getFromSubjectCache(concreteSubject); !op.CacheHit {
+			schema, err = client.requestSchemaByVersion(ctx, subject, "latest", isKey)
+		}
+		op.Schema = schema
+		return err
+	})
+	return schema, err
 }
 
 // GetSchemaByVersion gets the schema associated with the given subject.
 // The schema returned contains the version specified as a parameter.
 func (client *SchemaRegistryClient) GetSchemaByVersion(subject, version string, isKey bool) (*Schema, error) {
-	concreteSubject := getConcreteSubject(subject, isKey)
-	cacheKey := cacheKey(concreteSubject, version)
-	if schema, ok := client.getFromVersionCache(cacheKey); ok {
-		return schema, nil
-	}
-	return client.requestSchemaByVersion(subject, version, isKey)
+	concreteSubject := client.concreteSubject(subject, isKey)
+	op := &Operation{Name: "GetSchemaByVersion", Subject: concreteSubject, Version: version}
+	var schema *Schema
+	err := client.invoke(op, func(ctx context.Context) (err error) {
+		if schema, op.CacheHit = client.getFromVersionCache(cacheKey(concreteSubject, version)); !op.CacheHit {
+			schema, err = client.requestSchemaByVersion(ctx, subject, version, isKey)
+		}
+		op.Schema = schema
+		return err
+	})
+	return schema, err
 }
 
 // CreateSchema creates a new schema in Schema Registry and associates
 // with the subject provided. It returns the newly created schema with
 // all its associated information.
 func (client *SchemaRegistryClient) CreateSchema(subject string, schema string, schemaType SchemaType, isKey bool, references ...Reference) (*Schema, error) {
-	concreteSubject := getConcreteSubject(subject, isKey)
+	return client.CreateSchemaWithOptions(subject, schema, schemaType, isKey, WithReferences(references...))
+}
+
+// CreateSchemaWithOptions creates a new schema in Schema Registry like
+// CreateSchema, with its references and normalization set by options.
+func (client *SchemaRegistryClient) CreateSchemaWithOptions(subject string, schema string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (*Schema, error) {
+	op := &Operation{Name: "CreateSchema", Subject: client.concreteSubject(subject, isKey)}
+	var created *Schema
+	err := client.invoke(op, func(ctx context.Context) (err error) {
+		created, err = client.createSchema(ctx, subject, schema, schemaType, isKey, newSchemaOptions(opts))
+		op.Schema = created
+		return err
+	})
+	return created, err
+}
+
+func (client *SchemaRegistryClient) createSchema(ctx context.Context, subject string, schema string, schemaType SchemaType, isKey bool, options *schemaOptions) (*Schema, error) {
+	concreteSubject := client.concreteSubject(subject, isKey)
 
 	switch schemaType {
 	case Avro, Json:
@@ -160,18 +307,19 @@ func (client *SchemaRegistryClient) CreateSchema(subject string, schema string,
 		return nil, fmt.Errorf("invalid schema type. valid values are Avro, Json, or Protobuf")
 	}
 
-	if references == nil {
-		references = make([]Reference, 0)
+	schema, query, err := prepareSchema(schema, schemaType, options)
+	if err != nil {
+		return nil, err
 	}
 
-	schemaReq := schemaRequest{Schema: schema, SchemaType: schemaType.String(), References: references}
+	schemaReq := schemaRequest{Schema: schema, SchemaType: schemaType.String(), References: options.references}
 	schemaBytes, err := json.Marshal(schemaReq)
 	if err != nil {
 		return nil, err
 	}
 
 	payload := bytes.NewBuffer(schemaBytes)
-	resp, err := client.httpRequest("POST", fmt.Sprintf(subjectVersions, concreteSubject), payload)
+	resp, err := client.httpRequest(ctx, "POST", fmt.Sprintf(subjectVersions, concreteSubject)+query, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -189,22 +337,91 @@ func (client *SchemaRegistryClient) CreateSchema(subject string, schema string,
 	// this logic strongly relies on the idempotent guarantees
 	// from Schema Registry, as well as in the best practice
 	// that schemas don't change very often.
-	return client.GetLatestSchema(subject, isKey)
+	return client.requestSchemaByVersion(ctx, subject, "latest", isKey)
+}
+
+// LookupSchema checks if the given schema is registered under the
+// subject provided, and returns it with all its associated information.
+func (client *SchemaRegistryClient) LookupSchema(subject string, schema string, schemaType SchemaType, isKey bool, references ...Reference) (*Schema, error) {
+	return client.LookupSchemaWithOptions(subject, schema, schemaType, isKey, WithReferences(references...))
+}
+
+// LookupSchemaWithOptions looks a schema up like LookupSchema,
+// with its references and normalization set by options.
+func (client *SchemaRegistryClient) LookupSchemaWithOptions(subject string, schema string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (*Schema, error) {
+	op := &Operation{Name: "LookupSchema", Subject: client.concreteSubject(subject, isKey)}
+	var registered *Schema
+	err := client.invoke(op, func(ctx context.Context) (err error) {
+		registered, err = client.lookupSchema(ctx, subject, schema, schemaType, isKey, newSchemaOptions(opts))
+		op.Schema = registered
+		return err
+	})
+	return registered, err
+}
+
+func (client *SchemaRegistryClient) lookupSchema(ctx context.Context, subject string, schema string, schemaType SchemaType, isKey bool, options *schemaOptions) (*Schema, error) {
+	concreteSubject := client.concreteSubject(subject, isKey)
+
+	schema, query, err := prepareSchema(schema, schemaType, options)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaReq := schemaRequest{Schema: schema, SchemaType: schemaType.String(), References: options.references}
+	schemaBytes, err := json.Marshal(schemaReq)
+	if err != nil {
+		return nil, err
+	}
+
+	payload := bytes.NewBuffer(schemaBytes)
+	resp, err := client.httpRequest(ctx, "POST", fmt.Sprintf(subjectBySchema, concreteSubject)+query, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	registeredSchema, err := client.schemaFromResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	client.cacheByVersion(concreteSubject, registeredSchema)
+	return registeredSchema, nil
 }
 
 // IsSchemaCompatible checks if the given schema is compatible with the given subject and version
 // valid versions are versionID and "latest"
 func (client *SchemaRegistryClient) IsSchemaCompatible(subject, schema, version string, schemaType SchemaType, isKey bool) (bool, error) {
-	schemaReq := schemaRequest{Schema: schema, SchemaType: schemaType.String(), References: make([]Reference, 0)}
+	return client.IsSchemaCompatibleWithOptions(subject, schema, version, schemaType, isKey)
+}
+
+// IsSchemaCompatibleWithOptions checks a schema like IsSchemaCompatible,
+// with its references and normalization set by options.
+func (client *SchemaRegistryClient) IsSchemaCompatibleWithOptions(subject, schema, version string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (bool, error) {
+	op := &Operation{Name: "IsSchemaCompatible", Subject: client.concreteSubject(subject, isKey), Version: version}
+	var isCompatible bool
+	err := client.invoke(op, func(ctx context.Context) (err error) {
+		isCompatible, err = client.isSchemaCompatible(ctx, subject, schema, version, schemaType, isKey, newSchemaOptions(opts))
+		return err
+	})
+	return isCompatible, err
+}
+
+func (client *SchemaRegistryClient) isSchemaCompatible(ctx context.Context, subject, schema, version string, schemaType SchemaType, isKey bool, options *schemaOptions) (bool, error) {
+	schema, query, err := prepareSchema(schema, schemaType, options)
+	if err != nil {
+		return false, err
+	}
+
+	schemaReq := schemaRequest{Schema: schema, SchemaType: schemaType.String(), References: options.references}
 	schemaReqBytes, err := json.Marshal(schemaReq)
 	if err != nil {
 		return false, err
 	}
 	payload := bytes.NewBuffer(schemaReqBytes)
 
-	concreteSubject := getConcreteSubject(subject, isKey)
-	url := fmt.Sprintf("/compatibility/subjects/%s/versions/%s", concreteSubject, version)
-	resp, err := client.httpRequest("POST", url, payload)
+	concreteSubject := client.concreteSubject(subject, isKey)
+	url := fmt.Sprintf("/compatibility/subjects/%s/versions/%s", concreteSubject, version) + query
+	resp, err := client.httpRequest(ctx, "POST", url, payload)
 	if err != nil {
 		return false, err
 	}
@@ -218,18 +435,74 @@ func (client *SchemaRegistryClient) IsSchemaCompatible(subject, schema, version
 	return compatibilityResponse.IsCompatible, nil
 }
 
+// CheckSchemaCompatibility checks if the given schema is compatible with all
+// the versions of the given subject, according to the compatibility level
+// configured for it. The registry is asked for a verbose answer, so when the
+// schema is incompatible the result carries the reasons why.
+func (client *SchemaRegistryClient) CheckSchemaCompatibility(subject, schema string, schemaType SchemaType, isKey bool, references ...Reference) (*CompatibilityResult, error) {
+	return client.CheckSchemaCompatibilityWithOptions(subject, schema, schemaType, isKey, WithReferences(references...))
+}
+
+// CheckSchemaCompatibilityWithOptions checks a schema like
+// CheckSchemaCompatibility, with its references and
+// normalization set by options.
+func (client *SchemaRegistryClient) CheckSchemaCompatibilityWithOptions(subject, schema string, schemaType SchemaType, isKey bool, opts ...SchemaOption) (*CompatibilityResult, error) {
+	op := &Operation{Name: "CheckSchemaCompatibility", Subject: client.concreteSubject(subject, isKey)}
+	var result *CompatibilityResult
+	err := client.invoke(op, func(ctx context.Context) (err error) {
+		result, err = client.checkSchemaCompatibility(ctx, subject, schema, schemaType, isKey, newSchemaOptions(opts))
+		return err
+	})
+	return result, err
+}
+
+func (client *SchemaRegistryClient) checkSchemaCompatibility(ctx context.Context, subject, schema string, schemaType SchemaType, isKey bool, options *schemaOptions) (*CompatibilityResult, error) {
+	schema, query, err := prepareSchema(schema, schemaType, options)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaReq := schemaRequest{Schema: schema, SchemaType: schemaType.String(), References: options.references}
+	schemaReqBytes, err := json.Marshal(schemaReq)
+	if err != nil {
+		return nil, err
+	}
+	payload := bytes.NewBuffer(schemaReqBytes)
+
+	concreteSubject := client.concreteSubject(subject, isKey)
+	uri := fmt.Sprintf(compatibilityBySubject, concreteSubject) + "?verbose=true"
+	if len(query) > 0 {
+		uri += "&" + query[1:]
+	}
+	resp, err := client.httpRequest(ctx, "POST", uri, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	compatibilityResult := new(CompatibilityResult)
+	err = json.Unmarshal(resp, compatibilityResult)
+	if err != nil {
+		return nil, err
+	}
+
+	return compatibilityResult, nil
+}
+
 // SetCachingEnabled allows the client to cache any values
 // DeleteSubject deletes
 func (client *SchemaRegistryClient) DeleteSubject(subject string, permanent bool) error {
-	uri := "/subjects/" + subject
-	_, err := client.httpRequest("DELETE", uri, nil)
-	if err != nil || !permanent {
-		return err
-	}
+	subject = client.qualifySubject(subject)
+	return client.invoke(&Operation{Name: "DeleteSubject", Subject: subject}, func(ctx context.Context) error {
+		uri := "/subjects/" + subject
+		_, err := client.httpRequest(ctx, "DELETE", uri, nil)
+		if err != nil || !permanent {
+			return err
+		}
 
-	uri += "?permanent=true"
-	_, err = client.httpRequest("DELETE", uri, nil)
-	return err
+		uri += "?permanent=true"
+		_, err = client.httpRequest(ctx, "DELETE", uri, nil)
+		return err
+	})
 }
 
 // CachingEnabled allows the client to cache any values
@@ -249,9 +522,23 @@ func (client *SchemaRegistryClient) SetCodecCreationEnabled(value bool) {
 	client.codecCreationEnabled = value
 }
 
-func (client *SchemaRegistryClient) requestSchemaByID(id int) (*Schema, error) {
-	uri := fmt.Sprintf(schemaByID, id)
-	resp, err := client.httpRequest("GET", uri, nil)
+// prepareSchema normalizes the schema when the options ask for it,
+// and returns the query that must be added to the request as well.
+// Otherwise the schema is sent as it is.
+func prepareSchema(schema string, schemaType SchemaType, options *schemaOptions) (string, string, error) {
+	if !options.normalize {
+		return schema, "", nil
+	}
+	normalized, err := NormalizeSchema(schema, schemaType)
+	if err != nil {
+		return "", "", err
+	}
+	return normalized, "?normalize=true", nil
+}
+
+func (client *SchemaRegistryClient) requestSchemaByID(ctx context.Context, id int) (*Schema, error) {
+	uri := fmt.Sprintf(schemaByID, id) + client.contextQuery()
+	resp, err := client.httpRequest(ctx, "GET", uri, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -265,11 +552,30 @@ func (client *SchemaRegistryClient) requestSchemaByID(id int) (*Schema, error) {
 	return schema, nil
 }
 
-func (client *SchemaRegistryClient) requestSchemaByVersion(subject, version string, isKey bool) (*Schema, error) {
-	concreteSubject := getConcreteSubject(subject, isKey)
+func (client *SchemaRegistryClient) requestSchemaByGUID(ctx context.Context, guid string) (*Schema, error) {
+	uri := fmt.Sprintf(schemaByGUIDPath, guid)
+	resp, err := client.httpRequest(ctx, "GET", uri, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	schema, err := client.schemaFromResponse(resp)
+	if err != nil {
+		return nil, err
+	}
+	if len(schema.guid) == 0 {
+		schema.guid = guid
+	}
+
+	client.cacheByID(schema)
+	return schema, nil
+}
+
+func (client *SchemaRegistryClient) requestSchemaByVersion(ctx context.Context, subject, version string, isKey bool) (*Schema, error) {
+	concreteSubject := client.concreteSubject(subject, isKey)
 	uri := fmt.Sprintf(subjectByVersion, concreteSubject, version)
 
-	resp, err := client.httpRequest("GET", uri, nil)
+	resp, err := client.httpRequest(ctx, "GET", uri, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -291,43 +597,80 @@ func (client *SchemaRegistryClient) schemaFromResponse(resp []byte) (*Schema, er
 		return nil, err
 	}
 
-	var codec *goavro.Codec
-	if client.isCodecCreationEnabled() {
-		codec, err = goavro.NewCodec(schemaResp.Schema)
+	schema := &Schema{
+		id:         schemaResp.ID,
+		guid:       schemaResp.GUID,
+		schema:     schemaResp.Schema,
+		schemaType: SchemaType(schemaResp.SchemaType),
+		version:    schemaResp.Version,
+	}
+
+	if client.isCodecCreationEnabled() && schema.SchemaType() == Avro {
+		schema.codec, err = client.codecFor(schema.schema)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	return &Schema{
-		id:      schemaResp.ID,
-		schema:  schemaResp.Schema,
-		version: schemaResp.Version,
-		codec:   codec,
-	}, nil
+	return schema, nil
 }
 
-func (client *SchemaRegistryClient) httpRequest(method, uri string, payload io.Reader) ([]byte, error) {
-	client.sem.Acquire(context.Background(), 1)
-	defer client.sem.Release(1)
+// codecFor returns the codec of the given Avro schema, reusing the
+// codec of an equivalent schema when there is one in the cache.
+func (client *SchemaRegistryClient) codecFor(schema string) (*goavro.Codec, error) {
+	avroSchema, err := parseAvroSchema(schema)
+	if err != nil || !client.isCachingEnabled() {
+		return goavro.NewCodec(schema)
+	}
+	key := FingerprintSHA256(avroCanonicalForm(avroSchema, true))
 
-	url := fmt.Sprintf("%s%s", client.schemaRegistryURL, uri)
-	req, err := http.NewRequest(method, url, payload)
+	client.codecCacheLock.RLock()
+	codec, ok := client.codecCache[key]
+	client.codecCacheLock.RUnlock()
+	if ok {
+		return codec, nil
+	}
+
+	codec, err = goavro.NewCodec(schema)
 	if err != nil {
 		return nil, err
 	}
-	if client.credentials != nil {
-		req.SetBasicAuth(client.credentials.username, client.credentials.password)
+	client.codecCacheLock.Lock()
+	defer client.codecCacheLock.Unlock()
+	client.codecCache[key] = codec
+	return codec, nil
+}
+
+func (client *SchemaRegistryClient) httpRequest(ctx context.Context, method, uri string, payload io.Reader) ([]byte, error) {
+	if err := client.sem.Acquire(ctx, 1); err != nil {
+		return nil, err
 	}
-	req.Header.Set("Content-Type", contentType)
-	resp, err := client.httpClient.Do(req)
+	defer client.sem.Release(1)
+
+	// The payload is kept to send the request again when it is
+	// rejected with credentials that have just been rotated.
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = ioutil.ReadAll(payload); err != nil {
+			return nil, err
+		}
+	}
+	resp, err := client.sendRequest(ctx, method, uri, body)
 	if err != nil {
 		return nil, err
 	}
-
-	if resp != nil {
-		defer resp.Body.Close()
+	if refresher, ok := client.authProvider.(AuthRefresher); ok && resp.StatusCode == http.StatusUnauthorized {
+		resp.Body.Close()
+		if err := refresher.Refresh(); err != nil {
+			return nil, err
+		}
+		if resp, err = client.sendRequest(ctx, method, uri, body); err != nil {
+			return nil, err
+		}
 	}
+	defer resp.Body.Close()
+
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
 		return nil, createError(resp.Body)
 	}
@@ -335,6 +678,32 @@ func (client *SchemaRegistryClient) httpRequest(method, uri string, payload io.R
 	return ioutil.ReadAll(resp.Body)
 }
 
+func (client *SchemaRegistryClient) sendRequest(ctx context.Context, method, uri string, body []byte) (*http.Response, error) {
+	if client.configErr != nil {
+		return nil, client.configErr
+	}
+	var payload io.Reader
+	if body != nil {
+		payload = bytes.NewReader(body)
+	}
+	url := fmt.Sprintf("%s%s", client.schemaRegistryURL, uri)
+	req, err := http.NewRequestWithContext(ctx, method, url, payload)
+	if err != nil {
+		return nil, err
+	}
+	client.setHeaders(req)
+	if client.authProvider != nil {
+		if err := client.authProvider.Authenticate(req); err != nil {
+			return nil, err
+		}
+	} else if client.credentials != nil {
+		req.SetBasicAuth(client.credentials.username, client.credentials.password)
+	}
+	req.Header.Set("Content-Type", contentType)
+	client.callHeaderFuncs(req)
+	return client.roundTrip(req)
+}
+
 func (client *SchemaRegistryClient) isCachingEnabled() bool {
 	client.cachingEnabledLock.RLock()
 	defer client.cachingEnabledLock.RUnlock()
@@ -353,7 +722,17 @@ func (client *SchemaRegistryClient) getFromIDCache(id int) (*Schema, bool) {
 	}
 	client.idSchemaCacheLock.RLock()
 	defer client.idSchemaCacheLock.RUnlock()
-	val, exists := client.idSchemaCache[id]
+	val, exists := client.idSchemaCache[schemaIDKey{client.contextName, id}]
+	return val, exists
+}
+
+func (client *SchemaRegistryClient) getFromGUIDCache(guid string) (*Schema, bool) {
+	if !client.isCachingEnabled() {
+		return nil, false
+	}
+	client.guidSchemaCacheLock.RLock()
+	defer client.guidSchemaCacheLock.RUnlock()
+	val, exists := client.guidSchemaCache[guid]
 	return val, exists
 }
 
@@ -397,9 +776,18 @@ func (client *SchemaRegistryClient) cacheByID(schema *Schema) {
 	if !client.isCachingEnabled() {
 		return
 	}
+	if len(schema.guid) > 0 {
+		client.guidSchemaCacheLock.Lock()
+		client.guidSchemaCache[schema.guid] = schema
+		client.guidSchemaCacheLock.Unlock()
+	}
+	// Schemas fetched by GUID from some registries have no ID.
+	if schema.ID() == 0 {
+		return
+	}
 	client.idSchemaCacheLock.Lock()
 	defer client.idSchemaCacheLock.Unlock()
-	client.idSchemaCache[schema.ID()] = schema
+	client.idSchemaCache[schemaIDKey{client.contextName, schema.ID()}] = schema
 }
 
 func cacheKey(subject string, version string) string {
@@ -105,3 +105,188 @@ func TestSchemaRegistryClient_CreateSchemaWithReferences(t *testing.T) {
 	assert.Equal(t, schema.schema, "test2")
 	assert.Equal(t, schema.version, 1)
 }
+
+func TestSchemaRegistryClient_GetSubjectsWithListOptions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/subjects", req.URL.Path)
+		assert.Equal(t, "orders.", req.URL.Query().Get("subjectPrefix"))
+		assert.Equal(t, "10", req.URL.Query().Get("offset"))
+		assert.Equal(t, "2", req.URL.Query().Get("limit"))
+		rw.Write([]byte(`["orders.a-value","orders.b-value"]`))
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClient(server.URL)
+	subjects, err := srClient.GetSubjects(WithSubjectPrefix("orders."), WithOffset(10), WithLimit(2))
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"orders.a-value", "orders.b-value"}, subjects)
+}
+
+func TestSchemaRegistryClient_IteratorsWithoutPaging(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		switch req.URL.Path {
+		case "/subjects":
+			rw.Write([]byte(`["a-value","b-value","c-value"]`))
+		case "/subjects/a-value/versions":
+			rw.Write([]byte(`[1,2]`))
+		}
+	}))
+	defer server.Close()
+	srClient := CreateSchemaRegistryClient(server.URL)
+
+	// The whole list is larger than a page.
+	var subjects []string
+	subjectIterator := NewSubjectIterator(srClient, 2)
+	for subjectIterator.Next() {
+		subjects = append(subjects, subjectIterator.Subject())
+	}
+	assert.NoError(t, subjectIterator.Err())
+	assert.Equal(t, []string{"a-value", "b-value", "c-value"}, subjects)
+	assert.Equal(t, 1, requests)
+
+	// The whole list is exactly a page, and is returned again.
+	requests = 0
+	var versions []int
+	versionIterator := NewVersionIterator(srClient, "a", false, 2)
+	for versionIterator.Next() {
+		versions = append(versions, versionIterator.Version())
+	}
+	assert.NoError(t, versionIterator.Err())
+	assert.Equal(t, []int{1, 2}, versions)
+	assert.Equal(t, 2, requests)
+}
+
+func TestSchemaRegistryClient_CheckSchemaCompatibility(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/compatibility/subjects/test1-value/versions", req.URL.Path)
+		assert.Equal(t, "true", req.URL.Query().Get("verbose"))
+		requestPayload := schemaRequest{
+			Schema:     "test2",
+			SchemaType: Protobuf.String(),
+			References: []Reference{
+				{Name: "test3", Subject: "test4", Version: 1},
+			},
+		}
+		expected, _ := json.Marshal(requestPayload)
+		assert.Equal(t, string(expected), bodyToString(req.Body))
+		rw.Write([]byte(`{"is_compatible":false,"messages":["Field 'a' was removed"]}`))
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClient(server.URL)
+	result, err := srClient.CheckSchemaCompatibility("test1", "test2", Protobuf, false, Reference{Name: "test3", Subject: "test4", Version: 1})
+
+	assert.NoError(t, err)
+	assert.False(t, result.IsCompatible)
+	assert.Equal(t, []string{"Field 'a' was removed"}, result.Messages)
+}
+
+func TestSchemaRegistryClient_LookupSchemaWithNormalization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Equal(t, "/subjects/test1-value", req.URL.Path)
+		assert.Equal(t, "true", req.URL.Query().Get("normalize"))
+		requestPayload := schemaRequest{
+			Schema:     `{"fields":[{"name":"a","type":"int"}],"name":"com.example.A","type":"record"}`,
+			SchemaType: Avro.String(),
+			References: []Reference{},
+		}
+		expected, _ := json.Marshal(requestPayload)
+		assert.Equal(t, string(expected), bodyToString(req.Body))
+		response := schemaResponse{
+			Subject: "test1-value",
+			Version: 2,
+			Schema:  requestPayload.Schema,
+			ID:      7,
+		}
+		responsePayload, _ := json.Marshal(response)
+		rw.Write(responsePayload)
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClient(server.URL)
+	schema, err := srClient.LookupSchemaWithOptions("test1", `{
+		"type": "record", "namespace": "com.example", "name": "A",
+		"fields": [{"name": "a", "type": {"type": "int"}}]
+	}`, Avro, false, WithNormalize())
+
+	assert.NoError(t, err)
+	assert.Equal(t, 7, schema.ID())
+	assert.Equal(t, 2, schema.Version())
+}
+
+func TestSchemaRegistryClient_CreateSchemaWithNormalization(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.Method == "POST" {
+			assert.Equal(t, "/subjects/test1-value/versions", req.URL.Path)
+			assert.Equal(t, "true", req.URL.Query().Get("normalize"))
+			rw.Write([]byte(`{"id":1}`))
+			return
+		}
+		rw.Write([]byte(`{"subject":"test1-value","version":1,"schema":"{\"type\":\"object\"}","id":1,"schemaType":"JSON"}`))
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClient(server.URL)
+	_, err := srClient.CreateSchemaWithOptions("test1", `{ "type": "object" }`, Json, false, WithNormalize())
+	assert.NoError(t, err)
+
+	_, err = srClient.CreateSchemaWithOptions("test1", `{ "type": `, Json, false, WithNormalize())
+	assert.Error(t, err)
+}
+
+func TestSchemaRegistryClient_IsSchemaCompatibleWithoutNormalization(t *testing.T) {
+	const schema = "{\n  \"type\": \"string\"\n}"
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.Empty(t, req.URL.Query().Get("normalize"))
+		requestPayload := schemaRequest{Schema: schema, SchemaType: Avro.String(), References: []Reference{}}
+		expected, _ := json.Marshal(requestPayload)
+		assert.Equal(t, string(expected), bodyToString(req.Body))
+		rw.Write([]byte(`{"is_compatible":true}`))
+	}))
+	defer server.Close()
+
+	// The schema is sent as it is, and isn't validated.
+	srClient := CreateSchemaRegistryClient(server.URL)
+	isCompatible, err := srClient.IsSchemaCompatible("test1", schema, "latest", Avro, false)
+	assert.NoError(t, err)
+	assert.True(t, isCompatible)
+}
+
+func TestSchemaRegistryClient_GetSchemaByGUID(t *testing.T) {
+	const guid = "0f8fad5b-d9cb-469f-a165-70867728950e"
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		requests++
+		switch req.URL.String() {
+		case "/schemas/guids/" + guid:
+			response, _ := json.Marshal(schemaResponse{Schema: "test2", SchemaType: Protobuf.String(), ID: 7})
+			rw.Write(response)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			rw.Write([]byte(`{"error_code": 40403, "message": "Schema not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClient(server.URL)
+	schema, err := srClient.GetSchemaByGUID(guid)
+	assert.NoError(t, err)
+	assert.Equal(t, guid, schema.GUID())
+	assert.Equal(t, 7, schema.ID())
+	assert.Equal(t, Protobuf, schema.SchemaType())
+
+	// Schemas are cached by GUID and by ID.
+	cached, err := srClient.GetSchemaByGUID(guid)
+	assert.NoError(t, err)
+	assert.True(t, schema == cached)
+	byID, err := srClient.GetSchemaByID(7)
+	assert.NoError(t, err)
+	assert.True(t, schema == byID)
+	assert.Equal(t, 1, requests)
+
+	_, err = srClient.GetSchemaByGUID("1b4e28ba-2fa1-11d2-883f-0016d3cca427")
+	assert.Error(t, err)
+}
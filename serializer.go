@@ -0,0 +1,187 @@
+package srclient
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+// Serializer encodes Avro, JSON and Protobuf records in the wire format
+// of Schema Registry: a magic byte, the schema ID in four bytes and the
+// data. Avro values can be the native values of goavro, or structs mapped
+// to the schema as described by Deserializer.DeserializeInto, while JSON
+// values are encoded with encoding/json. Protobuf values are structs
+// mapped the same way, or maps keyed by field name as Deserialize returns
+// them, written after the indexes of their message: the message named by
+// the tag of the blank field of the struct, as in
+//
+//	_ struct{} `protobuf:"com.example.Order"`
+//
+// or else the first message of the schema. Logical types accept their Go
+// values, as listed with LogicalType, unless SetLogicalTypeEnabled
+// turns them off, as well as the values of their underlying types.
+type Serializer struct {
+	client ISchemaRegistryClient
+
+	encodings     map[resolverKey]*avroEncoding
+	protobufs     map[resolverKey]*protobufEncoder
+	encodingsLock sync.RWMutex
+	logicalTypes  logicalTypeMapping
+	plans         *avroTypePlans
+
+	idPlacement SchemaIDPlacement
+	idFormat    SchemaIDFormat
+}
+
+// SerializerOption configures a Serializer.
+type SerializerOption func(*Serializer)
+
+// avroEncoding encodes the records of a schema ID. Schemas that can't
+// be parsed on their own, such as schemas with references, are encoded
+// with their goavro codec instead.
+type avroEncoding struct {
+	codec  *goavro.Codec
+	schema *avroType
+}
+
+// NewSerializer creates a Serializer that uses the given client.
+func NewSerializer(client ISchemaRegistryClient, opts ...SerializerOption) *Serializer {
+	serializer := &Serializer{
+		client:    client,
+		encodings: make(map[resolverKey]*avroEncoding),
+		protobufs: make(map[resolverKey]*protobufEncoder),
+		plans:     newAvroTypePlans(),
+	}
+	for _, opt := range opts {
+		opt(serializer)
+	}
+	return serializer
+}
+
+// Serialize encodes the value with the given schema, which is
+// usually obtained from the client with GetLatestSchema. The schema
+// ID always prefixes the data, in the format set by WithSchemaIDFormat.
+func (serializer *Serializer) Serialize(schema *Schema, value interface{}) ([]byte, error) {
+	prefix, err := serializer.schemaIDBytes(schema)
+	if err != nil {
+		return nil, err
+	}
+	return serializer.appendValue(append(make([]byte, 0, 64), prefix...), schema, value)
+}
+
+// appendValue appends the encoding of the value to buf.
+func (serializer *Serializer) appendValue(buf []byte, schema *Schema, value interface{}) ([]byte, error) {
+	switch schema.SchemaType() {
+	case Avro:
+		return serializer.appendAvro(buf, schema, value)
+	case Json:
+		payload, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		return append(buf, payload...), nil
+	case Protobuf:
+		encoder, err := serializer.protobufEncoderFor(schema)
+		if err != nil {
+			return nil, err
+		}
+		return encoder.appendMessage(buf, value)
+	}
+	return nil, fmt.Errorf("schema %d is a %s schema, which can't be serialized", schema.ID(), schema.SchemaType())
+}
+
+// appendAvro appends the Avro encoding of the value to buf.
+func (serializer *Serializer) appendAvro(buf []byte, schema *Schema, value interface{}) ([]byte, error) {
+	encoding, err := serializer.encodingFor(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	native := value
+	if value != nil && isStructValue(reflect.TypeOf(value)) {
+		if encoding.schema == nil {
+			return nil, fmt.Errorf("unable to map %T to schema %d", value, schema.ID())
+		}
+		plan, err := serializer.plans.planFor(writerKey(schema), encoding.schema, reflect.TypeOf(value))
+		if err != nil {
+			return nil, err
+		}
+		if native, err = plan.encode(reflect.ValueOf(value)); err != nil {
+			return nil, err
+		}
+	}
+
+	if encoding.schema == nil {
+		return encoding.codec.BinaryFromNative(buf, native)
+	}
+	serializer.encodingsLock.RLock()
+	logicalTypes := serializer.logicalTypes
+	serializer.encodingsLock.RUnlock()
+	return appendAvro(buf, encoding.schema, logicalTypes, native)
+}
+
+// SetLogicalTypeEnabled sets whether values of the given logical type
+// are written from the Go type it maps to. All are enabled by default.
+func (serializer *Serializer) SetLogicalTypeEnabled(logicalType LogicalType, enabled bool) {
+	serializer.encodingsLock.Lock()
+	defer serializer.encodingsLock.Unlock()
+	serializer.logicalTypes = serializer.logicalTypes.with(logicalType, enabled)
+	serializer.plans.setLogicalTypes(serializer.logicalTypes)
+}
+
+// writerKey keys the caches of a schema by its ID, or by its GUID
+// for the schemas looked up by GUID that have no ID.
+func writerKey(schema *Schema) resolverKey {
+	key := resolverKey{writerID: schema.ID()}
+	if schema.ID() == 0 {
+		key.writerGUID = schema.GUID()
+	}
+	return key
+}
+
+func (serializer *Serializer) encodingFor(schema *Schema) (*avroEncoding, error) {
+	serializer.encodingsLock.RLock()
+	encoding, ok := serializer.encodings[writerKey(schema)]
+	serializer.encodingsLock.RUnlock()
+	if ok {
+		return encoding, nil
+	}
+
+	encoding = &avroEncoding{}
+	if encoding.schema, _ = parseAvroSchema(schema.Schema()); encoding.schema == nil {
+		if encoding.codec = schema.Codec(); encoding.codec == nil {
+			codec, err := goavro.NewCodec(schema.Schema())
+			if err != nil {
+				return nil, err
+			}
+			encoding.codec = codec
+		}
+	}
+
+	serializer.encodingsLock.Lock()
+	serializer.encodings[writerKey(schema)] = encoding
+	serializer.encodingsLock.Unlock()
+	return encoding, nil
+}
+
+func (serializer *Serializer) protobufEncoderFor(schema *Schema) (*protobufEncoder, error) {
+	serializer.encodingsLock.RLock()
+	encoder, ok := serializer.protobufs[writerKey(schema)]
+	serializer.encodingsLock.RUnlock()
+	if ok {
+		return encoder, nil
+	}
+
+	file, err := parseProtobufSchema(schema.Schema())
+	if err != nil {
+		return nil, err
+	}
+	encoder = newProtobufEncoder(file)
+	serializer.encodingsLock.Lock()
+	serializer.protobufs[writerKey(schema)] = encoder
+	serializer.encodingsLock.Unlock()
+	return encoder, nil
+}
@@ -0,0 +1,157 @@
+package srclient
+
+import (
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSerializer_Structs(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("orders", orderSchema, Avro, false)
+	assert.NoError(t, err)
+
+	serializer := NewSerializer(mockClient)
+	value := order{ID: 1, Lines: []line{}, Attributes: map[string]string{}, Status: "NEW", PlacedAt: time.Unix(0, 0)}
+	_, err = serializer.Serialize(registered, &value)
+	assert.Error(t, err)
+
+	value.Total = big.NewRat(5, 2)
+	record, err := serializer.Serialize(registered, &value)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{magicByte, 0, 0, 0, byte(registered.ID())}, record[:5])
+
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+	var decoded order
+	assert.NoError(t, deserializer.DeserializeInto(record, &decoded))
+	assert.Equal(t, int64(1), decoded.ID)
+	assert.Equal(t, "NEW", decoded.Status)
+	assert.Nil(t, decoded.Parent)
+	assert.Nil(t, decoded.Customer.Email)
+	assert.Equal(t, "5/2", decoded.Total.String())
+
+	assert.Error(t, deserializer.DeserializeInto(record, decoded))
+}
+
+func TestSerializer_NativeValues(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("native", schema, Avro, false)
+	assert.NoError(t, err)
+
+	serializer := NewSerializer(mockClient)
+	record, err := serializer.Serialize(registered, map[string]interface{}{"aField": 5})
+	assert.NoError(t, err)
+
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+	native, err := deserializer.Deserialize(record)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"aField": int32(5)}, native)
+
+	// Reading with a reader schema maps into structs too.
+	withReader, err := NewDeserializer(mockClient, WithReaderSchema(`{
+		"type": "record", "namespace": "com.mycorp.mynamespace", "name": "value_cdc_fake_2",
+		"fields": [{"name": "aField", "type": "long"}]
+	}`))
+	assert.NoError(t, err)
+	var decoded struct {
+		A int64 `avro:"aField"`
+	}
+	assert.NoError(t, withReader.DeserializeInto(record, &decoded))
+	assert.Equal(t, int64(5), decoded.A)
+}
+
+func TestSerializer_Json(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("json", `{"type": "object", "properties": {"name": {"type": "string"}}}`, Json, false)
+	assert.NoError(t, err)
+
+	type person struct {
+		Name string `json:"name"`
+	}
+	record, err := NewSerializer(mockClient).Serialize(registered, person{Name: "Gopher"})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"Gopher"}`, string(record[5:]))
+
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+	var decoded person
+	assert.NoError(t, deserializer.DeserializeInto(record, &decoded))
+	assert.Equal(t, "Gopher", decoded.Name)
+	native, err := deserializer.Deserialize(record)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"name": "Gopher"}, native)
+}
+
+func TestSerializer_Protobuf(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	registered, err := mockClient.CreateSchema("proto", orderProto, Protobuf, false)
+	assert.NoError(t, err)
+	serializer := NewSerializer(mockClient)
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+
+	type line struct {
+		Sku string `protobuf:"sku"`
+		Qty int32  `protobuf:"qty"`
+	}
+	type order struct {
+		_      struct{} `protobuf:"example.Order"`
+		ID     int64    `protobuf:"id"`
+		Status int32    `protobuf:"status"`
+		Lines  []*line  `protobuf:"lines"`
+		Counts map[string]int32
+		Codes  []int32
+		Delta  int32
+	}
+	value := order{ID: 7, Status: 1, Lines: []*line{{Sku: "a", Qty: 2}}, Counts: map[string]int32{"k": 3}, Codes: []int32{1, 2}, Delta: -2}
+	record, err := serializer.Serialize(registered, &value)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{
+		0x02, 0x02, // the message at index 1, Order
+		0x08, 0x07,
+		0x10, 0x01,
+		0x1a, 0x05, 0x0a, 0x01, 'a', 0x10, 0x02,
+		0x22, 0x05, 0x0a, 0x01, 'k', 0x10, 0x03,
+		0x2a, 0x02, 0x01, 0x02,
+		0x30, 0x03,
+	}, record[5:])
+
+	var decoded order
+	assert.NoError(t, deserializer.DeserializeInto(record, &decoded))
+	assert.Equal(t, value, decoded)
+
+	// Enums can be read and written by name as well.
+	var named struct {
+		_      struct{} `protobuf:"example.Order"`
+		Status string   `protobuf:"status"`
+	}
+	assert.NoError(t, deserializer.DeserializeInto(record, &named))
+	assert.Equal(t, "PAID", named.Status)
+	encoded, err := serializer.Serialize(registered, named)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x02, 0x02, 0x10, 0x01}, encoded[5:])
+
+	// Maps are written as the first message, and records are only
+	// read into the structs of their message.
+	encoded, err = serializer.Serialize(registered, map[string]interface{}{"x": "y"})
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0x00, 0x0a, 0x01, 'y'}, encoded[5:])
+	var other struct {
+		_ struct{} `protobuf:"example.Other"`
+		X string
+	}
+	assert.NoError(t, deserializer.DeserializeInto(encoded, &other))
+	assert.Equal(t, "y", other.X)
+	assert.Error(t, deserializer.DeserializeInto(record, &other))
+
+	_, err = serializer.Serialize(registered, struct {
+		_ struct{} `protobuf:"example.Missing"`
+	}{})
+	assert.Error(t, err)
+	_, err = serializer.Serialize(registered, named.Status)
+	assert.Error(t, err)
+}
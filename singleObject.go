@@ -0,0 +1,166 @@
+package srclient
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// singleObjectMarker starts the data written with the single object
+// encoding of Avro, followed by the CRC-64-AVRO fingerprint of the
+// schema in little-endian order and the data.
+var singleObjectMarker = [2]byte{0xC3, 0x01}
+
+// ErrInvalidSingleObject is returned when data doesn't start with
+// the marker and fingerprint of the Avro single object encoding.
+var ErrInvalidSingleObject = errors.New("data is not in the avro single object encoding")
+
+// ErrUnknownFingerprint is returned when the fingerprint of data
+// written with the single object encoding isn't in the index.
+var ErrUnknownFingerprint = errors.New("unknown schema fingerprint")
+
+// FingerprintIndex resolves the CRC-64-AVRO fingerprints used by the
+// single object encoding to the schemas of the registry. The index is
+// populated with schemas already fetched by a client, or with all the
+// versions of a list of subjects, and is safe for concurrent use.
+type FingerprintIndex struct {
+	schemas     map[uint64]*Schema
+	schemasLock sync.RWMutex
+}
+
+// NewFingerprintIndex creates an empty FingerprintIndex.
+func NewFingerprintIndex() *FingerprintIndex {
+	return &FingerprintIndex{schemas: make(map[uint64]*Schema)}
+}
+
+// Add indexes the given Avro schemas. Schemas with the same
+// fingerprint are equivalent, so the first one is kept.
+func (index *FingerprintIndex) Add(schemas ...*Schema) error {
+	for _, schema := range schemas {
+		fingerprint, err := schema.Fingerprint()
+		if err != nil {
+			return fmt.Errorf("unable to index schema %d: %w", schema.ID(), err)
+		}
+		index.schemasLock.Lock()
+		if _, ok := index.schemas[fingerprint]; !ok {
+			index.schemas[fingerprint] = schema
+		}
+		index.schemasLock.Unlock()
+	}
+	return nil
+}
+
+// AddCachedSchemas indexes the Avro schemas the client has cached.
+// Schemas that can't be fingerprinted on their own, such as schemas
+// with references, are left out.
+func (index *FingerprintIndex) AddCachedSchemas(client *SchemaRegistryClient) {
+	client.idSchemaCacheLock.RLock()
+	schemas := make([]*Schema, 0, len(client.idSchemaCache))
+	for _, schema := range client.idSchemaCache {
+		schemas = append(schemas, schema)
+	}
+	client.idSchemaCacheLock.RUnlock()
+
+	for _, schema := range schemas {
+		if schema.SchemaType() == Avro {
+			_ = index.Add(schema)
+		}
+	}
+}
+
+// AddSubjects fetches all the versions of the given subjects with the
+// client and indexes them. Subjects holding other types of schemas
+// than Avro are rejected.
+func (index *FingerprintIndex) AddSubjects(client ISchemaRegistryClient, isKey bool, subjects ...string) error {
+	for _, subject := range subjects {
+		versions, err := client.GetSchemaVersions(subject, isKey)
+		if err != nil {
+			return err
+		}
+		for _, version := range versions {
+			schema, err := client.GetSchemaByVersion(subject, strconv.Itoa(version), isKey)
+			if err != nil {
+				return err
+			}
+			if err := index.Add(schema); err != nil {
+				return fmt.Errorf("subject %s: %w", subject, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Lookup returns the schema with the given fingerprint, if indexed.
+func (index *FingerprintIndex) Lookup(fingerprint uint64) (*Schema, bool) {
+	index.schemasLock.RLock()
+	defer index.schemasLock.RUnlock()
+	schema, ok := index.schemas[fingerprint]
+	return schema, ok
+}
+
+// WithFingerprintIndex sets the index used to find the writer schemas
+// of data written with the single object encoding.
+func WithFingerprintIndex(index *FingerprintIndex) DeserializerOption {
+	return DeserializerOption(func(deserializer *Deserializer) {
+		deserializer.fingerprints = index
+	})
+}
+
+// SerializeSingleObject encodes the value with the given Avro schema
+// in the single object encoding of Avro, which identifies the schema
+// by its fingerprint rather than by its ID in the registry.
+func (serializer *Serializer) SerializeSingleObject(schema *Schema, value interface{}) ([]byte, error) {
+	if schema.SchemaType() != Avro {
+		return nil, fmt.Errorf("schema %d is a %s schema, which can't be written with the single object encoding", schema.ID(), schema.SchemaType())
+	}
+	fingerprint, err := schema.Fingerprint()
+	if err != nil {
+		return nil, err
+	}
+	record := make([]byte, 10, 64)
+	copy(record, singleObjectMarker[:])
+	binary.LittleEndian.PutUint64(record[2:10], fingerprint)
+	return serializer.appendAvro(record, schema, value)
+}
+
+// DeserializeSingleObject decodes data written with the single object
+// encoding of Avro, like Deserialize does for the wire format. Writer
+// schemas are found in the index set with WithFingerprintIndex.
+func (deserializer *Deserializer) DeserializeSingleObject(data []byte) (interface{}, error) {
+	schema, payload, err := deserializer.splitSingleObject(data)
+	if err != nil {
+		return nil, err
+	}
+	native, _, err := deserializer.decode(schema.ID(), schema, payload)
+	return native, err
+}
+
+// DeserializeSingleObjectInto decodes data written with the single
+// object encoding of Avro into the value v points to, like
+// DeserializeInto does for the wire format.
+func (deserializer *Deserializer) DeserializeSingleObjectInto(data []byte, v interface{}) error {
+	schema, payload, err := deserializer.splitSingleObject(data)
+	if err != nil {
+		return err
+	}
+	return deserializer.decodeInto(schema.ID(), schema, payload, v)
+}
+
+// splitSingleObject returns the writer schema and the payload of data
+// written with the single object encoding.
+func (deserializer *Deserializer) splitSingleObject(data []byte) (*Schema, []byte, error) {
+	if len(data) < 10 || data[0] != singleObjectMarker[0] || data[1] != singleObjectMarker[1] {
+		return nil, nil, ErrInvalidSingleObject
+	}
+	if deserializer.fingerprints == nil {
+		return nil, nil, fmt.Errorf("reading the single object encoding requires a fingerprint index")
+	}
+	fingerprint := binary.LittleEndian.Uint64(data[2:10])
+	schema, ok := deserializer.fingerprints.Lookup(fingerprint)
+	if !ok {
+		return nil, nil, fmt.Errorf("%w %016x", ErrUnknownFingerprint, fingerprint)
+	}
+	return schema, data[10:], nil
+}
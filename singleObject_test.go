@@ -0,0 +1,101 @@
+package srclient
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSingleObject_Subjects(t *testing.T) {
+	mockClient := CreateMockSchemaRegistryClient("mock://testingUrl")
+	first, err := mockClient.CreateSchema("native", schema, Avro, false)
+	assert.NoError(t, err)
+	second, err := mockClient.CreateSchema("native", `{
+		"type": "record", "namespace": "com.mycorp.mynamespace", "name": "value_cdc_fake_2",
+		"fields": [{"name": "aField", "type": "long"}, {"name": "other", "type": "string", "default": ""}]
+	}`, Avro, false)
+	assert.NoError(t, err)
+
+	record, err := NewSerializer(mockClient).SerializeSingleObject(first, map[string]interface{}{"aField": 5})
+	assert.NoError(t, err)
+	fingerprint, _ := first.Fingerprint()
+	assert.Equal(t, []byte{0xC3, 0x01}, record[:2])
+	assert.Equal(t, fingerprint, binary.LittleEndian.Uint64(record[2:10]))
+
+	deserializer, err := NewDeserializer(mockClient)
+	assert.NoError(t, err)
+	_, err = deserializer.DeserializeSingleObject(record)
+	assert.Error(t, err)
+
+	index := NewFingerprintIndex()
+	deserializer, err = NewDeserializer(mockClient, WithFingerprintIndex(index))
+	assert.NoError(t, err)
+	_, err = deserializer.DeserializeSingleObject(record)
+	assert.True(t, errors.Is(err, ErrUnknownFingerprint))
+
+	assert.NoError(t, index.AddSubjects(mockClient, false, "native"))
+	indexed, ok := index.Lookup(fingerprint)
+	assert.True(t, ok)
+	assert.Equal(t, first.ID(), indexed.ID())
+	secondFingerprint, _ := second.Fingerprint()
+	_, ok = index.Lookup(secondFingerprint)
+	assert.True(t, ok)
+
+	native, err := deserializer.DeserializeSingleObject(record)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"aField": int32(5)}, native)
+
+	var decoded struct {
+		A int32 `avro:"aField"`
+	}
+	assert.NoError(t, deserializer.DeserializeSingleObjectInto(record, &decoded))
+	assert.Equal(t, int32(5), decoded.A)
+
+	_, err = deserializer.DeserializeSingleObject(record[1:])
+	assert.Equal(t, ErrInvalidSingleObject, err)
+	_, err = deserializer.Deserialize(record)
+	assert.Equal(t, ErrInvalidWireFormat, err)
+}
+
+func TestSingleObject_CachedSchemas(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.String() {
+		case "/schemas/ids/7":
+			response, _ := json.Marshal(schemaResponse{Schema: schema, ID: 7})
+			rw.Write(response)
+		case "/schemas/ids/8":
+			response, _ := json.Marshal(schemaResponse{Schema: `{"type": "string"}`, SchemaType: Json.String(), ID: 8})
+			rw.Write(response)
+		default:
+			assert.Error(t, errors.New("unhandled request"))
+		}
+	}))
+	defer server.Close()
+
+	srClient := CreateSchemaRegistryClient(server.URL)
+	registered, err := srClient.GetSchemaByID(7)
+	assert.NoError(t, err)
+	_, err = srClient.GetSchemaByID(8)
+	assert.NoError(t, err)
+
+	index := NewFingerprintIndex()
+	index.AddCachedSchemas(srClient)
+	fingerprint, _ := registered.Fingerprint()
+	indexed, ok := index.Lookup(fingerprint)
+	assert.True(t, ok)
+	assert.Equal(t, 7, indexed.ID())
+	assert.Len(t, index.schemas, 1)
+
+	record, err := NewSerializer(srClient).SerializeSingleObject(registered, map[string]interface{}{"aField": 1})
+	assert.NoError(t, err)
+	deserializer, err := NewDeserializer(srClient, WithFingerprintIndex(index))
+	assert.NoError(t, err)
+	native, err := deserializer.DeserializeSingleObject(record)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"aField": int32(1)}, native)
+}
@@ -0,0 +1,217 @@
+package srclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// tlsSettings collects the TLS options of a client, which are turned
+// into the transport of its HTTP client once all options are applied,
+// so that they don't depend on the order of WithHttpClient.
+type tlsSettings struct {
+	caFiles    []string
+	caPEMs     [][]byte
+	certFile   string
+	keyFile    string
+	certPEM    []byte
+	keyPEM     []byte
+	serverName string
+	minVersion uint16
+}
+
+func (client *SchemaRegistryClient) tlsSettings() *tlsSettings {
+	if client.tls == nil {
+		client.tls = &tlsSettings{}
+	}
+	return client.tls
+}
+
+// WithCACertFile trusts the certificate authorities of the given PEM
+// file, instead of the ones of the system, to verify the registry.
+// It can be given several times.
+func WithCACertFile(path string) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		settings := client.tlsSettings()
+		settings.caFiles = append(settings.caFiles, path)
+	})
+}
+
+// WithCACertPEM trusts the PEM encoded certificate authorities, instead
+// of the ones of the system, to verify the registry. It can be given
+// several times, and along with WithCACertFile.
+func WithCACertPEM(pem []byte) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		settings := client.tlsSettings()
+		settings.caPEMs = append(settings.caPEMs, pem)
+	})
+}
+
+// WithClientCertFiles authenticates the client with the certificate and
+// key of the given PEM files, for mutual TLS. The files are read again
+// when they change, so renewed certificates are used without a restart.
+func WithClientCertFiles(certFile, keyFile string) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		settings := client.tlsSettings()
+		settings.certFile, settings.keyFile = certFile, keyFile
+	})
+}
+
+// WithClientCertPEM authenticates the client with the PEM encoded
+// certificate and key, for mutual TLS.
+func WithClientCertPEM(certPEM, keyPEM []byte) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		settings := client.tlsSettings()
+		settings.certPEM, settings.keyPEM = certPEM, keyPEM
+	})
+}
+
+// WithTLSServerName sets the name the certificate of the registry is
+// verified against, when it differs from the host of its URL.
+func WithTLSServerName(serverName string) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		client.tlsSettings().serverName = serverName
+	})
+}
+
+// WithMinTLSVersion sets the minimum version of TLS, such as
+// tls.VersionTLS12, accepted when connecting to the registry.
+func WithMinTLSVersion(version uint16) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		client.tlsSettings().minVersion = version
+	})
+}
+
+// configureHTTPClient applies the timeout and the TLS settings to the
+// HTTP client, once all options are applied. The HTTP client given to
+// WithHttpClient is copied rather than changed, as it may be shared.
+// Invalid TLS settings are returned by the requests of the client.
+func (client *SchemaRegistryClient) configureHTTPClient() {
+	if client.timeout == nil && client.tls == nil {
+		return
+	}
+	httpClient := *client.httpClient
+	if client.timeout != nil {
+		httpClient.Timeout = *client.timeout
+	}
+	if client.tls != nil {
+		transport, err := client.tls.transport(httpClient.Transport)
+		if err != nil {
+			client.configErr = fmt.Errorf("invalid TLS configuration: %w", err)
+			return
+		}
+		httpClient.Transport = transport
+	}
+	client.httpClient = &httpClient
+}
+
+func (settings *tlsSettings) transport(base http.RoundTripper) (*http.Transport, error) {
+	var transport *http.Transport
+	switch t := base.(type) {
+	case nil:
+		transport = http.DefaultTransport.(*http.Transport).Clone()
+	case *http.Transport:
+		transport = t.Clone()
+	default:
+		return nil, fmt.Errorf("the transport of the HTTP client is a %T, not an *http.Transport", base)
+	}
+
+	config := transport.TLSClientConfig
+	if config == nil {
+		config = &tls.Config{}
+	}
+	if len(settings.serverName) > 0 {
+		config.ServerName = settings.serverName
+	}
+	switch settings.minVersion {
+	case 0:
+	case tls.VersionTLS10, tls.VersionTLS11, tls.VersionTLS12, tls.VersionTLS13:
+		config.MinVersion = settings.minVersion
+	default:
+		return nil, fmt.Errorf("unknown TLS version %#04x", settings.minVersion)
+	}
+
+	if len(settings.caFiles) > 0 || len(settings.caPEMs) > 0 {
+		pool := x509.NewCertPool()
+		pems := settings.caPEMs
+		for _, path := range settings.caFiles {
+			pem, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			pems = append(pems, pem)
+		}
+		for _, pem := range pems {
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errors.New("no CA certificate found in PEM data")
+			}
+		}
+		config.RootCAs = pool
+	}
+
+	switch {
+	case len(settings.certFile) > 0 || len(settings.keyFile) > 0:
+		files := &certificateFiles{certFile: settings.certFile, keyFile: settings.keyFile}
+		if _, err := files.certificate(); err != nil {
+			return nil, err
+		}
+		config.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			return files.certificate()
+		}
+	case len(settings.certPEM) > 0 || len(settings.keyPEM) > 0:
+		cert, err := tls.X509KeyPair(settings.certPEM, settings.keyPEM)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	transport.TLSClientConfig = config
+	return transport, nil
+}
+
+// certificateFiles loads a client certificate from its files, loading
+// it again when the modification time of either file changes.
+type certificateFiles struct {
+	certFile string
+	keyFile  string
+
+	cert     *tls.Certificate
+	certMod  time.Time
+	keyMod   time.Time
+	certLock sync.Mutex
+}
+
+func (files *certificateFiles) certificate() (*tls.Certificate, error) {
+	files.certLock.Lock()
+	defer files.certLock.Unlock()
+	certInfo, err := os.Stat(files.certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyInfo, err := os.Stat(files.keyFile)
+	if err != nil {
+		return nil, err
+	}
+	if files.cert != nil && certInfo.ModTime().Equal(files.certMod) && keyInfo.ModTime().Equal(files.keyMod) {
+		return files.cert, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(files.certFile, files.keyFile)
+	if err != nil {
+		// Keep the current certificate while the files
+		// are being replaced, one after the other.
+		if files.cert != nil {
+			return files.cert, nil
+		}
+		return nil, err
+	}
+	files.cert, files.certMod, files.keyMod = &cert, certInfo.ModTime(), keyInfo.ModTime()
+	return files.cert, nil
+}
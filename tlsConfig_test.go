@@ -0,0 +1,189 @@
+package srclient
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// issueCertificate creates a certificate signed by the given
+// authority, or a self signed authority when there is none.
+func issueCertificate(t *testing.T, name string, authority *testCertificate, dnsNames ...string) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     dnsNames,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	parent, signer := template, key
+	if authority == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage |= x509.KeyUsageCertSign
+	} else {
+		parent, signer = authority.cert, authority.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+// mutualTLSServer is a registry that requires client certificates
+// issued by the authority, answering with the name of the client.
+func mutualTLSServer(t *testing.T, authority *testCertificate) *httptest.Server {
+	serverCert := issueCertificate(t, "registry", authority, "registry.internal")
+	keyPair, err := tls.X509KeyPair(serverCert.certPEM, serverCert.keyPEM)
+	assert.NoError(t, err)
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(authority.cert)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Header().Set("Connection", "close")
+		rw.Write([]byte(`["` + req.TLS.PeerCertificates[0].Subject.CommonName + `"]`))
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{keyPair},
+		ClientCAs:    clientCAs,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MaxVersion:   tls.VersionTLS12,
+	}
+	server.Config.ErrorLog = log.New(ioutil.Discard, "", 0)
+	server.StartTLS()
+	return server
+}
+
+func TestTLSConfig_MutualTLS(t *testing.T) {
+	authority := issueCertificate(t, "authority", nil)
+	server := mutualTLSServer(t, authority)
+	defer server.Close()
+
+	dir, err := ioutil.TempDir("", "tls")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caFile, certFile, keyFile := filepath.Join(dir, "ca.pem"), filepath.Join(dir, "client.pem"), filepath.Join(dir, "client.key")
+	assert.NoError(t, ioutil.WriteFile(caFile, authority.certPEM, 0600))
+	first := issueCertificate(t, "first", authority)
+	assert.NoError(t, ioutil.WriteFile(certFile, first.certPEM, 0600))
+	assert.NoError(t, ioutil.WriteFile(keyFile, first.keyPEM, 0600))
+
+	// The certificate of the registry is only valid for registry.internal.
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL,
+		WithCACertFile(caFile), WithClientCertFiles(certFile, keyFile), WithTLSServerName("registry.internal"))
+	subjects, err := srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first"}, subjects)
+
+	// Renewed certificates are picked up from the files.
+	second := issueCertificate(t, "second", authority)
+	assert.NoError(t, ioutil.WriteFile(certFile, second.certPEM, 0600))
+	assert.NoError(t, ioutil.WriteFile(keyFile, second.keyPEM, 0600))
+	subjects, err = srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"second"}, subjects)
+
+	srClient = CreateSchemaRegistryClientWithOptions(server.URL,
+		WithCACertPEM(authority.certPEM), WithClientCertPEM(first.certPEM, first.keyPEM), WithTLSServerName("registry.internal"))
+	subjects, err = srClient.GetSubjects()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first"}, subjects)
+
+	// The registry is rejected without its authority or its name.
+	_, err = CreateSchemaRegistryClientWithOptions(server.URL,
+		WithClientCertPEM(first.certPEM, first.keyPEM), WithTLSServerName("registry.internal")).GetSubjects()
+	assert.Error(t, err)
+	_, err = CreateSchemaRegistryClientWithOptions(server.URL,
+		WithCACertPEM(authority.certPEM), WithClientCertPEM(first.certPEM, first.keyPEM)).GetSubjects()
+	assert.Error(t, err)
+
+	// The registry only speaks TLS 1.2.
+	_, err = CreateSchemaRegistryClientWithOptions(server.URL, WithCACertPEM(authority.certPEM),
+		WithClientCertPEM(first.certPEM, first.keyPEM), WithTLSServerName("registry.internal"), WithMinTLSVersion(tls.VersionTLS13)).GetSubjects()
+	assert.Error(t, err)
+}
+
+func TestTLSConfig_Options(t *testing.T) {
+	// Options apply whatever their order, without changing
+	// the HTTP client given to WithHttpClient.
+	httpClient := &http.Client{Timeout: time.Minute}
+	srClient := CreateSchemaRegistryClientWithOptions("https://registry.internal",
+		WithTimeout(time.Second), WithTLSServerName("other.internal"), WithHttpClient(httpClient))
+	assert.NoError(t, srClient.configErr)
+	assert.Equal(t, time.Second, srClient.httpClient.Timeout)
+	assert.Equal(t, "other.internal", srClient.httpClient.Transport.(*http.Transport).TLSClientConfig.ServerName)
+	assert.Equal(t, time.Minute, httpClient.Timeout)
+	assert.Nil(t, httpClient.Transport)
+
+	srClient = CreateSchemaRegistryClientWithOptions("https://registry.internal", WithHttpClient(httpClient), WithTimeout(time.Second))
+	assert.Equal(t, time.Second, srClient.httpClient.Timeout)
+	assert.Equal(t, time.Minute, httpClient.Timeout)
+
+	// Invalid settings fail the requests.
+	srClient = CreateSchemaRegistryClientWithOptions("https://registry.internal", WithCACertPEM([]byte("not a certificate")))
+	_, err := srClient.GetSubjects()
+	assert.EqualError(t, err, "invalid TLS configuration: no CA certificate found in PEM data")
+	_, err = CreateSchemaRegistryClientWithOptions("https://registry.internal", WithClientCertFiles("missing.pem", "missing.key")).GetSubjects()
+	assert.Error(t, err)
+
+	unsupported := &http.Client{Transport: roundTripperFunc(func(*http.Request) (*http.Response, error) { return nil, nil })}
+	srClient = CreateSchemaRegistryClientWithOptions("https://registry.internal", WithHttpClient(unsupported), WithMinTLSVersion(tls.VersionTLS12))
+	assert.Error(t, srClient.configErr)
+}
+
+func TestTLSConfig_NewClientFails(t *testing.T) {
+	_, err := NewSchemaRegistryClient("https://registry.internal", WithCACertPEM([]byte("not a certificate")))
+	assert.EqualError(t, err, "invalid TLS configuration: no CA certificate found in PEM data")
+	_, err = NewSchemaRegistryClient("https://registry.internal", WithCACertFile("missing.pem"))
+	assert.Error(t, err)
+	_, err = NewSchemaRegistryClient("https://registry.internal", WithClientCertPEM([]byte("not a certificate"), []byte("not a key")))
+	assert.Error(t, err)
+	_, err = NewSchemaRegistryClient("https://registry.internal", WithMinTLSVersion(0x0305))
+	assert.EqualError(t, err, "invalid TLS configuration: unknown TLS version 0x0305")
+
+	srClient, err := NewSchemaRegistryClient("https://registry.internal", WithMinTLSVersion(tls.VersionTLS13))
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(tls.VersionTLS13), srClient.httpClient.Transport.(*http.Transport).TLSClientConfig.MinVersion)
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (fn roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return fn(req)
+}
@@ -0,0 +1,124 @@
+package srclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Tracer starts the spans that trace the calls to Schema Registry.
+// It is small enough to be implemented on top of any tracing library;
+// the otelsrclient module adapts OpenTelemetry to it.
+type Tracer interface {
+	Start(ctx context.Context, name string, kind SpanKind) (context.Context, Span)
+}
+
+// SpanKind tells what a span traces.
+type SpanKind int
+
+const (
+	// SpanKindInternal is the kind of the spans of operations, which
+	// run in the client.
+	SpanKindInternal SpanKind = iota
+	// SpanKindClient is the kind of the spans of HTTP requests sent
+	// to Schema Registry.
+	SpanKindClient
+)
+
+// Span is a span started by a Tracer.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	RecordError(err error)
+	End()
+}
+
+// Attributes set on the spans of operations and HTTP requests.
+const (
+	SubjectAttribute    = "schema_registry.subject"
+	VersionAttribute    = "schema_registry.version"
+	SchemaIDAttribute   = "schema_registry.schema.id"
+	SchemaGUIDAttribute = "schema_registry.schema.guid"
+	CacheHitAttribute   = "schema_registry.cache_hit"
+	HTTPMethodAttribute = "http.request.method"
+	HTTPURLAttribute    = "url.full"
+	HTTPStatusAttribute = "http.response.status_code"
+)
+
+// WithTracer traces the operations of the client, each in a span named
+// after the method called, such as GetSchemaByID, with the HTTP requests
+// they send in child spans. Operation spans tell the subject, version
+// and schema ID involved, and whether the schema came from the cache;
+// HTTP spans tell the method, URL and status code. The spans are made
+// by interceptors, see WithOperationInterceptor, chained where this
+// option is given.
+func WithTracer(tracer Tracer) Option {
+	return Option(func(client *SchemaRegistryClient) {
+		WithOperationInterceptor(traceOperation(tracer))(client)
+		WithHTTPInterceptor(traceHTTP(tracer))(client)
+	})
+}
+
+func traceOperation(tracer Tracer) OperationInterceptor {
+	return func(ctx context.Context, op *Operation, next OperationInvoker) error {
+		ctx, span := tracer.Start(ctx, op.Name, SpanKindInternal)
+		defer span.End()
+
+		err := next(ctx, op)
+		if len(op.Subject) > 0 {
+			span.SetAttribute(SubjectAttribute, op.Subject)
+		}
+		if len(op.Version) > 0 {
+			span.SetAttribute(VersionAttribute, op.Version)
+		}
+		id, guid := op.ID, op.GUID
+		if op.Schema != nil {
+			if op.Schema.ID() > 0 {
+				id = op.Schema.ID()
+			}
+			if len(op.Schema.GUID()) > 0 {
+				guid = op.Schema.GUID()
+			}
+		}
+		if id > 0 {
+			span.SetAttribute(SchemaIDAttribute, id)
+		}
+		if len(guid) > 0 {
+			span.SetAttribute(SchemaGUIDAttribute, guid)
+		}
+		switch op.Name {
+		case "GetSchemaByID", "GetSchemaByGUID", "GetSchemaBySubject", "GetSchemaByVersion":
+			span.SetAttribute(CacheHitAttribute, op.CacheHit)
+		}
+		if err != nil {
+			span.RecordError(err)
+		}
+		return err
+	}
+}
+
+func traceHTTP(tracer Tracer) HTTPInterceptor {
+	return func(req *http.Request, next HTTPInvoker) (*http.Response, error) {
+		ctx, span := tracer.Start(req.Context(), "HTTP "+req.Method, SpanKindClient)
+		defer span.End()
+		span.SetAttribute(HTTPMethodAttribute, req.Method)
+		span.SetAttribute(HTTPURLAttribute, redactURL(req))
+
+		resp, err := next(req.WithContext(ctx))
+		if err != nil {
+			span.RecordError(err)
+			return resp, err
+		}
+		span.SetAttribute(HTTPStatusAttribute, resp.StatusCode)
+		if resp.StatusCode >= 400 {
+			span.RecordError(fmt.Errorf("HTTP %s", resp.Status))
+		}
+		return resp, nil
+	}
+}
+
+// redactURL returns the URL of the request without its credentials.
+func redactURL(req *http.Request) string {
+	u := *req.URL
+	u.User = nil
+	return u.String()
+}
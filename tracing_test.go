@@ -0,0 +1,102 @@
+package srclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordedSpan struct {
+	name       string
+	kind       SpanKind
+	parent     *recordedSpan
+	attributes map[string]interface{}
+	errors     []error
+	ended      bool
+}
+
+func (span *recordedSpan) SetAttribute(key string, value interface{}) {
+	span.attributes[key] = value
+}
+
+func (span *recordedSpan) RecordError(err error) {
+	span.errors = append(span.errors, err)
+}
+
+func (span *recordedSpan) End() {
+	span.ended = true
+}
+
+type spanKey struct{}
+
+// recordingTracer records spans, with the parent found in the context.
+type recordingTracer struct {
+	spans []*recordedSpan
+}
+
+func (tracer *recordingTracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, Span) {
+	parent, _ := ctx.Value(spanKey{}).(*recordedSpan)
+	span := &recordedSpan{name: name, kind: kind, parent: parent, attributes: map[string]interface{}{}}
+	tracer.spans = append(tracer.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func TestTracing(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/subjects/test1-value/versions":
+			rw.Write([]byte(`{"id": 1}`))
+		case "/subjects/test1-value/versions/latest":
+			response, _ := json.Marshal(schemaResponse{Subject: "test1-value", Version: 1, Schema: "test2", SchemaType: Json.String(), ID: 1})
+			rw.Write(response)
+		default:
+			rw.WriteHeader(http.StatusNotFound)
+			rw.Write([]byte(`{"error_code": 40403, "message": "Schema not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	tracer := &recordingTracer{}
+	srClient := CreateSchemaRegistryClientWithOptions(server.URL, WithTracer(tracer))
+	_, err := srClient.CreateSchema("test1", "test2", Json, false)
+	assert.NoError(t, err)
+	_, err = srClient.GetSchemaByID(1)
+	assert.NoError(t, err)
+	_, err = srClient.GetSchemaByID(2)
+	assert.Error(t, err)
+
+	assert.Len(t, tracer.spans, 6)
+	for _, span := range tracer.spans {
+		assert.True(t, span.ended)
+	}
+
+	create, post, get := tracer.spans[0], tracer.spans[1], tracer.spans[2]
+	assert.Equal(t, "CreateSchema", create.name)
+	assert.Equal(t, SpanKindInternal, create.kind)
+	assert.Nil(t, create.parent)
+	assert.Equal(t, map[string]interface{}{SubjectAttribute: "test1-value", SchemaIDAttribute: 1}, create.attributes)
+	assert.Equal(t, "HTTP POST", post.name)
+	assert.Equal(t, SpanKindClient, post.kind)
+	assert.Equal(t, create, post.parent)
+	assert.Equal(t, "POST", post.attributes[HTTPMethodAttribute])
+	assert.Equal(t, server.URL+"/subjects/test1-value/versions", post.attributes[HTTPURLAttribute])
+	assert.Equal(t, 200, post.attributes[HTTPStatusAttribute])
+	assert.Equal(t, "HTTP GET", get.name)
+	assert.Equal(t, create, get.parent)
+
+	// The schema created was cached by ID.
+	cached := tracer.spans[3]
+	assert.Equal(t, map[string]interface{}{SchemaIDAttribute: 1, CacheHitAttribute: true}, cached.attributes)
+	assert.Empty(t, cached.errors)
+
+	missing, notFound := tracer.spans[4], tracer.spans[5]
+	assert.Equal(t, false, missing.attributes[CacheHitAttribute])
+	assert.EqualError(t, missing.errors[0], "40403: Schema not found")
+	assert.Equal(t, missing, notFound.parent)
+	assert.Equal(t, 404, notFound.attributes[HTTPStatusAttribute])
+	assert.Len(t, notFound.errors, 1)
+}